@@ -0,0 +1,18 @@
+package sonyflake
+
+import "time"
+
+// IDAge returns how long ago the given ID generated by the Sonyflake was generated.
+// Since IDs carry no precision below the time unit, the age is measured from the start of the time unit
+// in which the ID was generated, so it can exceed the actual age by up to a time unit.
+// IDAge is negative for an ID of the future, such as one generated on a host with a clock ahead.
+func (sf *Sonyflake) IDAge(id uint64) time.Duration {
+	elapsedTime := int64(id >> sf.shiftTime & (1<<sf.bitsTime - 1))
+	return time.Duration(time.Now().UnixNano() - (sf.startTime+elapsedTime)*sf.timeUnit)
+}
+
+// IDOlderThan reports whether the given ID generated by the Sonyflake was generated more than d ago,
+// which is what retention jobs and caches keyed by IDs check against a TTL.
+func (sf *Sonyflake) IDOlderThan(id uint64, d time.Duration) bool {
+	return sf.IDAge(id) > d
+}
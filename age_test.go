@@ -0,0 +1,33 @@
+package sonyflake
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIDAge(t *testing.T) {
+	sf, err := New(Settings{
+		StartTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		MachineID: func() (uint16, error) { return 1, nil },
+		TimeUnit:  time.Second,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	id, err := sf.Compose(time.Now().Add(-time.Hour), 0, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	age := sf.IDAge(id)
+	if age < time.Hour || age > time.Hour+2*time.Second {
+		t.Errorf("unexpected age: %s", age)
+	}
+	if !sf.IDOlderThan(id, 59*time.Minute) {
+		t.Errorf("id must be older than 59m: %s", age)
+	}
+	if sf.IDOlderThan(id, 61*time.Minute) {
+		t.Errorf("id must not be older than 61m: %s", age)
+	}
+}
@@ -0,0 +1,26 @@
+package sonyflake
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// Anonymize returns a pseudonym of the given Sonyflake ID keyed by the given secret key,
+// which is the first 64 bits of HMAC-SHA256 of the ID in big-endian.
+//
+// The same ID and key always map to the same pseudonym, so that events keyed by IDs can be joined after export.
+// Without the key, neither the ID nor its time, sequence number and machine ID can be recovered from the pseudonym,
+// and pseudonyms reveal no order or locality of the IDs.
+// Even with the key, recovering the ID requires a brute-force search over candidate IDs,
+// so the key must be kept secret by the exporter and rotated to unlink past exports.
+//
+// Pseudonyms of distinct IDs may collide with a negligible probability, about n*n/2^65 among n IDs.
+func Anonymize(id uint64, key []byte) uint64 {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], id)
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(b[:])
+	return binary.BigEndian.Uint64(mac.Sum(nil))
+}
@@ -0,0 +1,31 @@
+package sonyflake
+
+import "testing"
+
+func TestAnonymize(t *testing.T) {
+	key := []byte("secret")
+
+	// HMAC-SHA256 of 00 00 00 00 00 00 00 01 keyed by "secret"
+	if p := Anonymize(1, key); p != 0x3be7bf5eaadba158 {
+		t.Errorf("unexpected pseudonym: %#x", p)
+	}
+
+	if Anonymize(1, key) != Anonymize(1, key) {
+		t.Errorf("pseudonym must be deterministic")
+	}
+	if Anonymize(1, key) == Anonymize(2, key) {
+		t.Errorf("pseudonyms of distinct ids must differ")
+	}
+	if Anonymize(1, key) == Anonymize(1, []byte("other")) {
+		t.Errorf("pseudonyms must depend on the key")
+	}
+
+	seen := make(map[uint64]bool)
+	for id := uint64(0); id < 10000; id++ {
+		p := Anonymize(id, key)
+		if seen[p] {
+			t.Fatalf("duplicated pseudonym: %#x", p)
+		}
+		seen[p] = true
+	}
+}
@@ -0,0 +1,168 @@
+// Package audit verifies streams of Sonyflake IDs, such as after a suspected clock incident.
+//
+// An Auditor checks that each ID satisfies the invariants of its layout,
+// and detects duplicated IDs and IDs going back in time on the same machine.
+package audit
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/sony/sonyflake"
+)
+
+// Settings configures Auditor:
+//
+// Layout is the layout of the audited IDs.
+//
+// MaxMachineID is the greatest machine ID assigned in the deployment.
+// If MaxMachineID is 0, any machine ID that fits in Layout.BitsMachineID bits is valid.
+//
+// Now returns the current time, after which no ID can have been generated.
+// If Now is nil, time.Now is used.
+type Settings struct {
+	Layout       sonyflake.Layout
+	MaxMachineID uint16
+	Now          func() time.Time
+}
+
+// Violation is an ID which does not satisfy an invariant of the layout.
+type Violation struct {
+	ID     uint64
+	Reason string
+}
+
+// Regression is an ID generated at an earlier time than the previous ID of the same machine.
+type Regression struct {
+	ID        uint64
+	Previous  uint64
+	MachineID uint16
+}
+
+// Report is the result of an audit.
+type Report struct {
+	Total       int
+	Violations  []Violation
+	Duplicates  []uint64
+	Regressions []Regression
+}
+
+// OK returns true if the audit found no problem.
+func (r Report) OK() bool {
+	return len(r.Violations) == 0 && len(r.Duplicates) == 0 && len(r.Regressions) == 0
+}
+
+// WriteTo writes a human-readable summary of the Report to w.
+func (r Report) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "ids: %d\n", r.Total)
+	fmt.Fprintf(&b, "violations: %d\n", len(r.Violations))
+	for _, v := range r.Violations {
+		fmt.Fprintf(&b, "  %d: %s\n", v.ID, v.Reason)
+	}
+	fmt.Fprintf(&b, "duplicates: %d\n", len(r.Duplicates))
+	for _, id := range r.Duplicates {
+		fmt.Fprintf(&b, "  %d\n", id)
+	}
+	fmt.Fprintf(&b, "regressions: %d\n", len(r.Regressions))
+	for _, g := range r.Regressions {
+		fmt.Fprintf(&b, "  %d after %d on machine %d\n", g.ID, g.Previous, g.MachineID)
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// Auditor audits IDs added in the order in which they were generated or stored.
+type Auditor struct {
+	st     Settings
+	seen   map[uint64]struct{}
+	last   map[uint16]uint64
+	report Report
+}
+
+// New returns a new Auditor configured with the given Settings.
+// It returns the error of Layout.Validate if Settings.Layout is invalid.
+func New(st Settings) (*Auditor, error) {
+	if err := st.Layout.Validate(); err != nil {
+		return nil, err
+	}
+	if st.Now == nil {
+		st.Now = time.Now
+	}
+	return &Auditor{
+		st:   st,
+		seen: make(map[uint64]struct{}),
+		last: make(map[uint16]uint64),
+	}, nil
+}
+
+// Add audits the given ID.
+func (a *Auditor) Add(id uint64) {
+	a.report.Total++
+
+	parts := a.st.Layout.Decompose(id)
+	machineID := uint16(parts["machine-id"])
+
+	if !a.st.Layout.UseMSB && parts["msb"] != 0 {
+		a.violate(id, "most significant bit is set")
+	}
+	if t := a.st.Layout.Time(id); t.After(a.st.Now()) {
+		a.violate(id, fmt.Sprintf("time %s is in the future", t.Format(time.RFC3339Nano)))
+	}
+	if a.st.MaxMachineID != 0 && machineID > a.st.MaxMachineID {
+		a.violate(id, fmt.Sprintf("machine id %d is greater than %d", machineID, a.st.MaxMachineID))
+	}
+
+	if _, ok := a.seen[id]; ok {
+		a.report.Duplicates = append(a.report.Duplicates, id)
+		return
+	}
+	a.seen[id] = struct{}{}
+
+	if previous, ok := a.last[machineID]; ok && parts["time"] < a.st.Layout.Decompose(previous)["time"] {
+		a.report.Regressions = append(a.report.Regressions, Regression{ID: id, Previous: previous, MachineID: machineID})
+	}
+	a.last[machineID] = id
+}
+
+func (a *Auditor) violate(id uint64, reason string) {
+	a.report.Violations = append(a.report.Violations, Violation{ID: id, Reason: reason})
+}
+
+// Report returns the Report of the IDs added so far.
+func (a *Auditor) Report() Report {
+	return a.report
+}
+
+// Audit audits the IDs read from r, one per line, in any format accepted by sonyflake.Parse.
+// Empty lines are skipped.
+// Audit returns an error if r cannot be read or a line is not an ID.
+func Audit(r io.Reader, st Settings) (Report, error) {
+	a, err := New(st)
+	if err != nil {
+		return Report{}, err
+	}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		s := strings.TrimSpace(scanner.Text())
+		if s == "" {
+			continue
+		}
+
+		id, err := sonyflake.Parse(s)
+		if err != nil {
+			return Report{}, fmt.Errorf("line %d: %w", line, err)
+		}
+		a.Add(id)
+	}
+	if err := scanner.Err(); err != nil {
+		return Report{}, err
+	}
+
+	return a.Report(), nil
+}
@@ -0,0 +1,122 @@
+package audit
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sony/sonyflake"
+)
+
+func newSettings(t *testing.T) (Settings, *sonyflake.Sonyflake) {
+	sf, err := sonyflake.New(sonyflake.Settings{
+		MachineID: func() (uint16, error) { return 1, nil },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return Settings{Layout: sf.Layout()}, sf
+}
+
+func TestAuditorOK(t *testing.T) {
+	st, sf := newSettings(t)
+	a, err := New(st)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for i := 0; i < 1000; i++ {
+		id, err := sf.NextID()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		a.Add(id)
+	}
+
+	report := a.Report()
+	if !report.OK() || report.Total != 1000 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+}
+
+func TestAuditorFindings(t *testing.T) {
+	st, sf := newSettings(t)
+	st.MaxMachineID = 10
+
+	now := time.Now()
+	compose := func(t0 time.Time, machineID uint16) uint64 {
+		id, err := sf.Compose(t0, 0, machineID)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		return id
+	}
+
+	a, err := New(st)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	earlier := compose(now.Add(-time.Minute), 1)
+	later := compose(now.Add(-time.Second), 1)
+	future := compose(now.Add(time.Hour), 2)
+	foreign := compose(now.Add(-time.Second), 11)
+
+	a.Add(later)
+	a.Add(earlier)
+	a.Add(later)
+	a.Add(future)
+	a.Add(foreign)
+	a.Add(1<<63 | 3)
+
+	report := a.Report()
+	if report.Total != 6 {
+		t.Errorf("unexpected total: %d", report.Total)
+	}
+	if len(report.Duplicates) != 1 || report.Duplicates[0] != later {
+		t.Errorf("unexpected duplicates: %v", report.Duplicates)
+	}
+	if len(report.Regressions) != 1 || report.Regressions[0] != (Regression{ID: earlier, Previous: later, MachineID: 1}) {
+		t.Errorf("unexpected regressions: %v", report.Regressions)
+	}
+	if len(report.Violations) != 3 {
+		t.Fatalf("unexpected violations: %v", report.Violations)
+	}
+	for i, id := range []uint64{future, foreign, 1<<63 | 3} {
+		if report.Violations[i].ID != id {
+			t.Errorf("unexpected violation: %v", report.Violations[i])
+		}
+	}
+	if report.OK() {
+		t.Error("report must not be ok")
+	}
+
+	var b bytes.Buffer
+	if _, err := report.WriteTo(&b); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.Contains(b.String(), "duplicates: 1\n") {
+		t.Errorf("unexpected summary: %s", b.String())
+	}
+}
+
+func TestAudit(t *testing.T) {
+	st, _ := newSettings(t)
+
+	report, err := Audit(strings.NewReader("1\n\n0x2\n2\n"), st)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if report.Total != 3 || len(report.Duplicates) != 1 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+
+	if _, err := Audit(strings.NewReader("1\nfoo\n"), st); err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestNewInvalidLayout(t *testing.T) {
+	if _, err := New(Settings{}); err != sonyflake.ErrInvalidTimeUnit {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -3,28 +3,48 @@ package awsutil
 
 import (
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"os/exec"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/sony/sonyflake"
 )
 
-func amazonEC2PrivateIPv4() (net.IP, error) {
-	res, err := http.Get("http://169.254.169.254/latest/meta-data/local-ipv4")
+const (
+	amazonEC2PrivateIPv4Endpoint = "http://169.254.169.254/latest/meta-data/local-ipv4"
+	amazonEC2AZIDEndpoint        = "http://169.254.169.254/latest/meta-data/placement/availability-zone-id"
+)
+
+func amazonEC2Metadata(endpoint string) (string, error) {
+	res, err := http.Get(endpoint)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 	defer res.Body.Close()
 
 	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", errors.New(res.Status)
+	}
+	return string(body), nil
+}
+
+func amazonEC2PrivateIPv4() (net.IP, error) {
+	body, err := amazonEC2Metadata(amazonEC2PrivateIPv4Endpoint)
 	if err != nil {
 		return nil, err
 	}
 
-	ip := net.ParseIP(string(body))
+	ip := net.ParseIP(body)
 	if ip == nil {
 		return nil, errors.New("invalid ip address")
 	}
@@ -34,15 +54,76 @@ func amazonEC2PrivateIPv4() (net.IP, error) {
 // AmazonEC2MachineID retrieves the private IP address of the Amazon EC2 instance
 // and returns its lower 16 bits.
 // It works correctly on Docker as well.
+// Its errors name the instance metadata endpoint it attempted.
 func AmazonEC2MachineID() (uint16, error) {
 	ip, err := amazonEC2PrivateIPv4()
 	if err != nil {
-		return 0, err
+		return 0, fmt.Errorf("amazon ec2 machine id from %s: %w", amazonEC2PrivateIPv4Endpoint, err)
 	}
 
 	return uint16(ip[2])<<8 + uint16(ip[3]), nil
 }
 
+// ErrAZIndexOverflow is returned by the function of AmazonEC2AZMachineID
+// if the index of the availability zone does not fit in its bits.
+var ErrAZIndexOverflow = errors.New("availability zone index overflows its bits")
+
+// AmazonEC2AZMachineID returns a function usable as Settings.MachineID
+// which composes the machine ID of bitsMachineID bits from the index of the availability zone of the Amazon EC2 instance
+// in the upper bitsAZ bits and the lower bits of its private IP address in the rest,
+// so that fleets spanning availability zones with overlapping subnet plans avoid collisions of truncated IP addresses.
+//
+// The index is the number of the availability zone ID, such as 4 of "apne1-az4", minus 1,
+// which identifies the same zone across AWS accounts unlike the zone name.
+// The function returns sonyflake.ErrInvalidBitsMachineID if bitsAZ is not positive or bitsMachineID is not greater
+// than bitsAZ or more than 16, and ErrAZIndexOverflow if the index does not fit in bitsAZ bits.
+func AmazonEC2AZMachineID(bitsAZ, bitsMachineID int) func() (uint16, error) {
+	return func() (uint16, error) {
+		if bitsAZ <= 0 || bitsMachineID <= bitsAZ || bitsMachineID > sonyflake.BitLenMachineID {
+			return 0, sonyflake.ErrInvalidBitsMachineID
+		}
+
+		azID, err := amazonEC2Metadata(amazonEC2AZIDEndpoint)
+		if err != nil {
+			return 0, fmt.Errorf("amazon ec2 availability zone from %s: %w", amazonEC2AZIDEndpoint, err)
+		}
+		index, err := azIndex(azID)
+		if err != nil {
+			return 0, err
+		}
+
+		ip, err := amazonEC2PrivateIPv4()
+		if err != nil {
+			return 0, fmt.Errorf("amazon ec2 machine id from %s: %w", amazonEC2PrivateIPv4Endpoint, err)
+		}
+
+		return composeMachineID(index, bitsAZ, uint16(ip[2])<<8+uint16(ip[3]), bitsMachineID)
+	}
+}
+
+// azIndex returns the index of the given availability zone ID, such as 3 of "use1-az4".
+func azIndex(azID string) (uint16, error) {
+	i := strings.LastIndex(azID, "-az")
+	if i < 0 {
+		return 0, fmt.Errorf("invalid availability zone id %q", azID)
+	}
+	n, err := strconv.ParseUint(azID[i+len("-az"):], 10, 16)
+	if err != nil || n == 0 {
+		return 0, fmt.Errorf("invalid availability zone id %q", azID)
+	}
+	return uint16(n - 1), nil
+}
+
+// composeMachineID places the given index in the upper bitsIndex bits of a machine ID of bitsMachineID bits
+// above the lower bits of the given host part.
+func composeMachineID(index uint16, bitsIndex int, host uint16, bitsMachineID int) (uint16, error) {
+	bitsHost := uint(bitsMachineID - bitsIndex)
+	if uint32(index) >= 1<<uint(bitsIndex) {
+		return 0, ErrAZIndexOverflow
+	}
+	return index<<bitsHost | host&(1<<bitsHost-1), nil
+}
+
 // TimeDifference returns the time difference between the localhost and the given NTP server.
 func TimeDifference(server string) (time.Duration, error) {
 	output, err := exec.Command("/usr/sbin/ntpdate", "-q", server).CombinedOutput()
@@ -0,0 +1,54 @@
+package awsutil
+
+import "testing"
+
+func TestAZIndex(t *testing.T) {
+	tests := []struct {
+		azID  string
+		index uint16
+		ok    bool
+	}{
+		{"apne1-az4", 3, true},
+		{"use1-az1", 0, true},
+		{"usw2-lax1-az2", 1, true},
+		{"us-east-1a", 0, false},
+		{"use1-az0", 0, false},
+	}
+
+	for _, tc := range tests {
+		index, err := azIndex(tc.azID)
+		if (err == nil) != tc.ok || index != tc.index {
+			t.Errorf("unexpected index of %s: %d, %v", tc.azID, index, err)
+		}
+	}
+}
+
+func TestComposeMachineID(t *testing.T) {
+	machineID, err := composeMachineID(3, 3, 0x1234, 16)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if machineID != 3<<13|0x1234 {
+		t.Errorf("unexpected machine id: %#x", machineID)
+	}
+
+	machineID, err = composeMachineID(1, 2, 0xffff, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if machineID != 1<<8|0xff {
+		t.Errorf("unexpected machine id: %#x", machineID)
+	}
+
+	if _, err := composeMachineID(4, 2, 0, 10); err != ErrAZIndexOverflow {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestAmazonEC2AZMachineIDInvalidBits(t *testing.T) {
+	for _, bits := range [][2]int{{0, 16}, {4, 4}, {4, 17}} {
+		if _, err := AmazonEC2AZMachineID(bits[0], bits[1])(); err == nil {
+			t.Errorf("error expected for %v", bits)
+		}
+	}
+}
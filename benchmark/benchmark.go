@@ -0,0 +1,110 @@
+// Package benchmark provides a suite of benchmarks of Sonyflake comparing layouts and modes of generation,
+// so that users can choose a layout by the numbers on their own hardware and regressions are caught.
+//
+// The suite runs under go test, or in any program by testing.Benchmark:
+//
+//	for _, bm := range benchmark.Benchmarks() {
+//		fmt.Println(bm.Name, testing.Benchmark(bm.F))
+//	}
+//
+// Benchmarks of NextID measure the sustained rate of a layout, including the waits for the next time unit
+// after the sequence numbers of a time unit run out, so a layout of fewer IDs per time unit is slower.
+package benchmark
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sony/sonyflake"
+)
+
+// Benchmark is a named benchmark function.
+type Benchmark struct {
+	Name string
+	F    func(b *testing.B)
+}
+
+// batchSize is the number of IDs composed at once by the batch benchmarks.
+const batchSize = 1000
+
+// Benchmarks returns the suite of benchmarks:
+//
+//	NextID/default               NextID in the default layout, in units of 10 msec
+//	NextID/millisecond           NextID in the default bit lengths in units of 1 msec
+//	NextID/high-throughput       NextID in the layout of PresetHighThroughput
+//	NextID/parallel              NextID of the default layout called by GOMAXPROCS goroutines, contending for its mutex
+//	NextID/parallel-millisecond  the same in units of 1 msec
+//	NextID/paced                 NextID in the default layout with Settings.Paced
+//	ComposeBatch/default         ComposeBatch of 1000 IDs in the default layout
+//	ComposeBatch/high-throughput ComposeBatch of 1000 IDs in the layout of PresetHighThroughput
+//
+// Each benchmark reports the time per ID as ns/id in addition to ns/op,
+// so that single and batch generation are comparable.
+func Benchmarks() []Benchmark {
+	millisecond := sonyflake.Settings{TimeUnit: time.Millisecond}
+	return []Benchmark{
+		{"NextID/default", nextID(sonyflake.PresetDefault(), false)},
+		{"NextID/millisecond", nextID(millisecond, false)},
+		{"NextID/high-throughput", nextID(sonyflake.PresetHighThroughput(), false)},
+		{"NextID/parallel", nextID(sonyflake.PresetDefault(), true)},
+		{"NextID/parallel-millisecond", nextID(millisecond, true)},
+		{"NextID/paced", nextID(sonyflake.Settings{Paced: true}, false)},
+		{"ComposeBatch/default", composeBatch(sonyflake.PresetDefault())},
+		{"ComposeBatch/high-throughput", composeBatch(sonyflake.PresetHighThroughput())},
+	}
+}
+
+func newSonyflake(b *testing.B, st sonyflake.Settings) *sonyflake.Sonyflake {
+	st.MachineID = func() (uint16, error) { return 1, nil }
+	sf, err := sonyflake.New(st)
+	if err != nil {
+		b.Fatalf("unexpected error: %s", err)
+	}
+	return sf
+}
+
+func nextID(st sonyflake.Settings, parallel bool) func(b *testing.B) {
+	return func(b *testing.B) {
+		sf := newSonyflake(b, st)
+		defer sf.Close()
+
+		b.ResetTimer()
+		start := time.Now()
+		if parallel {
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := sf.NextID(); err != nil {
+						b.Errorf("unexpected error: %s", err)
+						return
+					}
+				}
+			})
+		} else {
+			for i := 0; i < b.N; i++ {
+				if _, err := sf.NextID(); err != nil {
+					b.Fatalf("unexpected error: %s", err)
+				}
+			}
+		}
+		b.StopTimer()
+		b.ReportMetric(float64(time.Since(start).Nanoseconds())/float64(b.N), "ns/id")
+	}
+}
+
+func composeBatch(st sonyflake.Settings) func(b *testing.B) {
+	return func(b *testing.B) {
+		sf := newSonyflake(b, st)
+		defer sf.Close()
+		at := time.Now()
+
+		b.ResetTimer()
+		start := time.Now()
+		for i := 0; i < b.N; i++ {
+			if _, err := sf.ComposeBatch(at, 2, batchSize); err != nil {
+				b.Fatalf("unexpected error: %s", err)
+			}
+		}
+		b.StopTimer()
+		b.ReportMetric(float64(time.Since(start).Nanoseconds())/float64(b.N*batchSize), "ns/id")
+	}
+}
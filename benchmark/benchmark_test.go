@@ -0,0 +1,19 @@
+package benchmark
+
+import "testing"
+
+func BenchmarkSuite(b *testing.B) {
+	for _, bm := range Benchmarks() {
+		b.Run(bm.Name, bm.F)
+	}
+}
+
+func TestBenchmarks(t *testing.T) {
+	names := make(map[string]bool)
+	for _, bm := range Benchmarks() {
+		if names[bm.Name] || bm.F == nil {
+			t.Errorf("invalid benchmark: %s", bm.Name)
+		}
+		names[bm.Name] = true
+	}
+}
@@ -0,0 +1,24 @@
+package sonyflake
+
+import "time"
+
+// Bucket returns the start of the time bucket of the given interval
+// into which the given ID generated by the Sonyflake falls.
+// Buckets are aligned in the same way as time.Time.Truncate, such as on the hour for an interval of an hour.
+// If interval <= 0, Bucket returns the time when the ID was generated.
+func (sf *Sonyflake) Bucket(id uint64, interval time.Duration) time.Time {
+	elapsedTime := int64(id >> sf.shiftTime & (1<<sf.bitsTime - 1))
+	t := time.Unix(0, (sf.startTime+elapsedTime)*sf.timeUnit).UTC()
+	return t.Truncate(interval)
+}
+
+// BucketKey returns the least ID whose time falls in the time bucket returned by Bucket,
+// which is usable as a key of roll-up jobs and as the lower bound of a range scan of the bucket.
+func (sf *Sonyflake) BucketKey(id uint64, interval time.Duration) uint64 {
+	start := sf.Bucket(id, interval).UnixNano()
+	elapsedTime := (start+sf.timeUnit-1)/sf.timeUnit - sf.startTime
+	if elapsedTime < 0 {
+		elapsedTime = 0
+	}
+	return uint64(elapsedTime) << sf.shiftTime
+}
@@ -0,0 +1,73 @@
+package sonyflake
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucket(t *testing.T) {
+	startTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sf, err := New(Settings{
+		StartTime: startTime,
+		MachineID: func() (uint16, error) { return 1, nil },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	at := time.Date(2024, 3, 15, 10, 42, 17, 0, time.UTC)
+	id, err := sf.Compose(at, 5, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	testCases := []struct {
+		interval time.Duration
+		bucket   time.Time
+	}{
+		{0, at},
+		{time.Minute, time.Date(2024, 3, 15, 10, 42, 0, 0, time.UTC)},
+		{time.Hour, time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC)},
+		{24 * time.Hour, time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range testCases {
+		bucket := sf.Bucket(id, tc.interval)
+		if !bucket.Equal(tc.bucket) {
+			t.Errorf("unexpected bucket of %s: %s, expected %s", tc.interval, bucket, tc.bucket)
+		}
+
+		key := sf.BucketKey(id, tc.interval)
+		expected, err := sf.Compose(tc.bucket, 0, 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if key != expected {
+			t.Errorf("unexpected bucket key of %s: %d, expected %d", tc.interval, key, expected)
+		}
+		if key > id {
+			t.Errorf("bucket key %d must not be greater than id %d", key, id)
+		}
+		if sf.Bucket(key, tc.interval) != bucket {
+			t.Errorf("bucket key of %s must be in the bucket", tc.interval)
+		}
+	}
+}
+
+func TestBucketKeyBeforeStartTime(t *testing.T) {
+	sf, err := New(Settings{
+		StartTime: time.Date(2024, 1, 1, 0, 30, 0, 0, time.UTC),
+		MachineID: func() (uint16, error) { return 1, nil },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	id, err := sf.Compose(time.Date(2024, 1, 1, 0, 40, 0, 0, time.UTC), 0, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if key := sf.BucketKey(id, time.Hour); key != 0 {
+		t.Errorf("unexpected bucket key: %d", key)
+	}
+}
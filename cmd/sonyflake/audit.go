@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+
+	"github.com/sony/sonyflake/audit"
+)
+
+func runAudit(args []string) error {
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	file := fs.String("file", "", "file of IDs, one per line (default stdin)")
+	maxMachineID := fs.Uint("max-machine-id", 0, "greatest machine ID assigned in the deployment")
+	layout := layoutFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	st := audit.Settings{MaxMachineID: uint16(*maxMachineID)}
+	var err error
+	st.Layout, err = layout()
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if *file != "" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	report, err := audit.Audit(r, st)
+	if err != nil {
+		return err
+	}
+	if _, err := report.WriteTo(os.Stdout); err != nil {
+		return err
+	}
+	if !report.OK() {
+		return errFailed
+	}
+	return nil
+}
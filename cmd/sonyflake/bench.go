@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sony/sonyflake"
+)
+
+// latencyBuckets is the number of buckets of a latency histogram in microseconds.
+// Latencies of 100 msec or longer fall into the last bucket.
+const latencyBuckets = 100000
+
+type histogram [latencyBuckets + 1]uint64
+
+func (h *histogram) add(d time.Duration) {
+	i := int(d / time.Microsecond)
+	if i > latencyBuckets {
+		i = latencyBuckets
+	}
+	h[i]++
+}
+
+// quantile returns the upper bound of the bucket holding the given quantile of the n latencies.
+func (h *histogram) quantile(q float64, n uint64) time.Duration {
+	rank := uint64(q * float64(n))
+	var count uint64
+	for i, c := range h {
+		count += c
+		if count > rank {
+			return time.Duration(i+1) * time.Microsecond
+		}
+	}
+	return latencyBuckets * time.Microsecond
+}
+
+func runBench(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	duration := fs.Duration("duration", 10*time.Second, "duration of the benchmark")
+	goroutines := fs.Int("goroutines", 1, "number of goroutines generating IDs")
+	settings := settingsFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	st, err := settings()
+	if err != nil {
+		return err
+	}
+	st.MachineID = func() (uint16, error) { return 0, nil }
+	sf, err := sonyflake.New(st)
+	if err != nil {
+		return err
+	}
+	defer sf.Close()
+
+	var (
+		mutex sync.Mutex
+		total histogram
+		wg    sync.WaitGroup
+		errs  = make(chan error, *goroutines)
+	)
+	start := time.Now()
+	deadline := start.Add(*duration)
+	for i := 0; i < *goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			h := new(histogram)
+			for time.Now().Before(deadline) {
+				t := time.Now()
+				if _, err := sf.NextID(); err != nil {
+					errs <- err
+					return
+				}
+				h.add(time.Since(t))
+			}
+
+			mutex.Lock()
+			for i, c := range h {
+				total[i] += c
+			}
+			mutex.Unlock()
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	select {
+	case err := <-errs:
+		return err
+	default:
+	}
+
+	stats := sf.Stats()
+	l := sf.Layout()
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "ids\t%d\n", stats.Generated)
+	fmt.Fprintf(w, "throughput\t%.0f IDs/sec\n", float64(stats.Generated)/elapsed.Seconds())
+	fmt.Fprintf(w, "max rate\t%d IDs/sec\n", uint64(1)<<l.BitsSequence*uint64(time.Second/l.TimeUnit))
+	fmt.Fprintf(w, "p50 latency\t%s\n", total.quantile(0.5, stats.Generated))
+	fmt.Fprintf(w, "p99 latency\t%s\n", total.quantile(0.99, stats.Generated))
+	fmt.Fprintf(w, "exhausted\t%d\n", stats.Exhausted)
+	return w.Flush()
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sony/sonyflake/coordinator"
+)
+
+func runCoordinator(args []string) error {
+	fs := flag.NewFlagSet("coordinator", flag.ContinueOnError)
+	addr := fs.String("addr", ":8080", "TCP address to listen on")
+	minMachineID := fs.Uint("min-machine-id", 0, "minimum machine ID to allocate")
+	maxMachineID := fs.Uint("max-machine-id", 0, "maximum machine ID to allocate (default 65535)")
+	leaseTTL := fs.Duration("lease-ttl", 0, "time to live of a lease (default 1m)")
+	quarantine := fs.Duration("quarantine", 0, "period before an expired or released machine ID is leased again")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *minMachineID > 1<<16-1 || *maxMachineID > 1<<16-1 {
+		return coordinator.ErrInvalidRange
+	}
+
+	c, err := coordinator.New(coordinator.Settings{
+		MinMachineID: uint16(*minMachineID),
+		MaxMachineID: uint16(*maxMachineID),
+		LeaseTTL:     *leaseTTL,
+		Quarantine:   *quarantine,
+	})
+	if err != nil {
+		return err
+	}
+	return http.ListenAndServe(*addr, coordinator.Handler(c))
+}
+
+func runAdmin(args []string) error {
+	fs := flag.NewFlagSet("admin", flag.ContinueOnError)
+	url := fs.String("url", "http://localhost:8080", "base URL of the coordinator")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: sonyflake admin [flags] list")
+		fmt.Fprintln(fs.Output(), "       sonyflake admin [flags] release <machine-id>")
+		fmt.Fprintln(fs.Output(), "       sonyflake admin [flags] reserve <from> <to> [note]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	c := coordinator.Client{URL: *url}
+	args = fs.Args()
+	switch {
+	case len(args) == 1 && args[0] == "list":
+		return listAllocations(c)
+	case len(args) == 2 && args[0] == "release":
+		machineID, err := parseMachineID(args[1])
+		if err != nil {
+			return err
+		}
+		return c.ForceRelease(machineID)
+	case (len(args) == 3 || len(args) == 4) && args[0] == "reserve":
+		from, err := parseMachineID(args[1])
+		if err != nil {
+			return err
+		}
+		to, err := parseMachineID(args[2])
+		if err != nil {
+			return err
+		}
+		var note string
+		if len(args) == 4 {
+			note = args[3]
+		}
+		return c.Reserve(from, to, note)
+	}
+	fs.Usage()
+	return errFailed
+}
+
+func listAllocations(c coordinator.Client) error {
+	allocations, err := c.Allocations()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "MACHINE ID\tOWNER\tEXPIRES AT")
+	for _, a := range allocations {
+		expiresAt := "reserved"
+		switch {
+		case a.Quarantined:
+			expiresAt = "quarantined since " + a.ExpiresAt.Format(time.RFC3339)
+		case !a.Reserved:
+			expiresAt = a.ExpiresAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", a.MachineID, a.Owner, expiresAt)
+	}
+	return w.Flush()
+}
+
+func parseMachineID(s string) (uint16, error) {
+	n, err := strconv.ParseUint(s, 10, 16)
+	if err != nil {
+		return 0, errors.New("invalid machine id: " + s)
+	}
+	return uint16(n), nil
+}
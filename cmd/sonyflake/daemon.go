@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/sony/sonyflake"
+	"github.com/sony/sonyflake/daemon"
+)
+
+func runDaemon(args []string) error {
+	fs := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	socket := fs.String("socket", "/tmp/sonyflake.sock", "path of the Unix domain socket")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	st, err := sonyflake.SettingsFromEnv()
+	if err != nil {
+		return err
+	}
+	sf, err := sonyflake.New(st)
+	if err != nil {
+		return err
+	}
+	defer sf.Close()
+
+	s := daemon.NewServer(sf)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		s.Close()
+	}()
+
+	defer os.Remove(*socket)
+	return s.ListenAndServe(*socket)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sony/sonyflake"
+)
+
+// foreignLayout is the layout of a snowflake variant,
+// whose machine ID consists of the subfields named from the most significant one, each of which has bitsSubfield bits.
+type foreignLayout struct {
+	layout       sonyflake.Layout
+	subfields    []string
+	bitsSubfield int
+}
+
+var foreignLayouts = map[string]foreignLayout{
+	"twitter": {
+		layout: sonyflake.Layout{
+			BitsTime:      41,
+			BitsSequence:  12,
+			BitsMachineID: 10,
+			TimeUnit:      time.Millisecond,
+			StartTime:     time.Unix(0, 1288834974657*int64(time.Millisecond)).UTC(),
+			FieldOrder:    sonyflake.FieldOrderMachineIDFirst,
+		},
+		subfields:    []string{"datacenter", "worker"},
+		bitsSubfield: 5,
+	},
+	"discord": {
+		layout: sonyflake.Layout{
+			BitsTime:      42,
+			BitsSequence:  12,
+			BitsMachineID: 10,
+			TimeUnit:      time.Millisecond,
+			StartTime:     time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC),
+			FieldOrder:    sonyflake.FieldOrderMachineIDFirst,
+			UseMSB:        true,
+		},
+		subfields:    []string{"worker", "process"},
+		bitsSubfield: 5,
+	},
+}
+
+// parseLayout returns the layout of the given name:
+// "default", "twitter", "discord", or "custom:" followed by the path of a JSON descriptor of sonyflake.Layout.
+func parseLayout(name string) (foreignLayout, error) {
+	if name == "default" {
+		l, err := sonyflake.PresetDefault().Layout()
+		return foreignLayout{layout: l}, err
+	}
+	if fl, ok := foreignLayouts[name]; ok {
+		return fl, nil
+	}
+	if path := strings.TrimPrefix(name, "custom:"); path != name {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return foreignLayout{}, err
+		}
+		// Layout.UnmarshalJSON rejects inconsistent descriptors, such as a zero time unit.
+		var l sonyflake.Layout
+		if err := json.Unmarshal(b, &l); err != nil {
+			return foreignLayout{}, fmt.Errorf("invalid layout descriptor %s: %w", path, err)
+		}
+		return foreignLayout{layout: l}, nil
+	}
+	return foreignLayout{}, errors.New("unknown layout " + name)
+}
+
+func runDecompose(args []string) error {
+	fs := flag.NewFlagSet("decompose", flag.ContinueOnError)
+	layout := fs.String("layout", "default", "layout of IDs: default, twitter, discord or custom:<path of a JSON descriptor>")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: sonyflake decompose [flags] [id ...]")
+		fmt.Fprintln(fs.Output(), "IDs are read from stdin, one per line, if none is given.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	fl, err := parseLayout(*layout)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	if fs.NArg() > 0 {
+		for _, s := range fs.Args() {
+			if err := decompose(w, fl, s); err != nil {
+				return err
+			}
+		}
+		return w.Flush()
+	}
+
+	if err := decomposeLines(w, fl, os.Stdin); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func decomposeLines(w io.Writer, fl foreignLayout, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		s := strings.TrimSpace(scanner.Text())
+		if s == "" {
+			continue
+		}
+		if err := decompose(w, fl, s); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func decompose(w io.Writer, fl foreignLayout, s string) error {
+	id, err := sonyflake.Parse(s)
+	if err != nil {
+		return err
+	}
+
+	parts := fl.layout.DecomposeVerbose(id)
+	fmt.Fprintf(w, "id\t%d\n", parts.ID)
+	fmt.Fprintf(w, "time\t%s\n", parts.GeneratedAt.Format(time.RFC3339Nano))
+	fmt.Fprintf(w, "elapsed time\t%d\n", parts.Time)
+	fmt.Fprintf(w, "sequence\t%d\n", parts.Sequence)
+	if fl.layout.BitsNamespace > 0 {
+		fmt.Fprintf(w, "namespace\t%d\n", parts.Namespace)
+	}
+	fmt.Fprintf(w, "machine-id\t%d\n", parts.MachineID)
+	for i, name := range fl.subfields {
+		shift := uint((len(fl.subfields) - 1 - i) * fl.bitsSubfield)
+		fmt.Fprintf(w, "  %s\t%d\n", name, parts.MachineID>>shift&(1<<fl.bitsSubfield-1))
+	}
+	if !fl.layout.UseMSB {
+		fmt.Fprintf(w, "msb\t%d\n", parts.MSB)
+	}
+	fmt.Fprintln(w)
+	return nil
+}
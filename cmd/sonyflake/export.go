@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/sony/sonyflake"
+	"github.com/sony/sonyflake/export"
+)
+
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	file := fs.String("file", "", "file of IDs, one per line (default stdin)")
+	format := fs.String("format", "csv", "output format: csv or ndjson")
+	output := fs.String("o", "", "output file (default stdout)")
+	layout := layoutFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	f, err := export.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+	l, err := layout()
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if *file != "" {
+		in, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		r = in
+	}
+
+	if *output == "" {
+		return exportIDs(os.Stdout, r, l, f)
+	}
+
+	out, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := exportIDs(out, r, l, f); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func exportIDs(w io.Writer, r io.Reader, l sonyflake.Layout, f export.Format) error {
+	ew, err := export.NewWriter(w, l, f)
+	if err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		s := strings.TrimSpace(scanner.Text())
+		if s == "" {
+			continue
+		}
+
+		id, err := sonyflake.Parse(s)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := ew.Write(id); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return ew.Flush()
+}
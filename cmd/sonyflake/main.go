@@ -0,0 +1,131 @@
+// Command sonyflake is a toolbox for Sonyflake IDs.
+//
+// Usage:
+//
+//	sonyflake <command> [flags]
+//
+// The commands are:
+//
+//	admin        manage the machine IDs allocated by a coordinator
+//	audit        verify IDs read from stdin or a file
+//	bench        measure the throughput and latency of generating IDs
+//	coordinator  serve a coordinator allocating machine IDs by leases
+//	daemon       serve IDs over a Unix domain socket
+//	decompose    print the parts of IDs in a layout such as twitter and discord
+//	export       export IDs as rows of their parts in CSV or NDJSON
+//	plan         recommend a layout for a lifetime, a rate and a number of machines
+//	seed         pre-generate IDs into a seed file or verify one
+//	vectors      emit test vectors of IDs in JSON
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/sony/sonyflake"
+)
+
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"admin":       {"manage the machine IDs allocated by a coordinator", runAdmin},
+	"audit":       {"verify IDs read from stdin or a file", runAudit},
+	"bench":       {"measure the throughput and latency of generating IDs", runBench},
+	"coordinator": {"serve a coordinator allocating machine IDs by leases", runCoordinator},
+	"daemon":      {"serve IDs over a Unix domain socket", runDaemon},
+	"decompose":   {"print the parts of IDs in a layout such as twitter and discord", runDecompose},
+	"export":      {"export IDs as rows of their parts in CSV or NDJSON", runExport},
+	"plan":        {"recommend a layout for a lifetime, a rate and a number of machines", runPlan},
+	"seed":        {"pre-generate IDs into a seed file or verify one", runSeed},
+	"vectors":     {"emit test vectors of IDs in JSON", runVectors},
+}
+
+// errFailed is returned by a command which has reported its failure by itself.
+var errFailed = errors.New("failed")
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "sonyflake: unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	if err := cmd.run(os.Args[2:]); err != nil {
+		if err != errFailed {
+			fmt.Fprintf(os.Stderr, "sonyflake %s: %s\n", os.Args[1], err)
+		}
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: sonyflake <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "The commands are:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "\t%-12s %s\n", name, commands[name].usage)
+	}
+}
+
+// layoutFlags defines the flags of the layout of IDs in fs.
+// The returned function returns the layout after fs is parsed.
+func layoutFlags(fs *flag.FlagSet) func() (sonyflake.Layout, error) {
+	settings := settingsFlags(fs)
+	return func() (sonyflake.Layout, error) {
+		st, err := settings()
+		if err != nil {
+			return sonyflake.Layout{}, err
+		}
+		return st.Layout()
+	}
+}
+
+// settingsFlags defines the flags of the layout of IDs in fs.
+// The returned function returns the Settings of the layout after fs is parsed.
+func settingsFlags(fs *flag.FlagSet) func() (sonyflake.Settings, error) {
+	var (
+		st             sonyflake.Settings
+		startTime      string
+		machineIDFirst bool
+	)
+	fs.StringVar(&startTime, "start-time", "", "start time in RFC 3339 (default 2014-09-01T00:00:00Z)")
+	fs.IntVar(&st.BitsSequence, "bits-sequence", 0, "bit length of a sequence number (default 8)")
+	fs.IntVar(&st.BitsMachineID, "bits-machine-id", 0, "bit length of a machine ID (default 16)")
+	fs.IntVar(&st.BitsNamespace, "bits-namespace", 0, "bit length of a namespace ID")
+	fs.DurationVar(&st.TimeUnit, "time-unit", 0, "time unit (default 10ms)")
+	fs.BoolVar(&machineIDFirst, "machine-id-first", false, "place the sequence number in the lowest bits")
+	fs.BoolVar(&st.UseMSB, "use-msb", false, "use the most significant bit for time")
+
+	return func() (sonyflake.Settings, error) {
+		if startTime != "" {
+			t, err := time.Parse(time.RFC3339, startTime)
+			if err != nil {
+				return sonyflake.Settings{}, err
+			}
+			st.StartTime = t
+		}
+		if machineIDFirst {
+			st.FieldOrder = sonyflake.FieldOrderMachineIDFirst
+		}
+		return st, st.Validate()
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sony/sonyflake"
+)
+
+const year = 365 * 24 * time.Hour
+
+func runPlan(args []string) error {
+	fs := flag.NewFlagSet("plan", flag.ContinueOnError)
+	lifetime := fs.String("lifetime", "50y", "lifetime from the start time, as a duration or in years such as 50y")
+	maxRate := fs.Int("max-rate", 25600, "maximum number of IDs per second per instance")
+	machines := fs.Int("machines", 65536, "number of instances")
+	startTime := fs.String("start-time", "", "start time in RFC 3339 (default now)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	r := sonyflake.Requirements{MaxRate: *maxRate, Machines: *machines}
+	var err error
+	r.Lifetime, err = parseLifetime(*lifetime)
+	if err != nil {
+		return err
+	}
+
+	st, err := sonyflake.Plan(r)
+	if err != nil {
+		return err
+	}
+	st.StartTime = time.Now()
+	if *startTime != "" {
+		st.StartTime, err = time.Parse(time.RFC3339, *startTime)
+		if err != nil {
+			return err
+		}
+	}
+
+	l, err := st.Layout()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "bits for time\t%d\n", l.BitsTime)
+	fmt.Fprintf(w, "bits for sequence\t%d\n", l.BitsSequence)
+	fmt.Fprintf(w, "bits for machine id\t%d\n", l.BitsMachineID)
+	fmt.Fprintf(w, "time unit\t%s\n", l.TimeUnit)
+	fmt.Fprintf(w, "max rate\t%d IDs/sec per instance\n", uint64(1)<<l.BitsSequence*uint64(time.Second/l.TimeUnit))
+	fmt.Fprintf(w, "max machines\t%d\n", 1<<l.BitsMachineID)
+	fmt.Fprintf(w, "start time\t%s\n", l.StartTime.Format(time.RFC3339))
+	fmt.Fprintf(w, "overflow\t%s\n", l.TimeLimit().Format(time.RFC3339))
+	fmt.Fprintf(w, "flags\t-bits-sequence %d -bits-machine-id %d -time-unit %s\n", l.BitsSequence, l.BitsMachineID, l.TimeUnit)
+	return w.Flush()
+}
+
+// parseLifetime parses a duration, or a number of years with the suffix "y".
+func parseLifetime(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "y") {
+		years, err := strconv.ParseFloat(strings.TrimSuffix(s, "y"), 64)
+		if err != nil {
+			return 0, err
+		}
+		if years*float64(year) >= math.MaxInt64 {
+			return 0, fmt.Errorf("lifetime %s too long", s)
+		}
+		return time.Duration(years * float64(year)), nil
+	}
+	return time.ParseDuration(s)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sony/sonyflake"
+	"github.com/sony/sonyflake/audit"
+	"github.com/sony/sonyflake/seed"
+)
+
+func runSeed(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "generate":
+			return runSeedGenerate(args[1:])
+		case "verify":
+			return runSeedVerify(args[1:])
+		}
+	}
+	fmt.Fprintln(os.Stderr, "usage: sonyflake seed generate [flags]")
+	fmt.Fprintln(os.Stderr, "       sonyflake seed verify [flags]")
+	return errFailed
+}
+
+func runSeedGenerate(args []string) error {
+	fs := flag.NewFlagSet("seed generate", flag.ContinueOnError)
+	n := fs.Int("n", 1000, "number of IDs to generate")
+	output := fs.String("o", "", "output file (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	st, err := sonyflake.SettingsFromEnv()
+	if err != nil {
+		return err
+	}
+	sf, err := sonyflake.New(st)
+	if err != nil {
+		return err
+	}
+	defer sf.Close()
+
+	if *output == "" {
+		return seed.Generate(os.Stdout, sf, *n)
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := seed.Generate(f, sf, *n); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func runSeedVerify(args []string) error {
+	fs := flag.NewFlagSet("seed verify", flag.ContinueOnError)
+	file := fs.String("file", "", "seed file (default stdin)")
+	n := fs.Int("n", 0, "expected number of IDs (default any)")
+	maxMachineID := fs.Uint("max-machine-id", 0, "greatest machine ID assigned in the deployment")
+	layout := layoutFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	st := audit.Settings{MaxMachineID: uint16(*maxMachineID)}
+	var err error
+	st.Layout, err = layout()
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if *file != "" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	report, err := seed.Verify(r, st, *n)
+	if err != nil {
+		return err
+	}
+	if _, err := report.WriteTo(os.Stdout); err != nil {
+		return err
+	}
+	if !report.OK() {
+		return errFailed
+	}
+	return nil
+}
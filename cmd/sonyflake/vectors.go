@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/sony/sonyflake/vectors"
+)
+
+func runVectors(args []string) error {
+	fs := flag.NewFlagSet("vectors", flag.ContinueOnError)
+	output := fs.String("o", "", "output file (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *output == "" {
+		return vectors.Write(os.Stdout)
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := vectors.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
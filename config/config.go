@@ -0,0 +1,341 @@
+// Package config loads Sonyflake settings from configuration files,
+// so that the generator can be managed by configuration management rather than recompiling.
+//
+// A configuration file is a JSON document of the following schema:
+//
+//	{
+//	  "start_time": "2014-09-01T00:00:00Z",
+//	  "bits_sequence": 8,
+//	  "bits_machine_id": 16,
+//	  "bits_namespace": 0,
+//	  "namespace_id": 0,
+//	  "time_unit": "10ms",
+//	  "field_order": "sequence-first",
+//	  "use_msb": false,
+//	  "random_sequence_start": false,
+//	  "random_sequence": false,
+//	  "machine_id": {
+//	    "provider": "chain",
+//	    "providers": [
+//	      {"provider": "env", "env": "MACHINE_ID"},
+//	      {"provider": "paas", "bits_index": 8},
+//	      {"provider": "aws-ec2"}
+//	    ]
+//	  },
+//	  "fallback_machine_id": {"provider": "static", "value": 1},
+//	  "registry": {"url": "http://registry.internal/machine-ids", "owner": "host-1", "retries": 3, "backoff": "100ms"},
+//	  "recheck_interval": "1m",
+//	  "recheck_every": 0,
+//	  "recheck_policy": "error",
+//	  "paced": false,
+//	  "backward_tolerance": "1s",
+//	  "utilization_quota": 0.8,
+//	  "interfaces": ["eth0"],
+//	  "preferred_cidrs": ["10.32.0.0/12"],
+//	  "allow_public_ip": false
+//	}
+//
+// Every field is optional. YAML documents of the same schema are loaded
+// once a YAML decoder is registered by RegisterFormat.
+// Callbacks such as OnUtilizationQuota for "utilization_quota" and OnClockBackward for "backward_tolerance"
+// are set on the returned Settings in code.
+package config
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sony/sonyflake"
+	"github.com/sony/sonyflake/awsutil"
+	"github.com/sony/sonyflake/gcputil"
+	"github.com/sony/sonyflake/helpers"
+)
+
+// These are the names of the machine ID providers selectable in a configuration file.
+// The providers deriving a machine ID of some bits use "bits_machine_id" of the configuration file.
+const (
+	ProviderDefault  = "default"    // default MachineID of Sonyflake
+	ProviderStatic   = "static"     // the machine ID given by "value"
+	ProviderEnv      = "env"        // the machine ID in the environment variable given by "env"
+	ProviderDNS      = "dns"        // helpers.DNSMachineID of the DNS name given by "dns"
+	ProviderHost     = "host"       // helpers.HostMachineID
+	ProviderPaaS     = "paas"       // helpers.PaaSMachineID with the bits of the dyno index given by "bits_index"
+	ProviderMeshVPN  = "meshvpn"    // helpers.MeshVPNMachineID of the WireGuard interfaces given by "interfaces"
+	ProviderAWSEC2   = "aws-ec2"    // awsutil.AmazonEC2MachineID
+	ProviderAWSEC2AZ = "aws-ec2-az" // awsutil.AmazonEC2AZMachineID with the bits of the zone index given by "bits_zone"
+	ProviderGCE      = "gce"        // gcputil.GCEMachineID with the bits of the zone index given by "bits_zone"
+	ProviderChain    = "chain"      // the first machine ID successfully provided by "providers"
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown machine id provider")
+	ErrChainedDefault  = errors.New("default machine id provider cannot be chained")
+	ErrFallbackDefault = errors.New("default machine id provider cannot be a fallback")
+	ErrUnknownFormat   = errors.New("unknown configuration format")
+)
+
+// Config is the schema of a configuration file.
+type Config struct {
+	StartTime           string    `json:"start_time" yaml:"start_time"`
+	BitsSequence        int       `json:"bits_sequence" yaml:"bits_sequence"`
+	BitsMachineID       int       `json:"bits_machine_id" yaml:"bits_machine_id"`
+	BitsNamespace       int       `json:"bits_namespace" yaml:"bits_namespace"`
+	NamespaceID         uint16    `json:"namespace_id" yaml:"namespace_id"`
+	TimeUnit            string    `json:"time_unit" yaml:"time_unit"`
+	FieldOrder          string    `json:"field_order" yaml:"field_order"`
+	UseMSB              bool      `json:"use_msb" yaml:"use_msb"`
+	RandomSequenceStart bool      `json:"random_sequence_start" yaml:"random_sequence_start"`
+	RandomSequence      bool      `json:"random_sequence" yaml:"random_sequence"`
+	MachineID           *Provider `json:"machine_id" yaml:"machine_id"`
+	FallbackMachineID   *Provider `json:"fallback_machine_id" yaml:"fallback_machine_id"`
+	Registry            *Registry `json:"registry" yaml:"registry"`
+	RecheckInterval     string    `json:"recheck_interval" yaml:"recheck_interval"`
+	RecheckEvery        uint64    `json:"recheck_every" yaml:"recheck_every"`
+	RecheckPolicy       string    `json:"recheck_policy" yaml:"recheck_policy"`
+	Paced               bool      `json:"paced" yaml:"paced"`
+	BackwardTolerance   string    `json:"backward_tolerance" yaml:"backward_tolerance"`
+	UtilizationQuota    float64   `json:"utilization_quota" yaml:"utilization_quota"`
+	Interfaces          []string  `json:"interfaces" yaml:"interfaces"`
+	PreferredCIDRs      []string  `json:"preferred_cidrs" yaml:"preferred_cidrs"`
+	AllowPublicIP       bool      `json:"allow_public_ip" yaml:"allow_public_ip"`
+}
+
+// Provider selects a machine ID provider by name.
+// Value is used by ProviderStatic, Env by ProviderEnv, DNS by ProviderDNS, BitsIndex by ProviderPaaS,
+// Interfaces by ProviderMeshVPN, BitsZone by ProviderAWSEC2AZ and ProviderGCE, and Providers by ProviderChain.
+type Provider struct {
+	Name       string     `json:"provider" yaml:"provider"`
+	Value      uint16     `json:"value" yaml:"value"`
+	Env        string     `json:"env" yaml:"env"`
+	DNS        string     `json:"dns" yaml:"dns"`
+	BitsIndex  int        `json:"bits_index" yaml:"bits_index"`
+	Interfaces []string   `json:"interfaces" yaml:"interfaces"`
+	BitsZone   int        `json:"bits_zone" yaml:"bits_zone"`
+	Providers  []Provider `json:"providers" yaml:"providers"`
+}
+
+// Registry configures helpers.Registry used as Settings.CheckMachineID.
+// Owner is the owner of the claimed machine ID; if it is empty, a random one is generated by Settings,
+// so that the rechecks by the same Settings are granted.
+// Backoff is a duration such as "100ms".
+type Registry struct {
+	URL     string `json:"url" yaml:"url"`
+	Owner   string `json:"owner" yaml:"owner"`
+	Retries int    `json:"retries" yaml:"retries"`
+	Backoff string `json:"backoff" yaml:"backoff"`
+}
+
+var (
+	formatsMutex sync.RWMutex
+	formats      = map[string]func([]byte, interface{}) error{
+		".json": json.Unmarshal,
+	}
+)
+
+// RegisterFormat registers the decoder of configuration files with the given extension, such as ".yaml".
+// For example, RegisterFormat(".yaml", yaml.Unmarshal) enables Load to read YAML files.
+func RegisterFormat(ext string, unmarshal func([]byte, interface{}) error) {
+	formatsMutex.Lock()
+	defer formatsMutex.Unlock()
+
+	formats[strings.ToLower(ext)] = unmarshal
+}
+
+// Load reads the configuration file at the given path and returns the Settings it describes.
+// The format of the file is determined by its extension.
+func Load(path string) (sonyflake.Settings, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+
+	formatsMutex.RLock()
+	unmarshal, ok := formats[ext]
+	formatsMutex.RUnlock()
+	if !ok {
+		return sonyflake.Settings{}, fmt.Errorf("%s: %w", path, ErrUnknownFormat)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return sonyflake.Settings{}, err
+	}
+
+	st, err := Parse(data, unmarshal)
+	if err != nil {
+		return sonyflake.Settings{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return st, nil
+}
+
+// Parse decodes data by unmarshal and returns the Settings it describes.
+func Parse(data []byte, unmarshal func([]byte, interface{}) error) (sonyflake.Settings, error) {
+	var c Config
+	if err := unmarshal(data, &c); err != nil {
+		return sonyflake.Settings{}, err
+	}
+	return c.Settings()
+}
+
+// Settings returns the Settings described by the Config.
+func (c Config) Settings() (sonyflake.Settings, error) {
+	var st sonyflake.Settings
+
+	st.BitsSequence = c.BitsSequence
+	st.BitsMachineID = c.BitsMachineID
+	st.BitsNamespace = c.BitsNamespace
+	st.NamespaceID = c.NamespaceID
+	st.UseMSB = c.UseMSB
+	st.RandomSequenceStart = c.RandomSequenceStart
+	st.RandomSequence = c.RandomSequence
+	if c.TimeUnit != "" {
+		unit, err := time.ParseDuration(c.TimeUnit)
+		if err != nil {
+			return sonyflake.Settings{}, fmt.Errorf("time_unit: %w", err)
+		}
+		st.TimeUnit = unit
+	}
+
+	switch c.FieldOrder {
+	case "", "sequence-first":
+		st.FieldOrder = sonyflake.FieldOrderSequenceFirst
+	case "machine-id-first":
+		st.FieldOrder = sonyflake.FieldOrderMachineIDFirst
+	default:
+		return sonyflake.Settings{}, fmt.Errorf("field_order: %w", sonyflake.ErrInvalidFieldOrder)
+	}
+
+	if c.StartTime != "" {
+		startTime, err := time.Parse(time.RFC3339, c.StartTime)
+		if err != nil {
+			return sonyflake.Settings{}, fmt.Errorf("start_time: %w", err)
+		}
+		st.StartTime = startTime
+	}
+
+	bitsMachineID := c.BitsMachineID
+	if bitsMachineID == 0 {
+		bitsMachineID = sonyflake.BitLenMachineID
+	}
+
+	if c.MachineID != nil {
+		machineID, err := c.MachineID.machineID(bitsMachineID)
+		if err != nil {
+			return sonyflake.Settings{}, fmt.Errorf("machine_id: %w", err)
+		}
+		st.MachineID = machineID
+	}
+
+	if c.FallbackMachineID != nil {
+		machineID, err := c.FallbackMachineID.machineID(bitsMachineID)
+		if err != nil {
+			return sonyflake.Settings{}, fmt.Errorf("fallback_machine_id: %w", err)
+		}
+		if machineID == nil {
+			return sonyflake.Settings{}, fmt.Errorf("fallback_machine_id: %w", ErrFallbackDefault)
+		}
+		st.FallbackMachineID = machineID
+	}
+
+	if c.Registry != nil {
+		r := helpers.Registry{URL: c.Registry.URL, Owner: c.Registry.Owner, Retries: c.Registry.Retries}
+		if r.Owner == "" {
+			var b [16]byte
+			if _, err := rand.Read(b[:]); err != nil {
+				return sonyflake.Settings{}, fmt.Errorf("registry: %w", err)
+			}
+			r.Owner = hex.EncodeToString(b[:])
+		}
+		if c.Registry.Backoff != "" {
+			backoff, err := time.ParseDuration(c.Registry.Backoff)
+			if err != nil {
+				return sonyflake.Settings{}, fmt.Errorf("registry: %w", err)
+			}
+			r.Backoff = backoff
+		}
+		st.CheckMachineID = r.CheckMachineID
+	}
+
+	if c.RecheckInterval != "" {
+		interval, err := time.ParseDuration(c.RecheckInterval)
+		if err != nil {
+			return sonyflake.Settings{}, fmt.Errorf("recheck_interval: %w", err)
+		}
+		st.RecheckInterval = interval
+	}
+	st.RecheckEvery = c.RecheckEvery
+	switch c.RecheckPolicy {
+	case "", "error":
+		st.RecheckPolicy = sonyflake.CheckPolicyError
+	case "halt":
+		st.RecheckPolicy = sonyflake.CheckPolicyHalt
+	default:
+		return sonyflake.Settings{}, fmt.Errorf("recheck_policy: %w", sonyflake.ErrInvalidRecheck)
+	}
+
+	st.Paced = c.Paced
+	if c.BackwardTolerance != "" {
+		tolerance, err := time.ParseDuration(c.BackwardTolerance)
+		if err != nil {
+			return sonyflake.Settings{}, fmt.Errorf("backward_tolerance: %w", err)
+		}
+		st.BackwardTolerance = tolerance
+	}
+	st.UtilizationQuota = c.UtilizationQuota
+
+	st.Interfaces = c.Interfaces
+	st.PreferredCIDRs = c.PreferredCIDRs
+	st.AllowPublicIP = c.AllowPublicIP
+	if err := st.Validate(); err != nil {
+		return sonyflake.Settings{}, err
+	}
+	return st, nil
+}
+
+func (p Provider) machineID(bitsMachineID int) (func() (uint16, error), error) {
+	switch p.Name {
+	case ProviderDefault, "":
+		return nil, nil
+	case ProviderStatic:
+		value := p.Value
+		return func() (uint16, error) {
+			return value, nil
+		}, nil
+	case ProviderEnv:
+		return helpers.EnvMachineID(p.Env), nil
+	case ProviderDNS:
+		return helpers.DNSMachineID(p.DNS, nil), nil
+	case ProviderHost:
+		return helpers.HostMachineID(bitsMachineID), nil
+	case ProviderPaaS:
+		return helpers.PaaSMachineID(bitsMachineID, p.BitsIndex), nil
+	case ProviderMeshVPN:
+		return helpers.MeshVPNMachineID(p.Interfaces...), nil
+	case ProviderAWSEC2:
+		return helpers.NamedMachineID(ProviderAWSEC2, awsutil.AmazonEC2MachineID), nil
+	case ProviderAWSEC2AZ:
+		return helpers.NamedMachineID(ProviderAWSEC2AZ, awsutil.AmazonEC2AZMachineID(p.BitsZone, bitsMachineID)), nil
+	case ProviderGCE:
+		return helpers.NamedMachineID(ProviderGCE, gcputil.GCEMachineID(p.BitsZone, bitsMachineID)), nil
+	case ProviderChain:
+		providers := make([]func() (uint16, error), 0, len(p.Providers))
+		for _, q := range p.Providers {
+			machineID, err := q.machineID(bitsMachineID)
+			if err != nil {
+				return nil, err
+			}
+			if machineID == nil {
+				return nil, ErrChainedDefault
+			}
+			providers = append(providers, machineID)
+		}
+		return helpers.ChainMachineID(providers...), nil
+	default:
+		return nil, fmt.Errorf("%s: %w", p.Name, ErrUnknownProvider)
+	}
+}
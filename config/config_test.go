@@ -0,0 +1,384 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sony/sonyflake"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		err  error
+	}{
+		{
+			name: "success: empty",
+			data: `{}`,
+		},
+		{
+			name: "success: all",
+			data: `{
+				"start_time": "2014-09-01T00:00:00Z",
+				"bits_sequence": 8,
+				"bits_machine_id": 14,
+				"bits_namespace": 2,
+				"namespace_id": 3,
+				"time_unit": "10ms",
+				"field_order": "machine-id-first",
+				"use_msb": true,
+				"random_sequence_start": true,
+				"random_sequence": true,
+				"machine_id": {"provider": "chain", "providers": [{"provider": "env", "env": "MACHINE_ID"}, {"provider": "static", "value": 1}]},
+				"fallback_machine_id": {"provider": "static", "value": 2},
+				"registry": {"url": "http://localhost/machine-ids", "owner": "host-1", "backoff": "1s"},
+				"recheck_interval": "1m",
+				"recheck_every": 1000,
+				"recheck_policy": "halt",
+				"paced": true,
+				"backward_tolerance": "1s",
+				"utilization_quota": 0.8,
+				"interfaces": ["eth0"],
+				"preferred_cidrs": ["10.32.0.0/12"],
+				"allow_public_ip": true
+			}`,
+		},
+		{
+			name: "failure: bits sequence",
+			data: `{"bits_sequence": -1}`,
+			err:  sonyflake.ErrInvalidBitsSequence,
+		},
+		{
+			name: "failure: bits machine id",
+			data: `{"bits_machine_id": 17}`,
+			err:  sonyflake.ErrInvalidBitsMachineID,
+		},
+		{
+			name: "failure: namespace id",
+			data: `{"bits_namespace": 2, "bits_machine_id": 14, "namespace_id": 4}`,
+			err:  sonyflake.ErrInvalidNamespaceID,
+		},
+		{
+			name: "failure: field order",
+			data: `{"field_order": "time-last"}`,
+			err:  sonyflake.ErrInvalidFieldOrder,
+		},
+		{
+			name: "failure: time unit",
+			data: `{"time_unit": "1us"}`,
+			err:  sonyflake.ErrInvalidTimeUnit,
+		},
+		{
+			name: "failure: bits time",
+			data: `{"bits_sequence": 16, "bits_machine_id": 16}`,
+			err:  sonyflake.ErrInvalidBitsTime,
+		},
+		{
+			name: "failure: unknown provider",
+			data: `{"machine_id": {"provider": "gcp"}}`,
+			err:  ErrUnknownProvider,
+		},
+		{
+			name: "failure: chained default",
+			data: `{"machine_id": {"provider": "chain", "providers": [{"provider": "default"}]}}`,
+			err:  ErrChainedDefault,
+		},
+		{
+			name: "failure: recheck interval",
+			data: `{"recheck_interval": "-1s"}`,
+			err:  sonyflake.ErrInvalidRecheck,
+		},
+		{
+			name: "failure: recheck policy",
+			data: `{"recheck_policy": "panic"}`,
+			err:  sonyflake.ErrInvalidRecheck,
+		},
+		{
+			name: "failure: backward tolerance",
+			data: `{"backward_tolerance": "-1s"}`,
+			err:  sonyflake.ErrInvalidTolerance,
+		},
+		{
+			name: "failure: utilization quota",
+			data: `{"utilization_quota": 1.5}`,
+			err:  sonyflake.ErrInvalidQuota,
+		},
+		{
+			name: "failure: default fallback",
+			data: `{"fallback_machine_id": {"provider": "default"}}`,
+			err:  ErrFallbackDefault,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse([]byte(test.data), json.Unmarshal)
+			if !errors.Is(err, test.err) {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestParseMachineID(t *testing.T) {
+	st, err := Parse([]byte(`{
+		"start_time": "2014-09-01T00:00:00Z",
+		"machine_id": {"provider": "static", "value": 1234},
+		"interfaces": ["eth0"],
+		"allow_public_ip": true
+	}`), json.Unmarshal)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !st.StartTime.Equal(time.Date(2014, 9, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected start time: %s", st.StartTime)
+	}
+	if len(st.Interfaces) != 1 || st.Interfaces[0] != "eth0" {
+		t.Errorf("unexpected interfaces: %v", st.Interfaces)
+	}
+	if !st.AllowPublicIP {
+		t.Error("public ip must be allowed")
+	}
+
+	id, err := st.MachineID()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if id != 1234 {
+		t.Errorf("unexpected machine id: %d", id)
+	}
+}
+
+func TestParseProviders(t *testing.T) {
+	for _, provider := range []string{
+		`{"provider": "dns", "dns": "{hostname}.ids.example.com"}`,
+		`{"provider": "host"}`,
+		`{"provider": "paas", "bits_index": 4}`,
+		`{"provider": "meshvpn", "interfaces": ["wg0"]}`,
+		`{"provider": "aws-ec2-az", "bits_zone": 3}`,
+		`{"provider": "gce", "bits_zone": 3}`,
+	} {
+		st, err := Parse([]byte(`{"machine_id": `+provider+`}`), json.Unmarshal)
+		if err != nil {
+			t.Fatalf("unexpected error of %s: %s", provider, err)
+		}
+		if st.MachineID == nil {
+			t.Errorf("no machine id provider of %s", provider)
+		}
+	}
+}
+
+func TestParsePaaSMachineID(t *testing.T) {
+	for key, value := range map[string]string{"DYNO": "web.3", "HEROKU_RELEASE_VERSION": "v1"} {
+		os.Setenv(key, value)
+		defer os.Unsetenv(key)
+	}
+
+	st, err := Parse([]byte(`{"bits_machine_id": 12, "machine_id": {"provider": "paas", "bits_index": 4}}`), json.Unmarshal)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	id, err := st.MachineID()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if id>>12 != 0 || id&0xf != 3 {
+		t.Errorf("unexpected machine id: %d", id)
+	}
+}
+
+func TestParseFallbackMachineID(t *testing.T) {
+	st, err := Parse([]byte(`{"fallback_machine_id": {"provider": "static", "value": 42}}`), json.Unmarshal)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if st.MachineID != nil {
+		t.Error("default machine id must be kept")
+	}
+
+	id, err := st.FallbackMachineID()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if id != 42 {
+		t.Errorf("unexpected machine id: %d", id)
+	}
+}
+
+func TestParseLayout(t *testing.T) {
+	st, err := Parse([]byte(`{
+		"bits_machine_id": 14,
+		"bits_namespace": 2,
+		"namespace_id": 3,
+		"field_order": "machine-id-first",
+		"use_msb": true
+	}`), json.Unmarshal)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if st.BitsNamespace != 2 || st.NamespaceID != 3 || st.FieldOrder != sonyflake.FieldOrderMachineIDFirst || !st.UseMSB {
+		t.Errorf("unexpected settings: %+v", st)
+	}
+}
+
+func TestParseRandomSequenceStart(t *testing.T) {
+	st, err := Parse([]byte(`{"random_sequence_start": true}`), json.Unmarshal)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !st.RandomSequenceStart {
+		t.Errorf("unexpected settings: %+v", st)
+	}
+}
+
+func TestParseRandomSequence(t *testing.T) {
+	st, err := Parse([]byte(`{"random_sequence": true, "field_order": "machine-id-first"}`), json.Unmarshal)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !st.RandomSequence || st.RandomSequenceStart {
+		t.Errorf("unexpected settings: %+v", st)
+	}
+}
+
+func TestParseRecheck(t *testing.T) {
+	st, err := Parse([]byte(`{"recheck_interval": "30s", "recheck_every": 100, "recheck_policy": "halt"}`), json.Unmarshal)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if st.RecheckInterval != 30*time.Second || st.RecheckEvery != 100 || st.RecheckPolicy != sonyflake.CheckPolicyHalt {
+		t.Errorf("unexpected settings: %+v", st)
+	}
+}
+
+func TestRegistryRecheck(t *testing.T) {
+	var mutex sync.Mutex
+	owners := map[uint16]string{}
+	claims := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			MachineID uint16 `json:"machine_id"`
+			Owner     string `json:"owner"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		claims++
+		if owner, ok := owners[body.MachineID]; ok && (owner == "" || owner != body.Owner) {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		owners[body.MachineID] = body.Owner
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	st, err := Parse([]byte(`{
+		"machine_id": {"provider": "static", "value": 1},
+		"registry": {"url": "`+server.URL+`"},
+		"recheck_interval": "1ms"
+	}`), json.Unmarshal)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	sf, err := sonyflake.New(st)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer sf.Close()
+
+	// the claim at startup and two rechecks
+	deadline := time.Now().Add(time.Second)
+	for {
+		mutex.Lock()
+		n := claims
+		mutex.Unlock()
+		if n >= 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("unexpected number of claims: %d", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := sf.NextID(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestParsePaced(t *testing.T) {
+	st, err := Parse([]byte(`{"paced": true}`), json.Unmarshal)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !st.Paced {
+		t.Errorf("unexpected settings: %+v", st)
+	}
+}
+
+func TestParseBackwardTolerance(t *testing.T) {
+	st, err := Parse([]byte(`{"backward_tolerance": "500ms"}`), json.Unmarshal)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if st.BackwardTolerance != 500*time.Millisecond {
+		t.Errorf("unexpected settings: %+v", st)
+	}
+}
+
+func TestParseUtilizationQuota(t *testing.T) {
+	st, err := Parse([]byte(`{"utilization_quota": 0.8}`), json.Unmarshal)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if st.UtilizationQuota != 0.8 {
+		t.Errorf("unexpected settings: %+v", st)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sonyflake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "sonyflake.json")
+	if err := ioutil.WriteFile(path, []byte(`{"machine_id": {"provider": "static", "value": 1}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	path = filepath.Join(dir, "sonyflake.yaml")
+	if err := ioutil.WriteFile(path, []byte(`machine_id: {provider: static, value: 1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	RegisterFormat(".yaml", func(data []byte, v interface{}) error {
+		return json.Unmarshal([]byte(`{"machine_id": {"provider": "static", "value": 1}}`), v)
+	})
+	if _, err := Load(path); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,17 @@
+package sonyflake
+
+import "context"
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the given ID,
+// such as a request ID shared by middleware, handlers and loggers.
+func NewContext(ctx context.Context, id ID) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the ID carried by ctx, if any.
+func FromContext(ctx context.Context) (ID, bool) {
+	id, ok := ctx.Value(contextKey{}).(ID)
+	return id, ok
+}
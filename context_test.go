@@ -0,0 +1,24 @@
+package sonyflake
+
+import (
+	"context"
+	"testing"
+)
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	if _, ok := FromContext(ctx); ok {
+		t.Error("id must be missing")
+	}
+
+	ctx = NewContext(ctx, ID(12345))
+	id, ok := FromContext(ctx)
+	if !ok || id != 12345 {
+		t.Errorf("unexpected id: %d, %t", id, ok)
+	}
+
+	ctx = NewContext(ctx, ID(67890))
+	if id, _ := FromContext(ctx); id != 67890 {
+		t.Errorf("unexpected id: %d", id)
+	}
+}
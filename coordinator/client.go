@@ -0,0 +1,231 @@
+package coordinator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Client is a client of the endpoints served by Handler.
+//
+// URL is the base URL of the endpoints, such as "http://coordinator.internal".
+//
+// HTTPClient is the HTTP client used for requests.
+// If HTTPClient is nil, http.DefaultClient is used.
+type Client struct {
+	URL        string
+	HTTPClient *http.Client
+}
+
+// Acquire requests a lease of a machine ID for the given owner.
+func (c Client) Acquire(owner string) (Allocation, error) {
+	var a Allocation
+	err := c.do(http.MethodPost, "/leases", map[string]string{"owner": owner}, &a)
+	return a, err
+}
+
+// Renew renews the lease of the given machine ID held by the given owner.
+func (c Client) Renew(machineID uint16, owner string) (Allocation, error) {
+	var a Allocation
+	err := c.do(http.MethodPut, "/leases/"+strconv.Itoa(int(machineID)), map[string]string{"owner": owner}, &a)
+	return a, err
+}
+
+// Release releases the lease of the given machine ID held by the given owner.
+func (c Client) Release(machineID uint16, owner string) error {
+	return c.do(http.MethodDelete, "/leases/"+strconv.Itoa(int(machineID))+"?owner="+url.QueryEscape(owner), nil, nil)
+}
+
+// Allocations lists the current leases and reservations.
+func (c Client) Allocations() ([]Allocation, error) {
+	var list []Allocation
+	err := c.do(http.MethodGet, "/admin/allocations", nil, &list)
+	return list, err
+}
+
+// ForceRelease releases the lease or the reservation of the given machine ID regardless of its owner.
+func (c Client) ForceRelease(machineID uint16) error {
+	return c.do(http.MethodDelete, "/admin/allocations/"+strconv.Itoa(int(machineID)), nil, nil)
+}
+
+// Reserve reserves the machine IDs from from to to inclusive with the given note.
+func (c Client) Reserve(from, to uint16, note string) error {
+	req := struct {
+		From uint16 `json:"from"`
+		To   uint16 `json:"to"`
+		Note string `json:"note"`
+	}{from, to, note}
+	return c.do(http.MethodPost, "/admin/reservations", req, nil)
+}
+
+func (c Client) do(method, path string, body, result interface{}) error {
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, c.URL+path, r)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		var e struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(res.Body).Decode(&e)
+
+		switch res.StatusCode {
+		case http.StatusServiceUnavailable:
+			return ErrNoFreeMachineID
+		case http.StatusConflict:
+			return ErrNotLeased
+		case http.StatusMisdirectedRequest:
+			return ErrNotLeader
+		case http.StatusBadRequest:
+			if e.Error == ErrInvalidRange.Error() {
+				return ErrInvalidRange
+			}
+		}
+		return fmt.Errorf("coordinator: %s: %s", res.Status, e.Error)
+	}
+
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(result)
+}
+
+// Lease holds a lease of a machine ID granted by a coordinator and keeps renewing it in the background.
+// Its method MachineID is usable as Settings.MachineID of Sonyflake.
+type Lease struct {
+	client Client
+	owner  string
+	onLost func(err error)
+
+	mutex      sync.Mutex
+	allocation Allocation
+	acquired   bool
+	stats      LeaseStats
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+// NewLease returns a new Lease of the given owner, which is acquired by MachineID.
+//
+// onLost is called if the lease is lost, such as when it was force-released or expired during a network partition.
+// Since the machine ID may then be leased to another instance, the Sonyflake should stop generating IDs,
+// such as by Close.
+func NewLease(c Client, owner string, onLost func(err error)) *Lease {
+	return &Lease{client: c, owner: owner, onLost: onLost}
+}
+
+// MachineID acquires a lease on the first call and starts renewing it every third of its time to live.
+// MachineID returns the leased machine ID.
+func (l *Lease) MachineID() (uint16, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.acquired {
+		return l.allocation.MachineID, nil
+	}
+
+	a, err := l.client.Acquire(l.owner)
+	if err != nil {
+		return 0, err
+	}
+	l.allocation = a
+	l.acquired = true
+	l.stats = LeaseStats{AcquiredAt: time.Now()}
+	l.stop = make(chan struct{})
+	l.done = make(chan struct{})
+	go l.renew(a)
+	return a.MachineID, nil
+}
+
+func (l *Lease) renew(a Allocation) {
+	defer close(l.done)
+
+	for {
+		interval := time.Until(a.ExpiresAt) / 3
+		if interval <= 0 {
+			interval = time.Second
+		}
+
+		select {
+		case <-l.stop:
+			return
+		case <-time.After(interval):
+		}
+
+		start := time.Now()
+		renewed, err := l.client.Renew(a.MachineID, l.owner)
+		l.mutex.Lock()
+		l.stats.RenewLatency += time.Since(start)
+		if err != nil {
+			l.stats.RenewFailures++
+		} else {
+			l.stats.Renewals++
+			l.allocation = renewed
+		}
+		l.mutex.Unlock()
+
+		if errors.Is(err, ErrNotLeased) || err != nil && !time.Now().Before(a.ExpiresAt) {
+			if l.onLost != nil {
+				l.onLost(err)
+			}
+			return
+		}
+		if err != nil {
+			continue // retry until the lease expires
+		}
+
+		a = renewed
+	}
+}
+
+// Close stops renewing the lease and releases it.
+func (l *Lease) Close() error {
+	l.mutex.Lock()
+	if !l.acquired {
+		l.mutex.Unlock()
+		return nil
+	}
+	l.acquired = false
+	close(l.stop)
+	done := l.done
+	machineID := l.allocation.MachineID
+	l.mutex.Unlock()
+
+	<-done
+	err := l.client.Release(machineID, l.owner)
+	if errors.Is(err, ErrNotLeased) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,126 @@
+package coordinator
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sony/sonyflake"
+)
+
+func newServer(t *testing.T, st Settings) (*httptest.Server, Client) {
+	c, err := New(st)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	ts := httptest.NewServer(Handler(c))
+	return ts, Client{URL: ts.URL}
+}
+
+func TestClient(t *testing.T) {
+	ts, c := newServer(t, Settings{MaxMachineID: 3})
+	defer ts.Close()
+
+	a, err := c.Acquire("a")
+	if err != nil || a.MachineID != 0 || a.Owner != "a" {
+		t.Fatalf("unexpected lease: %+v, %v", a, err)
+	}
+	if _, err := c.Renew(a.MachineID, "a"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if _, err := c.Renew(a.MachineID, "b"); err != ErrNotLeased {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := c.Reserve(1, 3, "spare"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := c.Reserve(3, 1, "spare"); err != ErrInvalidRange {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := c.Acquire("b"); err != ErrNoFreeMachineID {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	list, err := c.Allocations()
+	if err != nil || len(list) != 4 {
+		t.Fatalf("unexpected allocations: %v, %v", list, err)
+	}
+
+	if err := c.ForceRelease(2); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := c.Release(a.MachineID, "a"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	list, err = c.Allocations()
+	if err != nil || len(list) != 2 {
+		t.Errorf("unexpected allocations: %v, %v", list, err)
+	}
+}
+
+func TestLease(t *testing.T) {
+	ts, c := newServer(t, Settings{LeaseTTL: 300 * time.Millisecond})
+	defer ts.Close()
+
+	lost := make(chan error, 1)
+	l := NewLease(c, "a", func(err error) { lost <- err })
+
+	sf, err := sonyflake.New(sonyflake.Settings{MachineID: l.MachineID})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	id, err := sf.NextID()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if machineID := sonyflake.MachineID(id); machineID != 0 {
+		t.Errorf("unexpected machine id: %d", machineID)
+	}
+
+	// the lease outlives its time to live by renewals
+	time.Sleep(500 * time.Millisecond)
+	if _, err := c.Renew(0, "a"); err != nil {
+		t.Errorf("lease must be renewed: %s", err)
+	}
+	if stats := l.Stats(); stats.AcquiredAt.IsZero() || stats.Renewals == 0 || stats.RenewFailures != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	if err := c.ForceRelease(0); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	select {
+	case err := <-lost:
+		if err != ErrNotLeased {
+			t.Errorf("unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("lost lease must be reported")
+	}
+
+	if err := l.Close(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestLeaseClose(t *testing.T) {
+	ts, c := newServer(t, Settings{})
+	defer ts.Close()
+
+	l := NewLease(c, "a", nil)
+	if err := l.Close(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	if _, err := l.MachineID(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	list, err := c.Allocations()
+	if err != nil || len(list) != 0 {
+		t.Errorf("lease must be released: %v, %v", list, err)
+	}
+}
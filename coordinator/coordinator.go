@@ -0,0 +1,303 @@
+// Package coordinator allocates machine IDs to Sonyflake instances by leases.
+//
+// A Coordinator grants each instance a lease of a free machine ID, which the instance renews periodically.
+// If the instance stops renewing, such as when it crashes, the lease expires and the machine ID becomes free again.
+// Operators can list the allocations, force-release a lease, and reserve ranges of machine IDs.
+//
+// Handler serves a Coordinator over HTTP, and Client and Lease are its clients.
+package coordinator
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNoFreeMachineID = errors.New("no free machine id")
+	ErrNotLeased       = errors.New("machine id not leased by the owner")
+	ErrInvalidRange    = errors.New("invalid machine id range")
+	ErrNotLeader       = errors.New("coordinator not leader")
+	ErrNoURL           = errors.New("leader election requires the url of the replica")
+)
+
+// Settings configures Coordinator:
+//
+// Store persists the allocations.
+// If Store is nil, a new MemoryStore is used.
+//
+// MinMachineID and MaxMachineID are the range of machine IDs to allocate.
+// If MaxMachineID is 0, it is 65535.
+//
+// LeaseTTL is the time to live of a lease since it is granted or renewed.
+// If LeaseTTL is 0, it is 1 minute.
+//
+// Quarantine is the period after a lease expires or is released during which its machine ID is not leased again,
+// guarding against duplicated IDs from a zombie process of the previous owner still flushing writes.
+// If Quarantine is 0, the machine ID is free immediately.
+//
+// Now returns the current time.
+// If Now is nil, time.Now is used.
+//
+// Lock elects the leader among replicas of the coordinator sharing the Store,
+// so that they can run behind a load balancer with only the leader granting leases.
+// If Lock is nil, the Coordinator is always the leader, which suits a single replica.
+//
+// URL is the base URL at which the other replicas reach the Handler of the Coordinator.
+// It identifies the replica as the holder of Lock, and is required if Lock is not nil.
+//
+// LeaderTTL is the time after which the leadership expires unless the leader extends it.
+// If LeaderTTL is 0, it is 10 sec.
+type Settings struct {
+	Store        Store
+	MinMachineID uint16
+	MaxMachineID uint16
+	LeaseTTL     time.Duration
+	Quarantine   time.Duration
+	Now          func() time.Time
+	Lock         Lock
+	URL          string
+	LeaderTTL    time.Duration
+}
+
+const (
+	defaultMaxMachineID = 1<<16 - 1
+	defaultLeaseTTL     = time.Minute
+	defaultLeaderTTL    = 10 * time.Second
+)
+
+// Coordinator allocates machine IDs by leases.
+// If Settings.Lock is given, its methods return ErrNotLeader unless it is the leader among its replicas.
+type Coordinator struct {
+	mutex      sync.Mutex
+	st         Settings
+	stats      Stats
+	leadership leadership
+}
+
+// New returns a new Coordinator configured with the given Settings.
+// New returns ErrInvalidRange if MinMachineID is greater than MaxMachineID,
+// or ErrNoURL if Lock is given without URL.
+func New(st Settings) (*Coordinator, error) {
+	if st.Store == nil {
+		st.Store = NewMemoryStore()
+	}
+	if st.MaxMachineID == 0 {
+		st.MaxMachineID = defaultMaxMachineID
+	}
+	if st.MinMachineID > st.MaxMachineID {
+		return nil, ErrInvalidRange
+	}
+	if st.LeaseTTL == 0 {
+		st.LeaseTTL = defaultLeaseTTL
+	}
+	if st.Now == nil {
+		st.Now = time.Now
+	}
+	if st.Lock != nil && st.URL == "" {
+		return nil, ErrNoURL
+	}
+	if st.LeaderTTL == 0 {
+		st.LeaderTTL = defaultLeaderTTL
+	}
+	return &Coordinator{st: st}, nil
+}
+
+// Acquire grants the given owner a lease of the least free machine ID.
+// Acquire returns ErrNoFreeMachineID if all the machine IDs in the range are allocated or in quarantine.
+func (c *Coordinator) Acquire(ctx context.Context, owner string) (Allocation, error) {
+	if err := c.checkLeader(ctx); err != nil {
+		return Allocation{}, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	list, err := c.st.Store.List(ctx)
+	if err != nil {
+		return Allocation{}, err
+	}
+
+	now := c.st.Now()
+	stored := make(map[uint16]Allocation, len(list))
+	for _, a := range list {
+		stored[a.MachineID] = a
+	}
+
+	for id := int(c.st.MinMachineID); id <= int(c.st.MaxMachineID); id++ {
+		prev, ok := stored[uint16(id)]
+		if ok && !prev.free(now, c.st.Quarantine) {
+			continue
+		}
+
+		a := Allocation{MachineID: uint16(id), Owner: owner, ExpiresAt: now.Add(c.st.LeaseTTL)}
+		if err := c.st.Store.Put(ctx, a); err != nil {
+			return Allocation{}, err
+		}
+		c.stats.Granted++
+		if ok && !prev.Released {
+			c.stats.Reclaimed++
+		}
+		return a, nil
+	}
+	return Allocation{}, ErrNoFreeMachineID
+}
+
+// Renew extends the lease of the given machine ID held by the given owner.
+// Renew returns ErrNotLeased if the owner does not hold the lease, such as after it expired or was force-released.
+func (c *Coordinator) Renew(ctx context.Context, machineID uint16, owner string) (Allocation, error) {
+	if err := c.checkLeader(ctx); err != nil {
+		return Allocation{}, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	a, err := c.lease(ctx, machineID, owner)
+	if err == ErrNotLeased {
+		c.stats.RenewFailures++
+	}
+	if err != nil {
+		return Allocation{}, err
+	}
+
+	a.ExpiresAt = c.st.Now().Add(c.st.LeaseTTL)
+	if err := c.st.Store.Put(ctx, a); err != nil {
+		return Allocation{}, err
+	}
+	c.stats.Renewed++
+	return a, nil
+}
+
+// Release releases the lease of the given machine ID held by the given owner.
+// Release returns ErrNotLeased if the owner does not hold the lease.
+// The machine ID is in quarantine after the release.
+func (c *Coordinator) Release(ctx context.Context, machineID uint16, owner string) error {
+	if err := c.checkLeader(ctx); err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	a, err := c.lease(ctx, machineID, owner)
+	if err != nil {
+		return err
+	}
+	if err := c.expire(ctx, a); err != nil {
+		return err
+	}
+	c.stats.Released++
+	return nil
+}
+
+// expire ends the given lease now, keeping it in the Store during the quarantine.
+func (c *Coordinator) expire(ctx context.Context, a Allocation) error {
+	if c.st.Quarantine == 0 {
+		return c.st.Store.Delete(ctx, a.MachineID)
+	}
+	a.ExpiresAt = c.st.Now()
+	a.Released = true
+	return c.st.Store.Put(ctx, a)
+}
+
+func (c *Coordinator) lease(ctx context.Context, machineID uint16, owner string) (Allocation, error) {
+	a, ok, err := c.st.Store.Get(ctx, machineID)
+	if err != nil {
+		return Allocation{}, err
+	}
+	if !ok || a.Reserved || a.Owner != owner || a.expired(c.st.Now()) {
+		return Allocation{}, ErrNotLeased
+	}
+	return a, nil
+}
+
+// Allocations returns the current leases, the reservations and the leases in quarantine in the order of machine IDs.
+func (c *Coordinator) Allocations(ctx context.Context) ([]Allocation, error) {
+	if err := c.checkLeader(ctx); err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	list, err := c.st.Store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := c.st.Now()
+	current := list[:0]
+	for _, a := range list {
+		if a.free(now, c.st.Quarantine) {
+			continue
+		}
+		a.Quarantined = a.expired(now)
+		current = append(current, a)
+	}
+	return current, nil
+}
+
+// ForceRelease releases the lease or the reservation of the given machine ID regardless of its owner.
+// The machine ID of a released lease is in quarantine, while that of a reservation is free immediately.
+func (c *Coordinator) ForceRelease(ctx context.Context, machineID uint16) error {
+	if err := c.checkLeader(ctx); err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	a, ok, err := c.st.Store.Get(ctx, machineID)
+	if err != nil || !ok {
+		return err
+	}
+	if a.Reserved {
+		return c.st.Store.Delete(ctx, machineID)
+	}
+	if a.expired(c.st.Now()) {
+		// already in quarantine or free
+		return nil
+	}
+	if err := c.expire(ctx, a); err != nil {
+		return err
+	}
+	c.stats.ForceReleased++
+	return nil
+}
+
+// Reserve reserves the machine IDs from from to to inclusive, so that they are never leased,
+// with a note of why they are reserved.
+// Reserve returns ErrInvalidRange if from is greater than to,
+// or ErrNoFreeMachineID if any of the machine IDs is leased.
+func (c *Coordinator) Reserve(ctx context.Context, from, to uint16, note string) error {
+	if from > to {
+		return ErrInvalidRange
+	}
+
+	if err := c.checkLeader(ctx); err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := c.st.Now()
+	for id := int(from); id <= int(to); id++ {
+		a, ok, err := c.st.Store.Get(ctx, uint16(id))
+		if err != nil {
+			return err
+		}
+		if ok && !a.Reserved && !a.expired(now) {
+			return ErrNoFreeMachineID
+		}
+	}
+
+	for id := int(from); id <= int(to); id++ {
+		if err := c.st.Store.Put(ctx, Allocation{MachineID: uint16(id), Owner: note, Reserved: true}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package coordinator
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func newCoordinator(t *testing.T, st Settings) (*Coordinator, *clock) {
+	clk := &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	st.Now = clk.Now
+	c, err := New(st)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return c, clk
+}
+
+func TestAcquire(t *testing.T) {
+	ctx := context.Background()
+	c, clk := newCoordinator(t, Settings{MinMachineID: 10, MaxMachineID: 11})
+
+	a, err := c.Acquire(ctx, "a")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if a.MachineID != 10 || a.Owner != "a" || !a.ExpiresAt.Equal(clk.now.Add(time.Minute)) {
+		t.Errorf("unexpected lease: %+v", a)
+	}
+
+	b, err := c.Acquire(ctx, "b")
+	if err != nil || b.MachineID != 11 {
+		t.Fatalf("unexpected lease: %+v, %v", b, err)
+	}
+
+	if _, err := c.Acquire(ctx, "c"); err != ErrNoFreeMachineID {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// the lease of a expires while b renews its lease
+	clk.now = clk.now.Add(40 * time.Second)
+	if _, err := c.Renew(ctx, 11, "b"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	clk.now = clk.now.Add(40 * time.Second)
+
+	if _, err := c.Renew(ctx, 10, "a"); err != ErrNotLeased {
+		t.Errorf("unexpected error: %v", err)
+	}
+	next, err := c.Acquire(ctx, "c")
+	if err != nil || next.MachineID != 10 {
+		t.Errorf("unexpected lease: %+v, %v", next, err)
+	}
+}
+
+func TestRelease(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newCoordinator(t, Settings{})
+
+	a, err := c.Acquire(ctx, "a")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := c.Release(ctx, a.MachineID, "b"); err != ErrNotLeased {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := c.Release(ctx, a.MachineID, "a"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	list, err := c.Allocations(ctx)
+	if err != nil || len(list) != 0 {
+		t.Errorf("unexpected allocations: %v, %v", list, err)
+	}
+}
+
+func TestAdmin(t *testing.T) {
+	ctx := context.Background()
+	c, clk := newCoordinator(t, Settings{MaxMachineID: 9})
+
+	if _, err := c.Acquire(ctx, "a"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := c.Reserve(ctx, 0, 3, "leased"); err != ErrNoFreeMachineID {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := c.Reserve(ctx, 3, 2, "invalid"); err != ErrInvalidRange {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := c.Reserve(ctx, 1, 8, "legacy fleet"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	b, err := c.Acquire(ctx, "b")
+	if err != nil || b.MachineID != 9 {
+		t.Errorf("reserved machine ids must not be leased: %+v, %v", b, err)
+	}
+
+	clk.now = clk.now.Add(time.Hour)
+	list, err := c.Allocations(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(list) != 8 || list[0].MachineID != 1 || !list[0].Reserved || list[0].Owner != "legacy fleet" {
+		t.Errorf("unexpected allocations: %v", list)
+	}
+
+	if err := c.ForceRelease(ctx, 1); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	a, err := c.Acquire(ctx, "a")
+	if err != nil || a.MachineID != 0 {
+		t.Errorf("unexpected lease: %+v, %v", a, err)
+	}
+	a, err = c.Acquire(ctx, "c")
+	if err != nil || a.MachineID != 1 {
+		t.Errorf("unexpected lease: %+v, %v", a, err)
+	}
+}
+
+func TestNewInvalidRange(t *testing.T) {
+	if _, err := New(Settings{MinMachineID: 10, MaxMachineID: 9}); err != ErrInvalidRange {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestQuarantine(t *testing.T) {
+	ctx := context.Background()
+	c, clk := newCoordinator(t, Settings{MaxMachineID: 1, Quarantine: 10 * time.Minute})
+
+	a, err := c.Acquire(ctx, "a")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	b, err := c.Acquire(ctx, "b")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := c.Release(ctx, a.MachineID, "a"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := c.ForceRelease(ctx, b.MachineID); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := c.Acquire(ctx, "c"); err != ErrNoFreeMachineID {
+		t.Errorf("released machine ids must be in quarantine: %v", err)
+	}
+	if _, err := c.Renew(ctx, a.MachineID, "a"); err != ErrNotLeased {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	list, err := c.Allocations(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(list) != 2 || !list[0].Quarantined || !list[1].Quarantined {
+		t.Errorf("unexpected allocations: %v", list)
+	}
+
+	clk.now = clk.now.Add(10 * time.Minute)
+	next, err := c.Acquire(ctx, "c")
+	if err != nil || next.MachineID != 0 {
+		t.Errorf("unexpected lease: %+v, %v", next, err)
+	}
+
+	// an expired lease is in quarantine as well
+	clk.now = clk.now.Add(time.Minute)
+	if _, err := c.Acquire(ctx, "d"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := c.Acquire(ctx, "e"); err != ErrNoFreeMachineID {
+		t.Errorf("expired machine ids must be in quarantine: %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+package coordinator
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Handler returns an http.Handler serving the given Coordinator by the following endpoints:
+//
+//	POST   /leases                  acquire a lease: {"owner":"..."}
+//	PUT    /leases/{id}             renew a lease: {"owner":"..."}
+//	DELETE /leases/{id}?owner=...   release a lease
+//	GET    /admin/allocations       list the leases and reservations
+//	DELETE /admin/allocations/{id}  force-release a lease or a reservation
+//	POST   /admin/reservations      reserve a range: {"from":..., "to":..., "note":"..."}
+//
+// Leases and allocations are encoded as Allocation in JSON.
+// Errors are responded as {"error":"..."} with 503 for ErrNoFreeMachineID, 409 for ErrNotLeased,
+// 400 for ErrInvalidRange and malformed requests, and 421 for ErrNotLeader.
+// The admin endpoints should be protected, such as by a reverse proxy.
+//
+// If the Coordinator is not the leader among its replicas, the Handler forwards requests to the leader.
+func Handler(c *Coordinator) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/leases", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeMethodNotAllowed(w)
+			return
+		}
+
+		var req struct {
+			Owner string `json:"owner"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		a, err := c.Acquire(r.Context(), req.Owner)
+		writeResult(w, a, err)
+	})
+	mux.HandleFunc("/leases/", func(w http.ResponseWriter, r *http.Request) {
+		machineID, ok := parseMachineID(w, strings.TrimPrefix(r.URL.Path, "/leases/"))
+		if !ok {
+			return
+		}
+
+		switch r.Method {
+		case http.MethodPut:
+			var req struct {
+				Owner string `json:"owner"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+			a, err := c.Renew(r.Context(), machineID, req.Owner)
+			writeResult(w, a, err)
+		case http.MethodDelete:
+			err := c.Release(r.Context(), machineID, r.URL.Query().Get("owner"))
+			writeResult(w, nil, err)
+		default:
+			writeMethodNotAllowed(w)
+		}
+	})
+	mux.HandleFunc("/admin/allocations", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeMethodNotAllowed(w)
+			return
+		}
+		list, err := c.Allocations(r.Context())
+		writeResult(w, list, err)
+	})
+	mux.HandleFunc("/admin/allocations/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			writeMethodNotAllowed(w)
+			return
+		}
+		machineID, ok := parseMachineID(w, strings.TrimPrefix(r.URL.Path, "/admin/allocations/"))
+		if !ok {
+			return
+		}
+		writeResult(w, nil, c.ForceRelease(r.Context(), machineID))
+	})
+	mux.HandleFunc("/admin/reservations", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeMethodNotAllowed(w)
+			return
+		}
+
+		var req struct {
+			From uint16 `json:"from"`
+			To   uint16 `json:"to"`
+			Note string `json:"note"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeResult(w, nil, c.Reserve(r.Context(), req.From, req.To, req.Note))
+	})
+	return forward(c, mux)
+}
+
+func parseMachineID(w http.ResponseWriter, s string) (uint16, bool) {
+	id, err := strconv.ParseUint(s, 10, 16)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return 0, false
+	}
+	return uint16(id), true
+}
+
+func writeResult(w http.ResponseWriter, v interface{}, err error) {
+	switch {
+	case errors.Is(err, ErrNoFreeMachineID):
+		writeError(w, http.StatusServiceUnavailable, err)
+	case errors.Is(err, ErrNotLeased):
+		writeError(w, http.StatusConflict, err)
+	case errors.Is(err, ErrInvalidRange):
+		writeError(w, http.StatusBadRequest, err)
+	case errors.Is(err, ErrNotLeader):
+		writeError(w, http.StatusMisdirectedRequest, err)
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err)
+	case v == nil:
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeJSON(w, http.StatusOK, v)
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+func writeMethodNotAllowed(w http.ResponseWriter) {
+	writeError(w, http.StatusMethodNotAllowed, errors.New(http.StatusText(http.StatusMethodNotAllowed)))
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header()["Content-Type"] = []string{"application/json; charset=utf-8"}
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
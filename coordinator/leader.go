@@ -0,0 +1,146 @@
+package coordinator
+
+import (
+	"context"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Lock is a lock with expiration shared by the replicas of a coordinator, by which they elect the leader.
+// It is typically implemented by the primitives of the backend of the Store.
+type Lock interface {
+	// TryLock acquires the lock for holder, or extends it if holder already holds it, so that it expires after ttl.
+	// TryLock returns the holder of the lock after the attempt,
+	// which is another replica if the lock is held by it and not expired.
+	TryLock(ctx context.Context, holder string, ttl time.Duration) (string, error)
+	// Unlock releases the lock if holder holds it.
+	Unlock(ctx context.Context, holder string) error
+}
+
+// MemoryLock is a Lock in memory, which elects the leader among Coordinators in the same process.
+type MemoryLock struct {
+	mutex     sync.Mutex
+	holder    string
+	expiresAt time.Time
+	now       func() time.Time
+}
+
+var _ Lock = (*MemoryLock)(nil)
+
+// NewMemoryLock returns a new MemoryLock, which is not held.
+func NewMemoryLock() *MemoryLock {
+	return &MemoryLock{now: time.Now}
+}
+
+// TryLock implements Lock.
+func (l *MemoryLock) TryLock(ctx context.Context, holder string, ttl time.Duration) (string, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	if l.holder == holder || !now.Before(l.expiresAt) {
+		l.holder = holder
+		l.expiresAt = now.Add(ttl)
+	}
+	return l.holder, nil
+}
+
+// Unlock implements Lock.
+func (l *MemoryLock) Unlock(ctx context.Context, holder string) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.holder == holder {
+		l.holder = ""
+		l.expiresAt = time.Time{}
+	}
+	return nil
+}
+
+type leadership struct {
+	mutex     sync.Mutex
+	leader    string
+	checkedAt time.Time
+}
+
+// Leader returns the URL of the replica which is the leader, that is, which grants leases.
+// If Settings.Lock is nil, the Coordinator is always the leader.
+//
+// Leader tries to become or remain the leader by Settings.Lock at most every third of Settings.LeaderTTL,
+// and returns the last result in between.
+func (c *Coordinator) Leader(ctx context.Context) (string, error) {
+	if c.st.Lock == nil {
+		return c.st.URL, nil
+	}
+
+	c.leadership.mutex.Lock()
+	defer c.leadership.mutex.Unlock()
+
+	now := time.Now()
+	if c.leadership.leader != "" && now.Sub(c.leadership.checkedAt) < c.st.LeaderTTL/3 {
+		return c.leadership.leader, nil
+	}
+
+	leader, err := c.st.Lock.TryLock(ctx, c.st.URL, c.st.LeaderTTL)
+	if err != nil {
+		c.leadership.leader = ""
+		return "", err
+	}
+	c.leadership.leader = leader
+	c.leadership.checkedAt = now
+	return leader, nil
+}
+
+// Resign gives up the leadership, if any, so that another replica takes over without waiting for it to expire.
+func (c *Coordinator) Resign(ctx context.Context) error {
+	if c.st.Lock == nil {
+		return nil
+	}
+
+	c.leadership.mutex.Lock()
+	defer c.leadership.mutex.Unlock()
+
+	c.leadership.leader = ""
+	return c.st.Lock.Unlock(ctx, c.st.URL)
+}
+
+func (c *Coordinator) checkLeader(ctx context.Context) error {
+	leader, err := c.Leader(ctx)
+	if err != nil {
+		return err
+	}
+	if leader != c.st.URL {
+		return ErrNotLeader
+	}
+	return nil
+}
+
+// forwardedHeader marks a request forwarded to the leader, so that it is not forwarded again.
+const forwardedHeader = "X-Sonyflake-Coordinator-Forwarded"
+
+// forward returns an http.Handler which serves requests by next if the Coordinator is the leader,
+// or forwards them to the leader otherwise.
+func forward(c *Coordinator, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leader, err := c.Leader(r.Context())
+		if err != nil {
+			writeResult(w, nil, err)
+			return
+		}
+		if leader == c.st.URL || r.Header.Get(forwardedHeader) != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		u, err := url.Parse(leader)
+		if err != nil {
+			writeResult(w, nil, err)
+			return
+		}
+		r.Header.Set(forwardedHeader, c.st.URL)
+		httputil.NewSingleHostReverseProxy(u).ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,111 @@
+package coordinator
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMemoryLock(t *testing.T) {
+	ctx := context.Background()
+	clk := &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := NewMemoryLock()
+	l.now = clk.Now
+
+	if holder, _ := l.TryLock(ctx, "a", time.Second); holder != "a" {
+		t.Errorf("unexpected holder: %s", holder)
+	}
+	if holder, _ := l.TryLock(ctx, "b", time.Second); holder != "a" {
+		t.Errorf("unexpected holder: %s", holder)
+	}
+
+	clk.now = clk.now.Add(500 * time.Millisecond)
+	if holder, _ := l.TryLock(ctx, "a", time.Second); holder != "a" {
+		t.Errorf("unexpected holder: %s", holder)
+	}
+	clk.now = clk.now.Add(700 * time.Millisecond)
+	if holder, _ := l.TryLock(ctx, "b", time.Second); holder != "a" {
+		t.Errorf("the lock must be extended: %s", holder)
+	}
+	clk.now = clk.now.Add(300 * time.Millisecond)
+	if holder, _ := l.TryLock(ctx, "b", time.Second); holder != "b" {
+		t.Errorf("the expired lock must be taken over: %s", holder)
+	}
+
+	l.Unlock(ctx, "a")
+	if holder, _ := l.TryLock(ctx, "a", time.Second); holder != "b" {
+		t.Errorf("unlock by another holder must be ignored: %s", holder)
+	}
+	l.Unlock(ctx, "b")
+	if holder, _ := l.TryLock(ctx, "a", time.Second); holder != "a" {
+		t.Errorf("unexpected holder: %s", holder)
+	}
+}
+
+// newReplica returns a Coordinator served by a new test server at its URL.
+func newReplica(t *testing.T, st Settings) (*Coordinator, *httptest.Server) {
+	var h http.Handler
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r)
+	}))
+
+	st.URL = ts.URL
+	c, err := New(st)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	h = Handler(c)
+	return c, ts
+}
+
+func TestLeaderElection(t *testing.T) {
+	ctx := context.Background()
+	st := Settings{Store: NewMemoryStore(), Lock: NewMemoryLock(), LeaderTTL: 300 * time.Millisecond}
+	leader, ts1 := newReplica(t, st)
+	defer ts1.Close()
+	follower, ts2 := newReplica(t, st)
+	defer ts2.Close()
+
+	if url, err := leader.Leader(ctx); err != nil || url != ts1.URL {
+		t.Fatalf("unexpected leader: %s, %v", url, err)
+	}
+	if url, err := follower.Leader(ctx); err != nil || url != ts1.URL {
+		t.Fatalf("unexpected leader: %s, %v", url, err)
+	}
+	if _, err := follower.Acquire(ctx, "a"); err != ErrNotLeader {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// the follower forwards requests to the leader
+	a, err := Client{URL: ts2.URL}.Acquire("a")
+	if err != nil || a.MachineID != 0 {
+		t.Fatalf("unexpected lease: %+v, %v", a, err)
+	}
+	list, err := leader.Allocations(ctx)
+	if err != nil || len(list) != 1 {
+		t.Errorf("unexpected allocations: %v, %v", list, err)
+	}
+
+	if err := leader.Resign(ctx); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	time.Sleep(st.LeaderTTL / 3)
+
+	if _, err := (Client{URL: ts2.URL}).Renew(a.MachineID, "a"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if url, err := follower.Leader(ctx); err != nil || url != ts2.URL {
+		t.Errorf("the follower must take over: %s, %v", url, err)
+	}
+	if _, err := (Client{URL: ts1.URL}).Renew(a.MachineID, "a"); err != nil {
+		t.Errorf("the resigned leader must forward requests: %s", err)
+	}
+}
+
+func TestNewNoURL(t *testing.T) {
+	if _, err := New(Settings{Lock: NewMemoryLock()}); err != ErrNoURL {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package coordinator
+
+import "time"
+
+// Stats are the statistics of a Coordinator since it was created.
+//
+// Granted is the number of leases granted by Acquire.
+//
+// Renewed is the number of leases renewed by Renew.
+//
+// RenewFailures is the number of calls of Renew which returned ErrNotLeased,
+// that is, of instances trying to renew leases they had lost.
+//
+// Released is the number of leases released by their owners, and ForceReleased is that by operators.
+//
+// Reclaimed is the number of leases granted on machine IDs whose previous leases had expired without release,
+// such as when instances crash or fail to renew their leases.
+type Stats struct {
+	Granted       uint64
+	Renewed       uint64
+	RenewFailures uint64
+	Released      uint64
+	ForceReleased uint64
+	Reclaimed     uint64
+}
+
+// Stats returns the Stats of the Coordinator.
+func (c *Coordinator) Stats() Stats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.stats
+}
+
+// LeaseStats are the statistics of a Lease since it was acquired.
+//
+// AcquiredAt is the time when the lease was acquired, or zero if it is not acquired.
+//
+// Renewals is the number of successful renewals, and RenewFailures is the number of failed ones.
+//
+// RenewLatency is the total time taken by the renewals, successful or not.
+type LeaseStats struct {
+	AcquiredAt    time.Time
+	Renewals      uint64
+	RenewFailures uint64
+	RenewLatency  time.Duration
+}
+
+// Stats returns the LeaseStats of the Lease.
+func (l *Lease) Stats() LeaseStats {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.stats
+}
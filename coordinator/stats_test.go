@@ -0,0 +1,34 @@
+package coordinator
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	ctx := context.Background()
+	c, clk := newCoordinator(t, Settings{MaxMachineID: 2, Quarantine: time.Minute})
+
+	a, _ := c.Acquire(ctx, "a")
+	b, _ := c.Acquire(ctx, "b")
+	d, _ := c.Acquire(ctx, "d")
+	c.Renew(ctx, a.MachineID, "a")
+	c.Renew(ctx, a.MachineID, "b")
+	c.Release(ctx, a.MachineID, "a")
+	c.ForceRelease(ctx, b.MachineID)
+
+	// the lease of d expires without release
+	clk.now = clk.now.Add(2 * time.Minute)
+	c.Renew(ctx, d.MachineID, "d")
+	for _, owner := range []string{"e", "f", "g"} {
+		if _, err := c.Acquire(ctx, owner); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	expected := Stats{Granted: 6, Renewed: 1, RenewFailures: 2, Released: 1, ForceReleased: 1, Reclaimed: 1}
+	if stats := c.Stats(); stats != expected {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
@@ -0,0 +1,103 @@
+package coordinator
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Allocation is a machine ID allocated to an owner.
+//
+// A lease is an Allocation which expires at ExpiresAt unless renewed by its owner.
+// A reservation is an Allocation with Reserved set, which never expires and is never leased.
+// The Owner of a reservation is a note of why it is reserved.
+//
+// Released is set for a lease which was released rather than expired, kept during its quarantine.
+//
+// Quarantined is set by Coordinator.Allocations for a lease which has expired or been released
+// but whose machine ID is not yet free again; it is not meaningful in a Store.
+type Allocation struct {
+	MachineID   uint16    `json:"machine_id"`
+	Owner       string    `json:"owner"`
+	ExpiresAt   time.Time `json:"expires_at,omitempty"`
+	Reserved    bool      `json:"reserved,omitempty"`
+	Released    bool      `json:"released,omitempty"`
+	Quarantined bool      `json:"quarantined,omitempty"`
+}
+
+func (a Allocation) expired(now time.Time) bool {
+	return !a.Reserved && !now.Before(a.ExpiresAt)
+}
+
+// free reports whether the machine ID of the Allocation can be leased again,
+// that is, whether the lease has expired for the quarantine period.
+func (a Allocation) free(now time.Time, quarantine time.Duration) bool {
+	return !a.Reserved && !now.Before(a.ExpiresAt.Add(quarantine))
+}
+
+// Store persists the Allocations of a Coordinator.
+// A Store is accessed by one Coordinator at a time, which serializes its operations.
+type Store interface {
+	// List returns all the Allocations, including expired ones, in the order of machine IDs.
+	List(ctx context.Context) ([]Allocation, error)
+	// Get returns the Allocation of the given machine ID, if any.
+	Get(ctx context.Context, machineID uint16) (Allocation, bool, error)
+	// Put creates or replaces the Allocation of its machine ID.
+	Put(ctx context.Context, a Allocation) error
+	// Delete deletes the Allocation of the given machine ID, if any.
+	Delete(ctx context.Context, machineID uint16) error
+}
+
+// MemoryStore is a Store in memory, which loses the Allocations when the process exits.
+type MemoryStore struct {
+	mutex       sync.Mutex
+	allocations map[uint16]Allocation
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore returns a new empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{allocations: make(map[uint16]Allocation)}
+}
+
+// List implements Store.
+func (s *MemoryStore) List(ctx context.Context) ([]Allocation, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	list := make([]Allocation, 0, len(s.allocations))
+	for _, a := range s.allocations {
+		list = append(list, a)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].MachineID < list[j].MachineID })
+	return list, nil
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(ctx context.Context, machineID uint16) (Allocation, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	a, ok := s.allocations[machineID]
+	return a, ok, nil
+}
+
+// Put implements Store.
+func (s *MemoryStore) Put(ctx context.Context, a Allocation) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.allocations[a.MachineID] = a
+	return nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(ctx context.Context, machineID uint16) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.allocations, machineID)
+	return nil
+}
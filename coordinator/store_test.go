@@ -0,0 +1,12 @@
+package coordinator_test
+
+import (
+	"testing"
+
+	"github.com/sony/sonyflake/coordinator"
+	"github.com/sony/sonyflake/coordinator/storetest"
+)
+
+func TestMemoryStore(t *testing.T) {
+	storetest.Run(t, coordinator.NewMemoryStore())
+}
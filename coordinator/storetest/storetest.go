@@ -0,0 +1,89 @@
+// Package storetest provides a conformance test of implementations of coordinator.Store.
+package storetest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sony/sonyflake/coordinator"
+)
+
+// Run tests the given empty Store, which is modified by the test.
+func Run(t *testing.T, s coordinator.Store) {
+	ctx := context.Background()
+
+	list, err := s.List(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(list) != 0 {
+		t.Fatalf("unexpected allocations of an empty store: %v", list)
+	}
+	if _, ok, err := s.Get(ctx, 1); err != nil || ok {
+		t.Fatalf("unexpected allocation of an empty store: %t, %v", ok, err)
+	}
+
+	expiresAt := time.Date(2024, 1, 1, 0, 0, 0, 123456789, time.UTC)
+	allocations := []coordinator.Allocation{
+		{MachineID: 65535, Owner: "c", ExpiresAt: expiresAt, Released: true},
+		{MachineID: 0, Owner: "a", ExpiresAt: expiresAt},
+		{MachineID: 256, Owner: "legacy fleet", Reserved: true},
+	}
+	for _, a := range allocations {
+		if err := s.Put(ctx, a); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	// replace the lease of 0
+	renewed := coordinator.Allocation{MachineID: 0, Owner: "a", ExpiresAt: expiresAt.Add(time.Minute)}
+	if err := s.Put(ctx, renewed); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	a, ok, err := s.Get(ctx, 0)
+	if err != nil || !ok {
+		t.Fatalf("unexpected allocation: %t, %v", ok, err)
+	}
+	if !equal(a, renewed) {
+		t.Errorf("unexpected allocation: %+v", a)
+	}
+
+	list, err = s.List(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := []coordinator.Allocation{renewed, allocations[2], allocations[0]}
+	if len(list) != len(expected) {
+		t.Fatalf("unexpected allocations: %v", list)
+	}
+	for i := range list {
+		if !equal(list[i], expected[i]) {
+			t.Errorf("unexpected allocation: %+v != %+v", list[i], expected[i])
+		}
+	}
+
+	if err := s.Delete(ctx, 256); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := s.Delete(ctx, 256); err != nil {
+		t.Errorf("deleting a missing allocation must succeed: %s", err)
+	}
+	if _, ok, err := s.Get(ctx, 256); err != nil || ok {
+		t.Errorf("unexpected allocation: %t, %v", ok, err)
+	}
+	list, err = s.List(ctx)
+	if err != nil || len(list) != 2 {
+		t.Errorf("unexpected allocations: %v, %v", list, err)
+	}
+}
+
+// equal reports whether a and b are equal, with the times compared by time.Time.Equal.
+func equal(a, b coordinator.Allocation) bool {
+	if !a.ExpiresAt.Equal(b.ExpiresAt) {
+		return false
+	}
+	a.ExpiresAt, b.ExpiresAt = time.Time{}, time.Time{}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,69 @@
+// Package cursor encodes Sonyflake IDs into opaque pagination cursors for keyset pagination.
+//
+// A cursor carries an ID and the direction of pagination from it,
+// and is signed by HMAC-SHA256 so that clients cannot tamper with it.
+package cursor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+)
+
+// Direction is the direction of pagination from the ID of a cursor.
+type Direction byte
+
+const (
+	// Forward pages through IDs greater than the ID of the cursor.
+	Forward Direction = iota
+	// Backward pages through IDs less than the ID of the cursor.
+	Backward
+)
+
+// ErrInvalidCursor is returned by DecodeCursor if the cursor is malformed or its signature does not match.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+const (
+	payloadLen   = 1 + 8
+	signatureLen = 16
+)
+
+var encoding = base64.RawURLEncoding
+
+// EncodeCursor returns a URL-safe cursor of the given ID and direction signed by the given key.
+// The key must be kept secret by the server.
+func EncodeCursor(key []byte, id uint64, dir Direction) string {
+	b := make([]byte, payloadLen, payloadLen+signatureLen)
+	b[0] = byte(dir)
+	binary.BigEndian.PutUint64(b[1:], id)
+	b = append(b, sign(key, b)...)
+	return encoding.EncodeToString(b)
+}
+
+// DecodeCursor returns the ID and direction of a cursor encoded by EncodeCursor with the same key.
+// DecodeCursor returns ErrInvalidCursor if the cursor is malformed or was not signed by the key.
+func DecodeCursor(key []byte, cursor string) (uint64, Direction, error) {
+	b, err := encoding.DecodeString(cursor)
+	if err != nil || len(b) != payloadLen+signatureLen {
+		return 0, 0, ErrInvalidCursor
+	}
+
+	payload, signature := b[:payloadLen], b[payloadLen:]
+	if !hmac.Equal(signature, sign(key, payload)) {
+		return 0, 0, ErrInvalidCursor
+	}
+
+	dir := Direction(payload[0])
+	if dir != Forward && dir != Backward {
+		return 0, 0, ErrInvalidCursor
+	}
+	return binary.BigEndian.Uint64(payload[1:]), dir, nil
+}
+
+func sign(key, payload []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return mac.Sum(nil)[:signatureLen]
+}
@@ -0,0 +1,61 @@
+package cursor
+
+import (
+	"testing"
+)
+
+var key = []byte("secret")
+
+func TestCursor(t *testing.T) {
+	testCases := []struct {
+		id  uint64
+		dir Direction
+	}{
+		{0, Forward},
+		{1, Backward},
+		{1<<63 - 1, Forward},
+		{1<<64 - 1, Backward},
+	}
+
+	for _, tc := range testCases {
+		c := EncodeCursor(key, tc.id, tc.dir)
+		id, dir, err := DecodeCursor(key, c)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if id != tc.id || dir != tc.dir {
+			t.Errorf("unexpected cursor: %d, %d, expected %d, %d", id, dir, tc.id, tc.dir)
+		}
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	c := EncodeCursor(key, 12345, Forward)
+
+	tampered := []byte(c)
+	if tampered[3] == 'A' {
+		tampered[3] = 'B'
+	} else {
+		tampered[3] = 'A'
+	}
+
+	testCases := []struct {
+		name   string
+		key    []byte
+		cursor string
+	}{
+		{"wrong key", []byte("other"), c},
+		{"tampered", key, string(tampered)},
+		{"truncated", key, c[:len(c)-2]},
+		{"not base64", key, "!" + c[1:]},
+		{"empty", key, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, _, err := DecodeCursor(tc.key, tc.cursor); err != ErrInvalidCursor {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
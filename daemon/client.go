@@ -0,0 +1,73 @@
+package daemon
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+)
+
+// Client is a client of a Server, which is safe for concurrent use.
+type Client struct {
+	mutex sync.Mutex
+	conn  net.Conn
+}
+
+// Dial connects to the Server listening on the Unix domain socket of the given path.
+func Dial(path string) (*Client, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn}, nil
+}
+
+// NextID returns a new ID.
+func (c *Client) NextID() (uint64, error) {
+	ids, err := c.NextIDs(1)
+	if err != nil {
+		return 0, err
+	}
+	return ids[0], nil
+}
+
+// NextIDs returns the given number of new IDs, from 1 to MaxBatchSize, in the order generated by the Server,
+// which is ascending unless its Sonyflake uses Settings.RandomSequence.
+// NextIDs returns the error message of the Server as an error if the Server fails to generate the IDs.
+func (c *Client) NextIDs(count int) ([]uint64, error) {
+	req := make([]byte, 5)
+	req[0] = OpNextIDs
+	binary.BigEndian.PutUint32(req[1:], uint32(count))
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if err := writeFrame(c.conn, req); err != nil {
+		return nil, err
+	}
+	res, err := readFrame(c.conn)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res) == 0 {
+		return nil, ErrInvalidFrame
+	}
+	if res[0] == StatusError {
+		return nil, errors.New(string(res[1:]))
+	}
+	if res[0] != StatusOK || len(res) != 1+8*count {
+		return nil, ErrInvalidFrame
+	}
+
+	ids := make([]uint64, count)
+	for i := range ids {
+		ids[i] = binary.BigEndian.Uint64(res[1+8*i:])
+	}
+	return ids, nil
+}
+
+// Close closes the connection to the Server.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,200 @@
+// Package daemon serves Sonyflake IDs to local processes over a Unix domain socket,
+// so that processes written in any language on the same host share one generator with low latency.
+//
+// The protocol exchanges length-prefixed frames: a 4-byte big-endian length followed by a body.
+// A request body is an opcode byte followed by operands:
+//
+//	0x01 count(uint32)  request count IDs, from 1 to MaxBatchSize
+//
+// A response body is a status byte followed by a payload:
+//
+//	0x00 id(uint64)...  the requested IDs
+//	0x01 message        an error message in UTF-8
+//
+// All integers are in big endian.
+package daemon
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/sony/sonyflake"
+)
+
+// These are the opcodes of requests.
+const (
+	OpNextIDs byte = 0x01
+)
+
+// These are the statuses of responses.
+const (
+	StatusOK    byte = 0x00
+	StatusError byte = 0x01
+)
+
+// MaxBatchSize is the maximum number of IDs requested at once.
+const MaxBatchSize = 4096
+
+// maxFrameLen is the maximum length of a frame body, which fits a response of MaxBatchSize IDs.
+const maxFrameLen = 1 + 8*MaxBatchSize
+
+var (
+	ErrFrameTooLarge = errors.New("frame too large")
+	ErrInvalidFrame  = errors.New("invalid frame")
+)
+
+// Server serves IDs generated by a Sonyflake to clients connected to a listener.
+type Server struct {
+	sf *sonyflake.Sonyflake
+
+	mutex  sync.Mutex
+	ln     net.Listener
+	conns  map[net.Conn]struct{}
+	wg     sync.WaitGroup
+	closed bool
+}
+
+// NewServer returns a new Server serving IDs generated by the given Sonyflake.
+func NewServer(sf *sonyflake.Sonyflake) *Server {
+	return &Server{sf: sf, conns: make(map[net.Conn]struct{})}
+}
+
+// ListenAndServe listens on the Unix domain socket of the given path and serves clients.
+func (s *Server) ListenAndServe(path string) error {
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ln)
+}
+
+// Serve accepts clients on the given listener and serves each of them in a goroutine.
+// Serve returns nil after Close is called.
+func (s *Server) Serve(ln net.Listener) error {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		ln.Close()
+		return nil
+	}
+	s.ln = ln
+	s.mutex.Unlock()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			s.mutex.Lock()
+			closed := s.closed
+			s.mutex.Unlock()
+			if closed {
+				return nil
+			}
+			return err
+		}
+
+		s.mutex.Lock()
+		if s.closed { // Close has started after Accept returned, so conn would never be closed by it
+			s.mutex.Unlock()
+			conn.Close()
+			return nil
+		}
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.mutex.Unlock()
+
+		go s.serveConn(conn)
+	}
+}
+
+// Close stops the Server and closes the listener and all the connections.
+func (s *Server) Close() error {
+	s.mutex.Lock()
+	s.closed = true
+	var err error
+	if s.ln != nil {
+		err = s.ln.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mutex.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer func() {
+		s.mutex.Lock()
+		delete(s.conns, conn)
+		s.mutex.Unlock()
+		conn.Close()
+		s.wg.Done()
+	}()
+
+	for {
+		req, err := readFrame(conn)
+		if err != nil {
+			return
+		}
+		if err := writeFrame(conn, s.handle(req)); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) handle(req []byte) []byte {
+	if len(req) != 5 || req[0] != OpNextIDs {
+		return errorResponse(ErrInvalidFrame)
+	}
+
+	count := binary.BigEndian.Uint32(req[1:])
+	if count < 1 || count > MaxBatchSize {
+		return errorResponse(fmt.Errorf("count must be from 1 to %d", MaxBatchSize))
+	}
+
+	res := make([]byte, 1+8*count)
+	res[0] = StatusOK
+	for i := 0; i < int(count); i++ {
+		id, err := s.sf.NextID()
+		if err != nil {
+			return errorResponse(err)
+		}
+		binary.BigEndian.PutUint64(res[1+8*i:], id)
+	}
+	return res
+}
+
+func errorResponse(err error) []byte {
+	return append([]byte{StatusError}, err.Error()...)
+}
+
+func readFrame(r io.Reader) ([]byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+
+	n := binary.BigEndian.Uint32(header[:])
+	if n > maxFrameLen {
+		return nil, ErrFrameTooLarge
+	}
+
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func writeFrame(w io.Writer, body []byte) error {
+	frame := make([]byte, 4+len(body))
+	binary.BigEndian.PutUint32(frame, uint32(len(body)))
+	copy(frame[4:], body)
+	_, err := w.Write(frame)
+	return err
+}
@@ -0,0 +1,154 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sony/sonyflake"
+)
+
+func startServer(t *testing.T) (*Server, string, func()) {
+	sf, err := sonyflake.New(sonyflake.Settings{
+		MachineID: func() (uint16, error) { return 1, nil },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	dir, err := ioutil.TempDir("", "sonyflake")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	path := filepath.Join(dir, "sonyflake.sock")
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	s := NewServer(sf)
+	done := make(chan error)
+	go func() {
+		done <- s.Serve(ln)
+	}()
+
+	return s, path, func() {
+		if err := s.Close(); err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+		if err := <-done; err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+		os.RemoveAll(dir)
+	}
+}
+
+func TestClient(t *testing.T) {
+	_, path, stop := startServer(t)
+	defer stop()
+
+	c, err := Dial(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer c.Close()
+
+	id, err := c.NextID()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if sonyflake.MachineID(id) != 1 {
+		t.Errorf("unexpected id: %d", id)
+	}
+
+	ids, err := c.NextIDs(100)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for i, next := range ids {
+		if next <= id {
+			t.Fatalf("ids must be increasing: %d at %d", next, i)
+		}
+		id = next
+	}
+
+	if _, err := c.NextIDs(MaxBatchSize + 1); err == nil || !strings.HasPrefix(err.Error(), "count must be") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestClientsConcurrently(t *testing.T) {
+	_, path, stop := startServer(t)
+	defer stop()
+
+	var mutex sync.Mutex
+	seen := make(map[uint64]bool)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		c, err := Dial(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		defer c.Close()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				id, err := c.NextID()
+				if err != nil {
+					t.Errorf("unexpected error: %s", err)
+					return
+				}
+				mutex.Lock()
+				if seen[id] {
+					t.Errorf("duplicated id: %d", id)
+				}
+				seen[id] = true
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// closingListener calls Close of the Server in Accept, as if Close raced with an accepted connection.
+type closingListener struct {
+	net.Listener
+	s    *Server
+	conn net.Conn
+}
+
+func (ln *closingListener) Accept() (net.Conn, error) {
+	ln.s.Close()
+	return ln.conn, nil
+}
+
+func (ln *closingListener) Close() error {
+	return nil
+}
+
+func TestServeAfterClose(t *testing.T) {
+	sf, err := sonyflake.New(sonyflake.Settings{
+		MachineID: func() (uint16, error) { return 1, nil },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	s := NewServer(sf)
+	server, client := net.Pipe()
+	defer client.Close()
+	if err := s.Serve(&closingListener{s: s, conn: server}); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if _, err := client.Write([]byte{0}); err == nil {
+		t.Error("connection accepted during Close must be closed")
+	}
+}
@@ -0,0 +1,64 @@
+// Package debug provides an HTTP handler reporting the state of a live Sonyflake in JSON.
+//
+// Mount it at /debug/sonyflake, for example:
+//
+//	http.Handle("/debug/sonyflake", debug.Handler(sf))
+package debug
+
+import (
+	"encoding/json"
+	"math"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/sony/sonyflake"
+)
+
+// Report is the state of a Sonyflake reported by Handler.
+//
+// ElapsedTime is the current elapsed time since the start time in the time unit of the layout,
+// and Utilization is its ratio to the time limit, from 0 to 1.
+type Report struct {
+	Layout      sonyflake.Layout `json:"layout"`
+	MachineID   uint16           `json:"machine_id"`
+	MachineIP   net.IP           `json:"machine_ip,omitempty"`
+	StartTime   time.Time        `json:"start_time"`
+	ElapsedTime int64            `json:"elapsed_time"`
+	Utilization float64          `json:"utilization"`
+	OverflowAt  time.Time        `json:"overflow_at"`
+	LastID      uint64           `json:"last_id"`
+	Stats       sonyflake.Stats  `json:"stats"`
+}
+
+// NewReport returns the current Report of the given Sonyflake.
+func NewReport(sf *sonyflake.Sonyflake) Report {
+	layout := sf.Layout()
+	elapsedTime := int64(time.Since(layout.StartTime) / layout.TimeUnit)
+
+	return Report{
+		Layout:      layout,
+		MachineID:   sf.Snapshot().MachineID,
+		MachineIP:   sf.MachineIP(),
+		StartTime:   layout.StartTime,
+		ElapsedTime: elapsedTime,
+		Utilization: float64(elapsedTime) / math.Exp2(float64(layout.BitsTime)),
+		OverflowAt:  layout.Time(math.MaxUint64).Add(layout.TimeUnit),
+		LastID:      sf.LastID(),
+		Stats:       sf.Stats(),
+	}
+}
+
+// Handler returns an http.Handler responding the current Report of the given Sonyflake in JSON.
+func Handler(sf *sonyflake.Sonyflake) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := json.Marshal(NewReport(sf))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header()["Content-Type"] = []string{"application/json; charset=utf-8"}
+		w.Write(body)
+	})
+}
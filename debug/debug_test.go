@@ -0,0 +1,58 @@
+package debug
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sony/sonyflake"
+)
+
+func TestHandler(t *testing.T) {
+	startTime := time.Now().Add(-time.Hour).Truncate(10 * time.Millisecond).UTC()
+	sf, err := sonyflake.New(sonyflake.Settings{
+		StartTime: startTime,
+		MachineID: func() (uint16, error) { return 7, nil },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	id, err := sf.NextID()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	rec := httptest.NewRecorder()
+	Handler(sf).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/sonyflake", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", rec.Code)
+	}
+
+	var report Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if report.Layout != sf.Layout() {
+		t.Errorf("unexpected layout: %+v", report.Layout)
+	}
+	if report.MachineID != 7 || report.LastID != id || report.Stats.Generated != 1 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if !report.StartTime.Equal(startTime) {
+		t.Errorf("unexpected start time: %s", report.StartTime)
+	}
+	if report.ElapsedTime < 360000 || report.ElapsedTime > 360000+100 {
+		t.Errorf("unexpected elapsed time: %d", report.ElapsedTime)
+	}
+	if report.Utilization <= 0 || report.Utilization >= 1e-5 {
+		t.Errorf("unexpected utilization: %g", report.Utilization)
+	}
+
+	overflowAt := startTime.Add(time.Duration(1<<39) * 10 * time.Millisecond)
+	if !report.OverflowAt.Equal(overflowAt) {
+		t.Errorf("unexpected overflow time: %s, expected %s", report.OverflowAt, overflowAt)
+	}
+}
@@ -0,0 +1,41 @@
+package sonyflake
+
+import "time"
+
+// Parts is a set of parts of a Sonyflake ID together with the absolute time when it was generated.
+// Time is the raw elapsed time since the start time in the time unit, as returned by Decompose,
+// and GeneratedAt is the time converted from it, which is encoded in RFC 3339 by encoding/json.
+type Parts struct {
+	ID          uint64    `json:"id"`
+	MSB         uint64    `json:"msb"`
+	Time        uint64    `json:"time"`
+	Sequence    uint64    `json:"sequence"`
+	Namespace   uint64    `json:"namespace"`
+	MachineID   uint64    `json:"machine-id"`
+	GeneratedAt time.Time `json:"generated-at"`
+}
+
+// DecomposeVerbose returns the Parts of the given ID in the Layout.
+func (l Layout) DecomposeVerbose(id uint64) Parts {
+	parts := l.Decompose(id)
+	return Parts{
+		ID:          id,
+		MSB:         parts["msb"],
+		Time:        parts["time"],
+		Sequence:    parts["sequence"],
+		Namespace:   parts["namespace"],
+		MachineID:   parts["machine-id"],
+		GeneratedAt: l.Time(id),
+	}
+}
+
+// DecomposeVerbose returns the Parts of the given ID generated by the Sonyflake.
+func (sf *Sonyflake) DecomposeVerbose(id uint64) Parts {
+	return sf.Layout().DecomposeVerbose(id)
+}
+
+// DecomposeVerbose returns the Parts of a Sonyflake ID in the default layout.
+func DecomposeVerbose(id uint64) Parts {
+	layout, _ := Settings{}.Layout() // the default layout is always valid
+	return layout.DecomposeVerbose(id)
+}
@@ -0,0 +1,54 @@
+package sonyflake
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecomposeVerbose(t *testing.T) {
+	generatedAt := time.Date(2024, 1, 2, 3, 4, 5, 60*int(time.Millisecond), time.UTC)
+	elapsedTime := uint64(generatedAt.Sub(time.Date(2014, 9, 1, 0, 0, 0, 0, time.UTC)) / sonyflakeTimeUnit)
+	id := elapsedTime<<(BitLenSequence+BitLenMachineID) | 7<<BitLenMachineID | 9
+
+	parts := DecomposeVerbose(id)
+	expected := Parts{ID: id, Time: elapsedTime, Sequence: 7, MachineID: 9, GeneratedAt: generatedAt}
+	if parts != expected {
+		t.Errorf("unexpected parts: %+v", parts)
+	}
+
+	b, err := json.Marshal(parts)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.Contains(string(b), `"generated-at":"2024-01-02T03:04:05.06Z"`) {
+		t.Errorf("unexpected json: %s", b)
+	}
+}
+
+func TestSonyflakeDecomposeVerbose(t *testing.T) {
+	sf, err := New(Settings{
+		StartTime:    time.Now().Add(-time.Minute),
+		TimeUnit:     time.Millisecond,
+		BitsSequence: 10,
+		MachineID:    func() (uint16, error) { return 5, nil },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	now := time.Now()
+	id, err := sf.NextID()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	parts := sf.DecomposeVerbose(id)
+	if parts.MachineID != 5 || parts.Time != sf.Decompose(id)["time"] {
+		t.Errorf("unexpected parts: %+v", parts)
+	}
+	if d := parts.GeneratedAt.Sub(now); d < -time.Millisecond || d > time.Second {
+		t.Errorf("unexpected generation time: %s", parts.GeneratedAt)
+	}
+}
@@ -0,0 +1,154 @@
+// Package dedupe detects probable duplicates in a stream of Sonyflake IDs with bounded memory.
+//
+// A Filter is a Bloom filter sized for the expected number of IDs and the acceptable false positive rate.
+// It is meant for canary checks, such as rolling out a custom machine ID provider,
+// where every duplicate must be noticed and an occasional false alarm can be verified by hand.
+package dedupe
+
+import (
+	"math"
+	"sync"
+)
+
+// Filter is a Bloom filter of IDs, which is safe for concurrent use.
+type Filter struct {
+	mutex  sync.Mutex
+	bits   []uint64
+	m      uint64
+	k      int
+	count  uint64
+	onDupe func(id uint64)
+}
+
+// Settings configures Filter:
+//
+// Capacity is the expected number of IDs added to the Filter.
+// If Capacity is 0, it is 1,000,000.
+//
+// FalsePositiveRate is the acceptable probability that a new ID is reported as a duplicate
+// after Capacity IDs have been added.
+// If FalsePositiveRate is 0, it is 0.001.
+//
+// OnDuplicate is called with every ID reported as a probable duplicate, if it is not nil.
+type Settings struct {
+	Capacity          uint64
+	FalsePositiveRate float64
+	OnDuplicate       func(id uint64)
+}
+
+const (
+	defaultCapacity          = 1000000
+	defaultFalsePositiveRate = 0.001
+)
+
+// NewFilter returns a new Filter configured with the given Settings.
+// NewFilter panics if FalsePositiveRate is not in (0, 1).
+func NewFilter(st Settings) *Filter {
+	n := st.Capacity
+	if n == 0 {
+		n = defaultCapacity
+	}
+	p := st.FalsePositiveRate
+	if p == 0 {
+		p = defaultFalsePositiveRate
+	}
+	if p <= 0 || p >= 1 {
+		panic("dedupe: invalid false positive rate")
+	}
+
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	m = (m + 63) / 64 * 64
+	k := int(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+
+	return &Filter{
+		bits:   make([]uint64, m/64),
+		m:      m,
+		k:      k,
+		onDupe: st.OnDuplicate,
+	}
+}
+
+// Add adds the given ID to the Filter.
+// Add returns true, and calls Settings.OnDuplicate, if the ID has probably been added before.
+// Add never returns false for an ID that has been added before.
+func (f *Filter) Add(id uint64) bool {
+	h1, h2 := hash(id)
+
+	f.mutex.Lock()
+	dupe := true
+	for i := 0; i < f.k; i++ {
+		bit := (h1 + uint64(i)*h2) % f.m
+		word, mask := bit/64, uint64(1)<<(bit%64)
+		if f.bits[word]&mask == 0 {
+			dupe = false
+			f.bits[word] |= mask
+		}
+	}
+	f.count++
+	f.mutex.Unlock()
+
+	if dupe && f.onDupe != nil {
+		f.onDupe(id)
+	}
+	return dupe
+}
+
+// Contains returns true if the given ID has probably been added to the Filter.
+func (f *Filter) Contains(id uint64) bool {
+	h1, h2 := hash(id)
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for i := 0; i < f.k; i++ {
+		bit := (h1 + uint64(i)*h2) % f.m
+		if f.bits[bit/64]&(uint64(1)<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Count returns the number of IDs added to the Filter.
+func (f *Filter) Count() uint64 {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.count
+}
+
+// Reset removes all the IDs from the Filter.
+func (f *Filter) Reset() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for i := range f.bits {
+		f.bits[i] = 0
+	}
+	f.count = 0
+}
+
+// SizeBytes returns the memory used by the bits of the Filter.
+func (f *Filter) SizeBytes() int {
+	return len(f.bits) * 8
+}
+
+// hash returns two independent hashes of the ID for double hashing.
+// IDs differ mostly in their lower bits, so they are mixed by the finalizer of SplitMix64.
+func hash(id uint64) (uint64, uint64) {
+	h1 := mix(id)
+	h2 := mix(h1 ^ 0x9e3779b97f4a7c15)
+	return h1, h2
+}
+
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
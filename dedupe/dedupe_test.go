@@ -0,0 +1,78 @@
+package dedupe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sony/sonyflake"
+)
+
+func TestFilter(t *testing.T) {
+	var dupes []uint64
+	f := NewFilter(Settings{
+		Capacity:    10000,
+		OnDuplicate: func(id uint64) { dupes = append(dupes, id) },
+	})
+
+	sf, err := sonyflake.New(sonyflake.Settings{
+		StartTime: time.Now(),
+		MachineID: func() (uint16, error) { return 1, nil },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var ids []uint64
+	for i := 0; i < 10000; i++ {
+		id, err := sf.NextID()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		f.Add(id)
+		ids = append(ids, id)
+	}
+	if len(dupes) > 50 {
+		t.Errorf("too many false positives: %d", len(dupes))
+	}
+	if f.Count() != 10000 {
+		t.Errorf("unexpected count: %d", f.Count())
+	}
+
+	dupes = nil
+	for _, id := range ids[:100] {
+		if !f.Contains(id) {
+			t.Fatalf("%d must be contained", id)
+		}
+		if !f.Add(id) {
+			t.Fatalf("%d must be reported as a duplicate", id)
+		}
+	}
+	if len(dupes) != 100 {
+		t.Errorf("unexpected number of duplicates: %d", len(dupes))
+	}
+
+	f.Reset()
+	if f.Contains(ids[0]) || f.Count() != 0 {
+		t.Error("filter must be empty after reset")
+	}
+}
+
+func TestNewFilterSize(t *testing.T) {
+	f := NewFilter(Settings{})
+	// about 1.8 MB for 1,000,000 IDs at 0.1%
+	if size := f.SizeBytes(); size < 1700000 || size > 1900000 {
+		t.Errorf("unexpected size: %d", size)
+	}
+	if f.k != 10 {
+		t.Errorf("unexpected number of hashes: %d", f.k)
+	}
+}
+
+func TestNewFilterInvalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("NewFilter must panic")
+		}
+	}()
+	NewFilter(Settings{FalsePositiveRate: 1})
+}
@@ -0,0 +1,132 @@
+package sonyflake
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidID is returned when a string does not represent a Sonyflake ID.
+var ErrInvalidID = errors.New("invalid id")
+
+// Encoding is a positional string encoding of Sonyflake IDs with an alphabet.
+type Encoding struct {
+	name      string
+	alphabet  string
+	decodeMap [256]int
+}
+
+// NewEncoding returns a new Encoding of the given name with the given alphabet,
+// in which each character is a digit of the value of its index.
+// The alphabet must consist of 2 or more distinct ASCII characters.
+func NewEncoding(name, alphabet string) *Encoding {
+	if len(alphabet) < 2 {
+		panic("sonyflake: encoding alphabet is too short")
+	}
+
+	e := &Encoding{name: name, alphabet: alphabet}
+	for i := range e.decodeMap {
+		e.decodeMap[i] = -1
+	}
+	for i := 0; i < len(alphabet); i++ {
+		if alphabet[i] >= 0x80 || e.decodeMap[alphabet[i]] != -1 {
+			panic("sonyflake: invalid encoding alphabet")
+		}
+		e.decodeMap[alphabet[i]] = i
+	}
+	return e
+}
+
+// These are the predefined encodings.
+var (
+	Base32 = NewEncoding("base32", "0123456789abcdefghjkmnpqrstvwxyz") // Crockford's Base32 in lower case
+	Base58 = NewEncoding("base58", "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz")
+	Base62 = NewEncoding("base62", "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz")
+)
+
+// Name returns the name of the Encoding.
+func (e *Encoding) Name() string {
+	return e.name
+}
+
+// Encode returns the string representation of the given ID.
+func (e *Encoding) Encode(id uint64) string {
+	base := uint64(len(e.alphabet))
+	if id == 0 {
+		return e.alphabet[:1]
+	}
+
+	var b [64]byte
+	i := len(b)
+	for id > 0 {
+		i--
+		b[i] = e.alphabet[id%base]
+		id /= base
+	}
+	return string(b[i:])
+}
+
+// Decode returns the ID represented by the given string.
+// Decode returns ErrInvalidID if the string contains a character out of the alphabet or overflows uint64.
+func (e *Encoding) Decode(s string) (uint64, error) {
+	if s == "" {
+		return 0, ErrInvalidID
+	}
+
+	base := uint64(len(e.alphabet))
+	var id uint64
+	for i := 0; i < len(s); i++ {
+		digit := e.decodeMap[s[i]]
+		if digit < 0 {
+			return 0, ErrInvalidID
+		}
+		if id > (math.MaxUint64-uint64(digit))/base {
+			return 0, ErrInvalidID
+		}
+		id = id*base + uint64(digit)
+	}
+	return id, nil
+}
+
+var (
+	encodingsMutex sync.RWMutex
+	encodings      []*Encoding
+)
+
+// RegisterEncoding registers the given Encoding to be accepted by Parse.
+// Since strings of different encodings may look alike, register only the encodings accepted at your boundaries.
+func RegisterEncoding(e *Encoding) {
+	encodingsMutex.Lock()
+	defer encodingsMutex.Unlock()
+
+	encodings = append(encodings, e)
+}
+
+// Parse returns the ID represented by the given string.
+// Parse tries decimal, hexadecimal with the prefix "0x",
+// and then the encodings registered by RegisterEncoding in the order of registration,
+// and returns the first ID successfully decoded.
+// Parse returns ErrInvalidID if no representation matches.
+func Parse(s string) (uint64, error) {
+	if id, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return id, nil
+	}
+
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		if id, err := strconv.ParseUint(s[2:], 16, 64); err == nil {
+			return id, nil
+		}
+	}
+
+	encodingsMutex.RLock()
+	defer encodingsMutex.RUnlock()
+
+	for _, e := range encodings {
+		if id, err := e.Decode(s); err == nil {
+			return id, nil
+		}
+	}
+	return 0, ErrInvalidID
+}
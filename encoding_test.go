@@ -0,0 +1,73 @@
+package sonyflake
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestEncoding(t *testing.T) {
+	ids := []uint64{0, 1, 57, 58, 61, 62, 1 << 40, math.MaxInt64, math.MaxUint64}
+
+	for _, e := range []*Encoding{Base32, Base58, Base62} {
+		t.Run(e.Name(), func(t *testing.T) {
+			for _, id := range ids {
+				s := e.Encode(id)
+				decoded, err := e.Decode(s)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if decoded != id {
+					t.Errorf("unexpected id: %d, want %d", decoded, id)
+				}
+			}
+
+			if _, err := e.Decode(""); !errors.Is(err, ErrInvalidID) {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if _, err := e.Decode("!"); !errors.Is(err, ErrInvalidID) {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if _, err := e.Decode(e.Encode(math.MaxUint64) + e.Encode(0)); !errors.Is(err, ErrInvalidID) {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	defer func(registered []*Encoding) { encodings = registered }(encodings)
+	encodings = nil
+
+	tests := []struct {
+		s  string
+		id uint64
+	}{
+		{s: "1234", id: 1234},
+		{s: "0x4d2", id: 1234},
+		{s: "0X4D2", id: 1234},
+	}
+	for _, test := range tests {
+		id, err := Parse(test.s)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if id != test.id {
+			t.Errorf("unexpected id: %d, want %d", id, test.id)
+		}
+	}
+
+	s := Base62.Encode(1 << 40)
+	if _, err := Parse(s); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	RegisterEncoding(Base62)
+	id, err := Parse(s)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if id != 1<<40 {
+		t.Errorf("unexpected id: %d", id)
+	}
+}
@@ -0,0 +1,82 @@
+package sonyflake
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// These are the names of the environment variables read by SettingsFromEnv.
+const (
+	EnvBitsSequence  = "SONYFLAKE_BITS_SEQUENCE"
+	EnvBitsMachineID = "SONYFLAKE_BITS_MACHINE_ID"
+	EnvTimeUnit      = "SONYFLAKE_TIME_UNIT"
+	EnvStartTime     = "SONYFLAKE_START_TIME"
+	EnvMachineID     = "SONYFLAKE_MACHINE_ID"
+)
+
+// SettingsFromEnv returns Settings configured by the following environment variables:
+//
+// SONYFLAKE_START_TIME is Settings.StartTime in RFC 3339 format, such as "2014-09-01T00:00:00Z".
+//
+// SONYFLAKE_MACHINE_ID is the machine ID in decimal, which Settings.MachineID returns.
+// If it is not set, default MachineID is used.
+//
+// SONYFLAKE_BITS_SEQUENCE and SONYFLAKE_BITS_MACHINE_ID are Settings.BitsSequence and Settings.BitsMachineID in decimal.
+//
+// SONYFLAKE_TIME_UNIT is Settings.TimeUnit as a duration, such as "10ms".
+//
+// Unset or empty variables are ignored.
+// SettingsFromEnv returns an error if a variable is malformed or the Settings are invalid.
+func SettingsFromEnv() (Settings, error) {
+	var st Settings
+
+	if s := os.Getenv(EnvBitsSequence); s != "" {
+		bits, err := strconv.Atoi(s)
+		if err != nil {
+			return Settings{}, fmt.Errorf("%s: %w", EnvBitsSequence, err)
+		}
+		st.BitsSequence = bits
+	}
+
+	if s := os.Getenv(EnvBitsMachineID); s != "" {
+		bits, err := strconv.Atoi(s)
+		if err != nil {
+			return Settings{}, fmt.Errorf("%s: %w", EnvBitsMachineID, err)
+		}
+		st.BitsMachineID = bits
+	}
+
+	if s := os.Getenv(EnvTimeUnit); s != "" {
+		unit, err := time.ParseDuration(s)
+		if err != nil {
+			return Settings{}, fmt.Errorf("%s: %w", EnvTimeUnit, err)
+		}
+		st.TimeUnit = unit
+	}
+
+	if s := os.Getenv(EnvStartTime); s != "" {
+		startTime, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return Settings{}, fmt.Errorf("%s: %w", EnvStartTime, err)
+		}
+		st.StartTime = startTime
+	}
+
+	if s := os.Getenv(EnvMachineID); s != "" {
+		id, err := strconv.ParseUint(s, 10, BitLenMachineID)
+		if err != nil {
+			return Settings{}, fmt.Errorf("%s: %w", EnvMachineID, err)
+		}
+		machineID := uint16(id)
+		st.MachineID = func() (uint16, error) {
+			return machineID, nil
+		}
+	}
+
+	if err := st.Validate(); err != nil {
+		return Settings{}, err
+	}
+	return st, nil
+}
@@ -0,0 +1,124 @@
+package sonyflake
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func unsetEnv() {
+	for _, key := range []string{EnvBitsSequence, EnvBitsMachineID, EnvTimeUnit, EnvStartTime, EnvMachineID} {
+		os.Unsetenv(key)
+	}
+}
+
+func setEnv(env map[string]string) {
+	unsetEnv()
+	for key, value := range env {
+		os.Setenv(key, value)
+	}
+}
+
+func TestSettingsFromEnv(t *testing.T) {
+	defer unsetEnv()
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		err  error
+	}{
+		{
+			name: "success: empty",
+		},
+		{
+			name: "success: all",
+			env: map[string]string{
+				EnvBitsSequence:  "8",
+				EnvBitsMachineID: "16",
+				EnvTimeUnit:      "10ms",
+				EnvStartTime:     "2014-09-01T00:00:00Z",
+				EnvMachineID:     "1234",
+			},
+		},
+		{
+			name: "failure: bits sequence",
+			env:  map[string]string{EnvBitsSequence: "-1"},
+			err:  ErrInvalidBitsSequence,
+		},
+		{
+			name: "failure: bits machine id",
+			env:  map[string]string{EnvBitsMachineID: "17"},
+			err:  ErrInvalidBitsMachineID,
+		},
+		{
+			name: "failure: time unit",
+			env:  map[string]string{EnvTimeUnit: "1us"},
+			err:  ErrInvalidTimeUnit,
+		},
+		{
+			name: "failure: start time ahead",
+			env:  map[string]string{EnvStartTime: time.Now().Add(time.Hour).Format(time.RFC3339)},
+			err:  ErrStartTimeAhead,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setEnv(test.env)
+
+			_, err := SettingsFromEnv()
+			if !errors.Is(err, test.err) {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestSettingsFromEnvMachineID(t *testing.T) {
+	defer unsetEnv()
+
+	setEnv(map[string]string{
+		EnvStartTime: "2014-09-01T00:00:00Z",
+		EnvMachineID: "1234",
+	})
+
+	st, err := SettingsFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !st.StartTime.Equal(time.Date(2014, 9, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected start time: %s", st.StartTime)
+	}
+
+	id, err := st.MachineID()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if id != 1234 {
+		t.Errorf("unexpected machine id: %d", id)
+	}
+
+	setEnv(map[string]string{EnvMachineID: "65536"})
+	if _, err := SettingsFromEnv(); err == nil {
+		t.Errorf("expected an error for an out of range machine id")
+	}
+}
+
+func TestSettingsFromEnvLayout(t *testing.T) {
+	defer unsetEnv()
+
+	setEnv(map[string]string{
+		EnvBitsSequence:  "12",
+		EnvBitsMachineID: "10",
+		EnvTimeUnit:      "1ms",
+	})
+
+	st, err := SettingsFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if st.BitsSequence != 12 || st.BitsMachineID != 10 || st.TimeUnit != time.Millisecond {
+		t.Errorf("unexpected layout: %d, %d, %s", st.BitsSequence, st.BitsMachineID, st.TimeUnit)
+	}
+}
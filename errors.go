@@ -0,0 +1,82 @@
+package sonyflake
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// InvalidMachineIDError is the error returned when a machine ID is out of range or rejected by a uniqueness check.
+// It matches ErrInvalidMachineID by errors.Is.
+type InvalidMachineIDError struct {
+	Got uint16 // the rejected machine ID
+	Max uint16 // the largest machine ID which fits in the bit length of a machine ID
+}
+
+func (e *InvalidMachineIDError) Error() string {
+	return fmt.Sprintf("%s: %d (max %d)", ErrInvalidMachineID, e.Got, e.Max)
+}
+
+func (e *InvalidMachineIDError) Unwrap() error {
+	return ErrInvalidMachineID
+}
+
+// OverTimeLimitError is the error returned when the Sonyflake time overflows.
+// It matches ErrOverTimeLimit by errors.Is.
+type OverTimeLimitError struct {
+	OverflowAt time.Time // the time at which the Sonyflake time overflows
+}
+
+func (e *OverTimeLimitError) Error() string {
+	return fmt.Sprintf("%s: overflowed at %s", ErrOverTimeLimit, e.OverflowAt.UTC().Format(time.RFC3339))
+}
+
+func (e *OverTimeLimitError) Unwrap() error {
+	return ErrOverTimeLimit
+}
+
+// ClockBackwardError is the error returned by NextID when the clock is behind the time of the last ID
+// by more than Settings.BackwardTolerance.
+// It matches ErrClockBackward by errors.Is.
+type ClockBackwardError struct {
+	Backward  time.Duration // how far the clock is behind the time of the last ID
+	Tolerance time.Duration // Settings.BackwardTolerance
+}
+
+func (e *ClockBackwardError) Error() string {
+	return fmt.Sprintf("%s by %s, beyond the tolerance of %s", ErrClockBackward, e.Backward, e.Tolerance)
+}
+
+func (e *ClockBackwardError) Unwrap() error {
+	return ErrClockBackward
+}
+
+// NoPrivateAddressError is the error returned when default MachineID finds no address to derive the machine ID from.
+// It matches ErrNoPrivateAddress by errors.Is.
+type NoPrivateAddressError struct {
+	Interfaces []string          // the names of the network interfaces looked up
+	Addresses  []RejectedAddress // the addresses inspected and why each was rejected
+}
+
+func (e *NoPrivateAddressError) Error() string {
+	msg := fmt.Sprintf("%s on interfaces [%s]", ErrNoPrivateAddress, strings.Join(e.Interfaces, ", "))
+	if len(e.Addresses) == 0 {
+		return msg + ": no address found"
+	}
+
+	addrs := make([]string, 0, len(e.Addresses))
+	for _, a := range e.Addresses {
+		addrs = append(addrs, fmt.Sprintf("%s (%s)", a.Addr, a.Reason))
+	}
+	return msg + ": " + strings.Join(addrs, ", ")
+}
+
+func (e *NoPrivateAddressError) Unwrap() error {
+	return ErrNoPrivateAddress
+}
+
+// RejectedAddress is an address which default MachineID inspected but did not use.
+type RejectedAddress struct {
+	Addr   string // the address, such as "127.0.0.1"
+	Reason string // why the address was rejected, such as "loopback"
+}
@@ -0,0 +1,101 @@
+package sonyflake
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sony/sonyflake/mock"
+)
+
+func TestInvalidMachineIDError(t *testing.T) {
+	_, err := New(Settings{
+		MachineID:     func() (uint16, error) { return 1024, nil },
+		BitsMachineID: 10,
+	})
+	if !errors.Is(err, ErrInvalidMachineID) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var e *InvalidMachineIDError
+	if !errors.As(err, &e) {
+		t.Fatalf("unexpected error type: %T", err)
+	}
+	if e.Got != 1024 || e.Max != 1023 {
+		t.Errorf("unexpected fields: %+v", e)
+	}
+}
+
+func TestOverTimeLimitError(t *testing.T) {
+	startTime := time.Date(2014, 9, 1, 0, 0, 0, 0, time.UTC)
+	sf, err := New(Settings{
+		StartTime:     startTime,
+		MachineID:     func() (uint16, error) { return 1, nil },
+		BitsSequence:  15,
+		BitsMachineID: 16,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	_, err = sf.NextID()
+	if !errors.Is(err, ErrOverTimeLimit) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var e *OverTimeLimitError
+	if !errors.As(err, &e) {
+		t.Fatalf("unexpected error type: %T", err)
+	}
+	if want := startTime.Add(time.Duration(1<<32) * 10 * time.Millisecond); !e.OverflowAt.Equal(want) {
+		t.Errorf("unexpected overflow time: %s", e.OverflowAt)
+	}
+}
+
+func TestOverTimeLimitErrorMSB(t *testing.T) {
+	startTime := time.Date(2014, 9, 1, 0, 0, 0, 0, time.UTC)
+	sf, err := New(Settings{
+		StartTime: startTime,
+		MachineID: func() (uint16, error) { return 1, nil },
+		UseMSB:    true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// the time limit of 40 bits in units of 10 msec overflows int64 nanoseconds
+	sf.elapsedTime = 1 << 40
+	_, err = sf.toID()
+
+	var e *OverTimeLimitError
+	if !errors.As(err, &e) {
+		t.Fatalf("unexpected error type: %T", err)
+	}
+	half := time.Duration(1<<39) * 10 * time.Millisecond
+	if want := startTime.Add(half).Add(half); !e.OverflowAt.Equal(want) {
+		t.Errorf("unexpected overflow time: %s", e.OverflowAt)
+	}
+}
+
+func TestRejectedAddrs(t *testing.T) {
+	rejected := rejectedAddrs(mock.NewRejectedInterfaceAddrs())
+
+	expected := []RejectedAddress{
+		{Addr: "127.0.0.1", Reason: "loopback"},
+		{Addr: "8.8.8.8", Reason: "public ipv4"},
+		{Addr: "fe80::1", Reason: "link-local ipv6"},
+	}
+	if len(rejected) != len(expected) {
+		t.Fatalf("unexpected rejected addresses: %v", rejected)
+	}
+	for i, a := range rejected {
+		if a != expected[i] {
+			t.Errorf("error: expected: %v, but got: %v", expected[i], a)
+		}
+	}
+
+	err := &NoPrivateAddressError{Interfaces: []string{"eth0"}, Addresses: rejected}
+	if got, want := err.Error(), "no private ip address on interfaces [eth0]: 127.0.0.1 (loopback), 8.8.8.8 (public ipv4), fe80::1 (link-local ipv6)"; got != want {
+		t.Errorf("unexpected message: %s", got)
+	}
+}
@@ -0,0 +1,136 @@
+// Package export streams Sonyflake IDs as rows of their decomposed parts in CSV or NDJSON,
+// for bulk exports of generated batches and data-migration tools.
+//
+// Each row holds the ID, its time in RFC 3339 with nanoseconds, its sequence number, namespace ID and machine ID.
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/sony/sonyflake"
+)
+
+// ErrUnknownFormat is returned by ParseFormat if the format is neither "csv" nor "ndjson".
+var ErrUnknownFormat = errors.New("unknown export format")
+
+// Format is the format of exported rows.
+type Format int
+
+const (
+	// CSV writes a header line with the first row and a line of comma-separated values per ID.
+	CSV Format = iota
+	// NDJSON writes a JSON object per ID and line.
+	// The ID is encoded as a string, since JSON numbers may lose precision beyond 53 bits.
+	NDJSON
+)
+
+// ParseFormat returns the Format of the given name, "csv" or "ndjson".
+func ParseFormat(name string) (Format, error) {
+	switch name {
+	case "csv":
+		return CSV, nil
+	case "ndjson":
+		return NDJSON, nil
+	}
+	return 0, ErrUnknownFormat
+}
+
+// Row is the decomposed parts of an ID.
+type Row struct {
+	ID        uint64    `json:"id,string"`
+	Time      time.Time `json:"time"`
+	Sequence  uint64    `json:"sequence"`
+	Namespace uint64    `json:"namespace"`
+	MachineID uint64    `json:"machine_id"`
+}
+
+// NewRow returns the Row of the given ID in the given Layout, which must be valid.
+func NewRow(l sonyflake.Layout, id uint64) Row {
+	parts := l.Decompose(id)
+	return Row{
+		ID:        id,
+		Time:      l.Time(id).UTC(),
+		Sequence:  parts["sequence"],
+		Namespace: parts["namespace"],
+		MachineID: parts["machine-id"],
+	}
+}
+
+var header = []string{"id", "time", "sequence", "namespace", "machine_id"}
+
+// Writer writes the rows of IDs in a Format.
+// Rows are buffered, so Flush must be called after the last row.
+type Writer struct {
+	layout sonyflake.Layout
+	w      *bufio.Writer
+	csv    *csv.Writer
+	json   *json.Encoder
+	header bool
+}
+
+// NewWriter returns a new Writer of the rows of IDs in the given Layout to w in the given Format.
+// It returns the error of Layout.Validate if the Layout is invalid.
+func NewWriter(w io.Writer, l sonyflake.Layout, f Format) (*Writer, error) {
+	if err := l.Validate(); err != nil {
+		return nil, err
+	}
+
+	bw := bufio.NewWriter(w)
+	ew := &Writer{layout: l, w: bw}
+	switch f {
+	case CSV:
+		ew.csv = csv.NewWriter(bw)
+	default:
+		ew.json = json.NewEncoder(bw)
+	}
+	return ew, nil
+}
+
+// Write writes the row of the given ID.
+func (w *Writer) Write(id uint64) error {
+	row := NewRow(w.layout, id)
+	if w.csv == nil {
+		return w.json.Encode(row)
+	}
+
+	if !w.header {
+		w.header = true
+		if err := w.csv.Write(header); err != nil {
+			return err
+		}
+	}
+	return w.csv.Write([]string{
+		strconv.FormatUint(row.ID, 10),
+		row.Time.Format(time.RFC3339Nano),
+		strconv.FormatUint(row.Sequence, 10),
+		strconv.FormatUint(row.Namespace, 10),
+		strconv.FormatUint(row.MachineID, 10),
+	})
+}
+
+// WriteBatch writes the rows of the given IDs.
+func (w *Writer) WriteBatch(ids []uint64) error {
+	for _, id := range ids {
+		if err := w.Write(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush writes the buffered rows to the underlying io.Writer.
+func (w *Writer) Flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		if err := w.csv.Error(); err != nil {
+			return err
+		}
+	}
+	return w.w.Flush()
+}
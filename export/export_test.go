@@ -0,0 +1,85 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sony/sonyflake"
+)
+
+func newLayout(t *testing.T) sonyflake.Layout {
+	l, err := sonyflake.Settings{StartTime: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}.Layout()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return l
+}
+
+// compose returns the ID at 1 sec after the start time with sequence 2 and machine ID 3.
+func compose() uint64 {
+	return 100<<24 | 2<<16 | 3
+}
+
+func TestCSV(t *testing.T) {
+	var b bytes.Buffer
+	w, err := NewWriter(&b, newLayout(t), CSV)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := w.WriteBatch([]uint64{compose()}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := "id,time,sequence,namespace,machine_id\n" +
+		"1677852675,2020-01-01T00:00:01Z,2,0,3\n"
+	if b.String() != expected {
+		t.Errorf("unexpected csv: %q", b.String())
+	}
+}
+
+func TestNDJSON(t *testing.T) {
+	var b bytes.Buffer
+	w, err := NewWriter(&b, newLayout(t), NDJSON)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := w.WriteBatch([]uint64{compose(), compose() + 1}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	dec := json.NewDecoder(&b)
+	var row Row
+	if err := dec.Decode(&row); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := Row{ID: compose(), Time: time.Date(2020, 1, 1, 0, 0, 1, 0, time.UTC), Sequence: 2, MachineID: 3}
+	if row != expected {
+		t.Errorf("unexpected row: %+v", row)
+	}
+	if err := dec.Decode(&row); err != nil || row.MachineID != 4 {
+		t.Errorf("unexpected second row: %+v, %v", row, err)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat("ndjson"); err != nil || f != NDJSON {
+		t.Errorf("unexpected format: %v, %v", f, err)
+	}
+	if _, err := ParseFormat("xml"); err != ErrUnknownFormat {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestNewWriterInvalidLayout(t *testing.T) {
+	if _, err := NewWriter(&bytes.Buffer{}, sonyflake.Layout{}, CSV); err != sonyflake.ErrInvalidTimeUnit {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+// Package gcputil provides utility functions for using Sonyflake on Google Cloud.
+package gcputil
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/sony/sonyflake"
+)
+
+const (
+	gceZoneEndpoint = "http://metadata.google.internal/computeMetadata/v1/instance/zone"
+	gceNameEndpoint = "http://metadata.google.internal/computeMetadata/v1/instance/name"
+)
+
+// ErrZoneIndexOverflow is returned by the function of GCEMachineID
+// if the index of the zone does not fit in its bits.
+var ErrZoneIndexOverflow = errors.New("zone index overflows its bits")
+
+func gceMetadata(endpoint string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", errors.New(res.Status)
+	}
+	return string(body), nil
+}
+
+// GCEMachineID returns a function usable as Settings.MachineID
+// which composes the machine ID of bitsMachineID bits for the Compute Engine instance from the metadata server:
+// the index of its zone in the upper bitsZone bits, and a hash of its project, zone and instance name in the rest,
+// so that multi-zone managed instance groups, whose instances often share private IP addresses across zones,
+// are less likely to collide.
+//
+// The index of a zone is that of its letter, such as 0 of "us-central1-a" and 5 of "us-central1-f".
+// Hashed machine IDs may still collide among many instances in a zone, so combine it with CheckMachineID for large groups.
+// The function returns sonyflake.ErrInvalidBitsMachineID if bitsZone is not positive or bitsMachineID is not greater
+// than bitsZone or more than 16, and ErrZoneIndexOverflow if the index does not fit in bitsZone bits.
+func GCEMachineID(bitsZone, bitsMachineID int) func() (uint16, error) {
+	return func() (uint16, error) {
+		if bitsZone <= 0 || bitsMachineID <= bitsZone || bitsMachineID > sonyflake.BitLenMachineID {
+			return 0, sonyflake.ErrInvalidBitsMachineID
+		}
+
+		zone, err := gceMetadata(gceZoneEndpoint)
+		if err != nil {
+			return 0, fmt.Errorf("gce zone from %s: %w", gceZoneEndpoint, err)
+		}
+		name, err := gceMetadata(gceNameEndpoint)
+		if err != nil {
+			return 0, fmt.Errorf("gce instance name from %s: %w", gceNameEndpoint, err)
+		}
+
+		return machineID(zone, name, bitsZone, bitsMachineID)
+	}
+}
+
+// machineID composes the machine ID of the instance of the given name
+// in the given zone such as "projects/123456789/zones/us-central1-a".
+func machineID(zone, name string, bitsZone, bitsMachineID int) (uint16, error) {
+	index, err := zoneIndex(zone)
+	if err != nil {
+		return 0, err
+	}
+	if uint32(index) >= 1<<uint(bitsZone) {
+		return 0, ErrZoneIndexOverflow
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(zone + "/" + name))
+
+	bitsHash := uint(bitsMachineID - bitsZone)
+	return index<<bitsHash | uint16(h.Sum32())&(1<<bitsHash-1), nil
+}
+
+// zoneIndex returns the index of the letter of the given zone.
+func zoneIndex(zone string) (uint16, error) {
+	zone = zone[strings.LastIndex(zone, "/")+1:]
+	i := strings.LastIndex(zone, "-")
+	if i < 0 || len(zone) != i+2 || zone[i+1] < 'a' || zone[i+1] > 'z' {
+		return 0, fmt.Errorf("invalid zone %q", zone)
+	}
+	return uint16(zone[i+1] - 'a'), nil
+}
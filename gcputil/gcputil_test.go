@@ -0,0 +1,57 @@
+package gcputil
+
+import "testing"
+
+func TestZoneIndex(t *testing.T) {
+	tests := []struct {
+		zone  string
+		index uint16
+		ok    bool
+	}{
+		{"projects/123456789/zones/us-central1-a", 0, true},
+		{"projects/123456789/zones/us-central1-f", 5, true},
+		{"europe-west1-b", 1, true},
+		{"projects/123456789/zones/us-central1", 0, false},
+		{"", 0, false},
+	}
+
+	for _, tc := range tests {
+		index, err := zoneIndex(tc.zone)
+		if (err == nil) != tc.ok || index != tc.index {
+			t.Errorf("unexpected index of %q: %d, %v", tc.zone, index, err)
+		}
+	}
+}
+
+func TestMachineID(t *testing.T) {
+	const zone = "projects/123456789/zones/us-central1-c"
+
+	id, err := machineID(zone, "vm-1", 3, 16)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if id>>13 != 2 {
+		t.Errorf("unexpected zone bits of %#x", id)
+	}
+	if again, _ := machineID(zone, "vm-1", 3, 16); again != id {
+		t.Errorf("machine id must be stable: %#x != %#x", again, id)
+	}
+	if other, _ := machineID(zone, "vm-2", 3, 16); other == id {
+		t.Errorf("machine ids of different instances must differ: %#x", other)
+	}
+	if other, _ := machineID("projects/987654321/zones/us-central1-c", "vm-1", 3, 16); other == id {
+		t.Errorf("machine ids in different projects must differ: %#x", other)
+	}
+
+	if _, err := machineID(zone, "vm-1", 1, 16); err != ErrZoneIndexOverflow {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestGCEMachineIDInvalidBits(t *testing.T) {
+	for _, bits := range [][2]int{{0, 16}, {4, 4}, {4, 17}} {
+		if _, err := GCEMachineID(bits[0], bits[1])(); err == nil {
+			t.Errorf("error expected for %v", bits)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+// Package gossip detects machine ID collisions at runtime.
+//
+// An Announcer periodically announces the machine ID of a Sonyflake instance to its peers
+// and reports any other instance claiming the same machine ID,
+// so that misconfigurations are caught before duplicated IDs hit the database.
+package gossip
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Transport delivers announcements between instances.
+//
+// Send broadcasts a message to all peers, possibly including the sender itself.
+// Receive blocks until a message arrives and returns it along with a description of the sender.
+// After Close is called, Receive must return an error.
+type Transport interface {
+	Send(msg []byte) error
+	Receive() (msg []byte, from string, err error)
+	Close() error
+}
+
+// Settings configures Announcer:
+//
+// MachineID is the machine ID to announce.
+//
+// Transport delivers announcements between instances.
+// If Transport is nil, Announcer is not created.
+//
+// Interval is the period of announcements.
+// If Interval is 0, announcements are sent every 10 seconds.
+//
+// OnCollision is called with the sender of an announcement claiming the same machine ID.
+// If OnCollision is nil, collisions are only counted.
+//
+// OnError is called when sending or receiving an announcement fails.
+// If OnError is nil, errors are ignored.
+// After a failure of receiving, the next receipt is delayed by a backoff doubling up to Interval,
+// so that a broken transport does not make Announcer spin.
+type Settings struct {
+	MachineID   uint16
+	Transport   Transport
+	Interval    time.Duration
+	OnCollision func(machineID uint16, from string)
+	OnError     func(error)
+}
+
+// Announcer announces a machine ID and detects collisions with its peers.
+type Announcer struct {
+	collisions uint64 // accessed atomically; kept first for 64-bit alignment
+
+	machineID   uint16
+	instance    [8]byte
+	transport   Transport
+	interval    time.Duration
+	onCollision func(uint16, string)
+	onError     func(error)
+
+	closeOnce sync.Once
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+var (
+	ErrNoTransport     = errors.New("no transport")
+	ErrInvalidInterval = errors.New("invalid interval")
+)
+
+const defaultInterval = 10 * time.Second
+
+// minRetryDelay is the delay of receiving after the first of consecutive failures.
+const minRetryDelay = 10 * time.Millisecond
+
+var magic = []byte("SFG1")
+
+const messageLen = 4 + 2 + 8 // magic, machine ID, and instance
+
+// New returns a new Announcer configured with the given Settings and starts announcing.
+// New returns an error in the following cases:
+// - Settings.Transport is nil.
+// - Settings.Interval is negative.
+func New(st Settings) (*Announcer, error) {
+	if st.Transport == nil {
+		return nil, ErrNoTransport
+	}
+	if st.Interval < 0 {
+		return nil, ErrInvalidInterval
+	}
+
+	a := &Announcer{
+		machineID:   st.MachineID,
+		transport:   st.Transport,
+		interval:    st.Interval,
+		onCollision: st.OnCollision,
+		onError:     st.OnError,
+		done:        make(chan struct{}),
+	}
+	if a.interval == 0 {
+		a.interval = defaultInterval
+	}
+	if _, err := rand.Read(a.instance[:]); err != nil {
+		return nil, err
+	}
+
+	a.wg.Add(2)
+	go a.announce()
+	go a.listen()
+	return a, nil
+}
+
+// Collisions returns the number of announcements received so far that claimed the same machine ID.
+func (a *Announcer) Collisions() uint64 {
+	return atomic.LoadUint64(&a.collisions)
+}
+
+// Close stops announcing and closes the transport.
+func (a *Announcer) Close() error {
+	var err error
+	a.closeOnce.Do(func() {
+		close(a.done)
+		err = a.transport.Close()
+		a.wg.Wait()
+	})
+	return err
+}
+
+func (a *Announcer) announce() {
+	defer a.wg.Done()
+
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	msg := a.message()
+	for {
+		if err := a.transport.Send(msg); err != nil {
+			a.reportError(err)
+		}
+
+		select {
+		case <-a.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *Announcer) listen() {
+	defer a.wg.Done()
+
+	var delay time.Duration
+	for {
+		msg, from, err := a.transport.Receive()
+		if err != nil {
+			select {
+			case <-a.done:
+				return
+			default:
+			}
+			a.reportError(err)
+
+			delay = retryDelay(delay, a.interval)
+			select {
+			case <-a.done:
+				return
+			case <-time.After(delay):
+			}
+			continue
+		}
+		delay = 0
+
+		machineID, instance, err := parseMessage(msg)
+		if err != nil {
+			a.reportError(fmt.Errorf("announcement from %s: %w", from, err))
+			continue
+		}
+		if machineID != a.machineID || instance == a.instance {
+			continue
+		}
+
+		atomic.AddUint64(&a.collisions, 1)
+		if a.onCollision != nil {
+			a.onCollision(machineID, from)
+		}
+	}
+}
+
+// retryDelay returns the delay of receiving after a failure, which doubles the previous delay up to max.
+func retryDelay(previous, max time.Duration) time.Duration {
+	delay := 2 * previous
+	if delay < minRetryDelay {
+		delay = minRetryDelay
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+func (a *Announcer) reportError(err error) {
+	if a.onError != nil {
+		a.onError(err)
+	}
+}
+
+func (a *Announcer) message() []byte {
+	msg := make([]byte, messageLen)
+	copy(msg, magic)
+	binary.BigEndian.PutUint16(msg[4:], a.machineID)
+	copy(msg[6:], a.instance[:])
+	return msg
+}
+
+var errInvalidMessage = errors.New("invalid message")
+
+func parseMessage(msg []byte) (uint16, [8]byte, error) {
+	var instance [8]byte
+	if len(msg) != messageLen || !bytes.Equal(msg[:4], magic) {
+		return 0, instance, errInvalidMessage
+	}
+
+	copy(instance[:], msg[6:])
+	return binary.BigEndian.Uint16(msg[4:]), instance, nil
+}
@@ -0,0 +1,180 @@
+package gossip
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// hub delivers every message sent by any of its transports to all of them.
+type hub struct {
+	mutex      sync.Mutex
+	transports []*localTransport
+}
+
+type localTransport struct {
+	hub    *hub
+	name   string
+	inbox  chan []byte
+	closed chan struct{}
+	once   sync.Once
+}
+
+func (h *hub) newTransport(name string) *localTransport {
+	t := &localTransport{hub: h, name: name, inbox: make(chan []byte, 16), closed: make(chan struct{})}
+	h.mutex.Lock()
+	h.transports = append(h.transports, t)
+	h.mutex.Unlock()
+	return t
+}
+
+func (t *localTransport) Send(msg []byte) error {
+	t.hub.mutex.Lock()
+	defer t.hub.mutex.Unlock()
+	for _, peer := range t.hub.transports {
+		select {
+		case peer.inbox <- append([]byte(t.name+"|"), msg...):
+		default:
+		}
+	}
+	return nil
+}
+
+func (t *localTransport) Receive() ([]byte, string, error) {
+	select {
+	case <-t.closed:
+		return nil, "", errors.New("closed")
+	case msg := <-t.inbox:
+		for i, b := range msg {
+			if b == '|' {
+				return msg[i+1:], string(msg[:i]), nil
+			}
+		}
+		return msg, "", nil
+	}
+}
+
+func (t *localTransport) Close() error {
+	t.once.Do(func() { close(t.closed) })
+	return nil
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(Settings{}); !errors.Is(err, ErrNoTransport) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	h := new(hub)
+	if _, err := New(Settings{Transport: h.newTransport("a"), Interval: -1}); !errors.Is(err, ErrInvalidInterval) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCollision(t *testing.T) {
+	h := new(hub)
+	collided := make(chan string, 16)
+
+	newAnnouncer := func(name string, machineID uint16) *Announcer {
+		a, err := New(Settings{
+			MachineID: machineID,
+			Transport: h.newTransport(name),
+			Interval:  10 * time.Millisecond,
+			OnCollision: func(id uint16, from string) {
+				if id != 1 {
+					t.Errorf("unexpected machine id: %d", id)
+				}
+				collided <- name + "<-" + from
+			},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		return a
+	}
+
+	a := newAnnouncer("a", 1)
+	defer a.Close()
+	b := newAnnouncer("b", 2)
+	defer b.Close()
+	c := newAnnouncer("c", 1)
+	defer c.Close()
+
+	seen := make(map[string]bool)
+	timeout := time.After(5 * time.Second)
+	for !seen["a<-c"] || !seen["c<-a"] {
+		select {
+		case s := <-collided:
+			seen[s] = true
+		case <-timeout:
+			t.Fatalf("collision not detected: %v", seen)
+		}
+	}
+
+	if a.Collisions() == 0 || c.Collisions() == 0 {
+		t.Errorf("unexpected collisions: %d, %d", a.Collisions(), c.Collisions())
+	}
+	if b.Collisions() != 0 {
+		t.Errorf("unexpected collisions: %d", b.Collisions())
+	}
+	for s := range seen {
+		if s != "a<-c" && s != "c<-a" {
+			t.Errorf("unexpected collision: %s", s)
+		}
+	}
+}
+
+// brokenTransport fails to receive until closed.
+type brokenTransport struct {
+	localTransport
+}
+
+func (t *brokenTransport) Receive() ([]byte, string, error) {
+	return nil, "", errors.New("broken")
+}
+
+func TestReceiveBackoff(t *testing.T) {
+	var errs uint64
+	a, err := New(Settings{
+		Transport: &brokenTransport{localTransport{hub: &hub{}, closed: make(chan struct{})}},
+		Interval:  40 * time.Millisecond,
+		OnError:   func(error) { atomic.AddUint64(&errs, 1) },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	a.Close()
+
+	// delays of 10, 20, 40, 40, ... msec allow about 7 failures in 200 msec
+	if n := atomic.LoadUint64(&errs); n > 20 {
+		t.Errorf("receive must back off after failures: %d errors", n)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	var delay time.Duration
+	for _, expected := range []time.Duration{10, 20, 40, 40} {
+		delay = retryDelay(delay, 40*time.Millisecond)
+		if delay != expected*time.Millisecond {
+			t.Errorf("unexpected delay: %s, expected %s", delay, expected*time.Millisecond)
+		}
+	}
+}
+
+func TestParseMessage(t *testing.T) {
+	a := &Announcer{machineID: 0x1234, instance: [8]byte{1, 2, 3, 4, 5, 6, 7, 8}}
+
+	machineID, instance, err := parseMessage(a.message())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if machineID != a.machineID || instance != a.instance {
+		t.Errorf("unexpected message: %d, %v", machineID, instance)
+	}
+
+	if _, _, err := parseMessage([]byte("invalid")); err == nil {
+		t.Errorf("expected an error for an invalid message")
+	}
+}
@@ -0,0 +1,95 @@
+package gossip
+
+import (
+	"errors"
+	"net"
+	"syscall"
+)
+
+// ErrNoIPv4Address is returned by NewUDPMulticastTransport
+// if the network interface given for an IPv4 multicast group has no IPv4 address.
+var ErrNoIPv4Address = errors.New("no ipv4 address of the network interface")
+
+// ErrMulticastInterface is returned by NewUDPMulticastTransport
+// if the network interface cannot be selected for sending on the platform.
+var ErrMulticastInterface = errors.New("multicast interface not supported")
+
+// UDPMulticastTransport is a Transport sending announcements to a UDP multicast group.
+type UDPMulticastTransport struct {
+	sender   *net.UDPConn
+	listener *net.UDPConn
+}
+
+// NewUDPMulticastTransport returns a Transport joining the multicast group address, such as "239.255.77.77:7777",
+// on the given network interface, and sending announcements out of it.
+// If ifi is nil, the system-assigned multicast interface is used.
+func NewUDPMulticastTransport(address string, ifi *net.Interface) (*UDPMulticastTransport, error) {
+	group, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.ListenMulticastUDP("udp", ifi, group)
+	if err != nil {
+		return nil, err
+	}
+
+	var dialer net.Dialer
+	if ifi != nil {
+		ipv6 := group.IP.To4() == nil
+		dialer.Control = func(network, address string, c syscall.RawConn) error {
+			return setMulticastInterface(c, ifi, ipv6)
+		}
+	}
+	sender, err := dialer.Dial("udp", group.String())
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	return &UDPMulticastTransport{sender: sender.(*net.UDPConn), listener: listener}, nil
+}
+
+// interfaceIPv4 returns the first IPv4 address of the network interface.
+func interfaceIPv4(ifi *net.Interface) ([4]byte, error) {
+	var ip [4]byte
+
+	addrs, err := ifi.Addrs()
+	if err != nil {
+		return ip, err
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok {
+			if ip4 := ipNet.IP.To4(); ip4 != nil {
+				copy(ip[:], ip4)
+				return ip, nil
+			}
+		}
+	}
+	return ip, ErrNoIPv4Address
+}
+
+// Send sends msg to the multicast group.
+func (t *UDPMulticastTransport) Send(msg []byte) error {
+	_, err := t.sender.Write(msg)
+	return err
+}
+
+// Receive returns the next message sent to the multicast group and the address of its sender.
+func (t *UDPMulticastTransport) Receive() ([]byte, string, error) {
+	buf := make([]byte, 64)
+	n, from, err := t.listener.ReadFromUDP(buf)
+	if err != nil {
+		return nil, "", err
+	}
+	return buf[:n], from.String(), nil
+}
+
+// Close leaves the multicast group.
+func (t *UDPMulticastTransport) Close() error {
+	err := t.sender.Close()
+	if lerr := t.listener.Close(); err == nil {
+		err = lerr
+	}
+	return err
+}
@@ -0,0 +1,52 @@
+package gossip
+
+import (
+	"net"
+	"syscall"
+	"testing"
+)
+
+func multicastInterface(t *testing.T) (*net.Interface, [4]byte) {
+	ifs, err := net.Interfaces()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for i := range ifs {
+		ifi := &ifs[i]
+		if ifi.Flags&net.FlagUp == 0 || ifi.Flags&net.FlagMulticast == 0 || ifi.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		if ip, err := interfaceIPv4(ifi); err == nil {
+			return ifi, ip
+		}
+	}
+	t.Skip("no multicast interface")
+	return nil, [4]byte{}
+}
+
+func TestUDPMulticastTransportInterface(t *testing.T) {
+	ifi, ip := multicastInterface(t)
+
+	transport, err := NewUDPMulticastTransport("239.255.77.77:7777", ifi)
+	if err != nil {
+		t.Skipf("cannot join the multicast group: %s", err)
+	}
+	defer transport.Close()
+
+	c, err := transport.sender.SyscallConn()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var got [4]byte
+	if cerr := c.Control(func(fd uintptr) {
+		got, err = syscall.GetsockoptInet4Addr(int(fd), syscall.IPPROTO_IP, syscall.IP_MULTICAST_IF)
+	}); cerr != nil {
+		t.Fatalf("unexpected error: %s", cerr)
+	}
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got != ip {
+		t.Errorf("unexpected multicast interface: %v, expected %v of %s", net.IP(got[:]), net.IP(ip[:]), ifi.Name)
+	}
+}
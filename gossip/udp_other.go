@@ -0,0 +1,14 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!windows
+
+package gossip
+
+import (
+	"net"
+	"syscall"
+)
+
+// setMulticastInterface returns ErrMulticastInterface on the other platforms.
+func setMulticastInterface(c syscall.RawConn, ifi *net.Interface, ipv6 bool) error {
+	return ErrMulticastInterface
+}
@@ -0,0 +1,30 @@
+package gossip
+
+import (
+	"net"
+	"os"
+	"syscall"
+)
+
+// setMulticastInterface sets the network interface out of which the socket sends multicast packets.
+func setMulticastInterface(c syscall.RawConn, ifi *net.Interface, ipv6 bool) error {
+	var ip [4]byte
+	if !ipv6 {
+		var err error
+		if ip, err = interfaceIPv4(ifi); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if cerr := c.Control(func(fd uintptr) {
+		if ipv6 {
+			err = syscall.SetsockoptInt(syscall.Handle(fd), syscall.IPPROTO_IPV6, syscall.IPV6_MULTICAST_IF, ifi.Index)
+		} else {
+			err = syscall.SetsockoptInet4Addr(syscall.Handle(fd), syscall.IPPROTO_IP, syscall.IP_MULTICAST_IF, ip)
+		}
+	}); cerr != nil {
+		return cerr
+	}
+	return os.NewSyscallError("setsockopt", err)
+}
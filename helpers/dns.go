@@ -0,0 +1,92 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrNoDNSMachineID is returned by DNSMachineID when the DNS records assign no machine ID.
+var ErrNoDNSMachineID = errors.New("no machine id in dns records")
+
+// DNSMachineID returns a function usable as Settings.MachineID
+// that looks up the given DNS name by the given resolver, or net.DefaultResolver if it is nil,
+// and returns the machine ID assigned by its records,
+// so that shops managing host metadata in DNS need no additional infrastructure.
+//
+// A TXT record assigns the machine ID in decimal, such as "42" or "machine-id=42".
+// If the name has no such TXT record, the lower 16 bits of the first IPv4 address of its A records are the machine ID.
+// If the TXT lookup fails for another reason than the absence of records, such as a timeout, it returns the error.
+// The name may contain "{hostname}", which is replaced with the hostname, such as "{hostname}.ids.example.com".
+// Its errors are ProviderErrors naming the DNS name.
+func DNSMachineID(name string, resolver *net.Resolver) func() (uint16, error) {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	return func() (uint16, error) {
+		host := name
+		if strings.Contains(host, "{hostname}") {
+			hostname, err := os.Hostname()
+			if err != nil {
+				return 0, &ProviderError{Provider: "dns", Param: name, Err: err}
+			}
+			host = strings.Replace(host, "{hostname}", hostname, -1)
+		}
+
+		ctx := context.Background()
+		records, err := resolver.LookupTXT(ctx, host)
+		if err != nil && !isNotFound(err) {
+			return 0, &ProviderError{Provider: "dns", Param: host, Err: err}
+		}
+		id, ok, err := txtMachineID(records)
+		if err != nil {
+			return 0, &ProviderError{Provider: "dns", Param: host, Err: err}
+		}
+		if ok {
+			return id, nil
+		}
+
+		addrs, err := resolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return 0, &ProviderError{Provider: "dns", Param: host, Err: err}
+		}
+		for _, a := range addrs {
+			if ip := a.IP.To4(); ip != nil {
+				return uint16(ip[2])<<8 + uint16(ip[3]), nil
+			}
+		}
+		return 0, &ProviderError{Provider: "dns", Param: host, Err: ErrNoDNSMachineID}
+	}
+}
+
+// isNotFound reports whether err is a DNS error telling that the name has no records of the type,
+// as opposed to a failure of the lookup such as a timeout, which must not be mistaken for the absence of TXT records.
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+// txtMachineID returns the machine ID assigned by the given TXT records, ignoring unrelated records.
+// txtMachineID returns an error if the records assign different machine IDs.
+func txtMachineID(records []string) (uint16, bool, error) {
+	var (
+		id    uint16
+		found bool
+	)
+	for _, r := range records {
+		r = strings.TrimPrefix(strings.TrimSpace(r), "machine-id=")
+		n, err := strconv.ParseUint(r, 10, 16)
+		if err != nil {
+			continue
+		}
+		if found && uint16(n) != id {
+			return 0, false, errors.New("conflicting machine ids in txt records")
+		}
+		id, found = uint16(n), true
+	}
+	return id, found, nil
+}
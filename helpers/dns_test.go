@@ -0,0 +1,49 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+)
+
+func TestTXTMachineID(t *testing.T) {
+	tests := []struct {
+		records []string
+		id      uint16
+		found   bool
+		err     bool
+	}{
+		{[]string{"42"}, 42, true, false},
+		{[]string{"v=spf1 -all", "machine-id=7"}, 7, true, false},
+		{[]string{"machine-id=7", "7"}, 7, true, false},
+		{[]string{"machine-id=7", "8"}, 0, false, true},
+		{[]string{"machine-id=70000"}, 0, false, false},
+		{nil, 0, false, false},
+	}
+
+	for _, tc := range tests {
+		id, found, err := txtMachineID(tc.records)
+		if id != tc.id || found != tc.found || (err != nil) != tc.err {
+			t.Errorf("unexpected machine id of %q: %d, %v, %v", tc.records, id, found, err)
+		}
+	}
+}
+
+func TestIsNotFound(t *testing.T) {
+	tests := []struct {
+		err      error
+		notFound bool
+	}{
+		{&net.DNSError{Err: "no such host", IsNotFound: true}, true},
+		{fmt.Errorf("lookup: %w", &net.DNSError{Err: "no such host", IsNotFound: true}), true},
+		{&net.DNSError{Err: "i/o timeout", IsTimeout: true}, false},
+		{errors.New("connection refused"), false},
+	}
+
+	for _, tc := range tests {
+		if notFound := isNotFound(tc.err); notFound != tc.notFound {
+			t.Errorf("unexpected result of %v: %v", tc.err, notFound)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Package helpers provides ready-made functions for configuring Sonyflake.
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrNoProvider is returned by ChainMachineID when no provider is given.
+var ErrNoProvider = errors.New("no machine id provider")
+
+// ErrEnvNotSet is returned by EnvMachineID when the environment variable is not set.
+var ErrEnvNotSet = errors.New("environment variable not set")
+
+// ProviderError is the error returned by a machine ID provider,
+// identifying which provider failed and what it attempted.
+type ProviderError struct {
+	Provider string // the name of the provider, such as "env"
+	Param    string // the parameter attempted, such as an environment variable, an endpoint or an interface
+	Err      error
+}
+
+func (e *ProviderError) Error() string {
+	if e.Param == "" {
+		return fmt.Sprintf("%s: %s", e.Provider, e.Err)
+	}
+	return fmt.Sprintf("%s(%s): %s", e.Provider, e.Param, e.Err)
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// ChainError is the error returned when every machine ID provider of a chain fails.
+// It holds the errors of the providers in the order they were tried.
+type ChainError []error
+
+func (e ChainError) Error() string {
+	msgs := make([]string, 0, len(e))
+	for i, err := range e {
+		msgs = append(msgs, fmt.Sprintf("[%d] %s", i+1, err))
+	}
+	return "all machine id providers failed: " + strings.Join(msgs, "; ")
+}
+
+// Is reports whether any error of the chain matches target.
+func (e ChainError) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ChainMachineID returns a function usable as Settings.MachineID
+// that tries the given providers in order and returns the first machine ID successfully provided.
+// If every provider fails, the function returns a ChainError listing all failures.
+func ChainMachineID(providers ...func() (uint16, error)) func() (uint16, error) {
+	return func() (uint16, error) {
+		if len(providers) == 0 {
+			return 0, ErrNoProvider
+		}
+
+		var errs ChainError
+		for _, provider := range providers {
+			id, err := provider()
+			if err == nil {
+				return id, nil
+			}
+			errs = append(errs, err)
+		}
+		return 0, errs
+	}
+}
+
+// NamedMachineID returns a function usable as Settings.MachineID
+// that calls the given provider and wraps its error in a ProviderError with the given name,
+// unless the error is already a ProviderError.
+func NamedMachineID(name string, provider func() (uint16, error)) func() (uint16, error) {
+	return func() (uint16, error) {
+		id, err := provider()
+		if err == nil {
+			return id, nil
+		}
+
+		var pe *ProviderError
+		if errors.As(err, &pe) {
+			return 0, err
+		}
+		return 0, &ProviderError{Provider: name, Err: err}
+	}
+}
+
+// EnvMachineID returns a function usable as Settings.MachineID
+// that reads the machine ID in decimal from the given environment variable.
+// Its errors are ProviderErrors naming the environment variable.
+func EnvMachineID(key string) func() (uint16, error) {
+	return func() (uint16, error) {
+		s, ok := os.LookupEnv(key)
+		if !ok || s == "" {
+			return 0, &ProviderError{Provider: "env", Param: key, Err: ErrEnvNotSet}
+		}
+
+		id, err := strconv.ParseUint(s, 10, 16)
+		if err != nil {
+			return 0, &ProviderError{Provider: "env", Param: key, Err: err}
+		}
+		return uint16(id), nil
+	}
+}
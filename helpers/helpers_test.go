@@ -0,0 +1,114 @@
+package helpers
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestChainMachineID(t *testing.T) {
+	errFirst := errors.New("first error")
+	errSecond := errors.New("second error")
+
+	failing := func(err error) func() (uint16, error) {
+		return func() (uint16, error) {
+			return 0, err
+		}
+	}
+	succeeding := func(id uint16) func() (uint16, error) {
+		return func() (uint16, error) {
+			return id, nil
+		}
+	}
+
+	tests := []struct {
+		name      string
+		providers []func() (uint16, error)
+		id        uint16
+		errs      []error
+	}{
+		{
+			name:      "failure: no provider",
+			providers: nil,
+			errs:      []error{ErrNoProvider},
+		},
+		{
+			name:      "failure: all providers",
+			providers: []func() (uint16, error){failing(errFirst), failing(errSecond)},
+			errs:      []error{errFirst, errSecond},
+		},
+		{
+			name:      "success: first provider",
+			providers: []func() (uint16, error){succeeding(1), succeeding(2)},
+			id:        1,
+		},
+		{
+			name:      "success: fallback provider",
+			providers: []func() (uint16, error){failing(errFirst), succeeding(2)},
+			id:        2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			id, err := ChainMachineID(test.providers...)()
+
+			if len(test.errs) == 0 && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			for _, want := range test.errs {
+				if !errors.Is(err, want) {
+					t.Errorf("unexpected value, want %#v, got %#v", want, err)
+				}
+			}
+
+			if id != test.id {
+				t.Errorf("unexpected machine id: %d", id)
+			}
+		})
+	}
+}
+
+func TestEnvMachineID(t *testing.T) {
+	const key = "SONYFLAKE_TEST_MACHINE_ID"
+	defer os.Unsetenv(key)
+
+	os.Unsetenv(key)
+	if _, err := EnvMachineID(key)(); !errors.Is(err, ErrEnvNotSet) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	os.Setenv(key, "65536")
+	if _, err := EnvMachineID(key)(); err == nil {
+		t.Errorf("expected an error for an out of range machine id")
+	}
+
+	os.Setenv(key, "1234")
+	id, err := EnvMachineID(key)()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if id != 1234 {
+		t.Errorf("unexpected machine id: %d", id)
+	}
+}
+
+func TestNamedMachineID(t *testing.T) {
+	errProvider := errors.New("provider error")
+
+	_, err := NamedMachineID("test", func() (uint16, error) { return 0, errProvider })()
+	var pe *ProviderError
+	if !errors.As(err, &pe) || pe.Provider != "test" || !errors.Is(err, errProvider) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	_, err = NamedMachineID("test", EnvMachineID("SONYFLAKE_TEST_UNSET"))()
+	if !errors.As(err, &pe) || pe.Provider != "env" || pe.Param != "SONYFLAKE_TEST_UNSET" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	id, err := NamedMachineID("test", func() (uint16, error) { return 1, nil })()
+	if err != nil || id != 1 {
+		t.Errorf("unexpected result: %d, %v", id, err)
+	}
+}
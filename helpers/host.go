@@ -0,0 +1,66 @@
+package helpers
+
+import (
+	"errors"
+	"hash/fnv"
+	"regexp"
+	"strings"
+)
+
+// ErrNoHostUUID is returned by HostUUID when the platform provides no host UUID or it is not found.
+var ErrNoHostUUID = errors.New("no host uuid")
+
+// HostMachineID returns a function usable as Settings.MachineID
+// that derives the machine ID of bitsMachineID bits from the host UUID returned by HostUUID,
+// which is the registry value MachineGuid on Windows and IOPlatformUUID on macOS.
+// If bitsMachineID is 0, it is 16.
+//
+// The machine ID is stable across reboots and network changes, unlike one derived from an IP address,
+// which suits developer machines and Windows server fleets.
+// Since the machine ID is a hash of the UUID, the machine IDs of n hosts collide
+// with a probability of about n*n/2^(bitsMachineID+1), so CheckMachineID is recommended for large fleets.
+// Its errors are ProviderErrors naming the source of the UUID.
+func HostMachineID(bitsMachineID int) func() (uint16, error) {
+	if bitsMachineID == 0 {
+		bitsMachineID = 16
+	}
+	return func() (uint16, error) {
+		uuid, err := HostUUID()
+		if err != nil {
+			return 0, &ProviderError{Provider: "host", Param: hostUUIDSource, Err: err}
+		}
+		return hashMachineID(uuid, bitsMachineID), nil
+	}
+}
+
+// hashMachineID returns the lower bitsMachineID bits of the FNV-1a hash of the given UUID,
+// which is normalized so that the case and braces do not matter.
+func hashMachineID(uuid string, bitsMachineID int) uint16 {
+	uuid = strings.ToLower(strings.Trim(strings.TrimSpace(uuid), "{}"))
+	h := fnv.New32a()
+	h.Write([]byte(uuid))
+	return uint16(h.Sum32() & (1<<uint(bitsMachineID) - 1))
+}
+
+var (
+	machineGUIDPattern    = regexp.MustCompile(`MachineGuid\s+REG_SZ\s+(\S+)`)
+	ioPlatformUUIDPattern = regexp.MustCompile(`"IOPlatformUUID"\s*=\s*"([^"]+)"`)
+)
+
+// parseMachineGUID extracts MachineGuid from the output of reg query.
+func parseMachineGUID(output string) (string, error) {
+	m := machineGUIDPattern.FindStringSubmatch(output)
+	if m == nil {
+		return "", ErrNoHostUUID
+	}
+	return m[1], nil
+}
+
+// parseIOPlatformUUID extracts IOPlatformUUID from the output of ioreg.
+func parseIOPlatformUUID(output string) (string, error) {
+	m := ioPlatformUUIDPattern.FindStringSubmatch(output)
+	if m == nil {
+		return "", ErrNoHostUUID
+	}
+	return m[1], nil
+}
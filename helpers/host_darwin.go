@@ -0,0 +1,14 @@
+package helpers
+
+import "os/exec"
+
+const hostUUIDSource = "IOPlatformUUID"
+
+// HostUUID returns the UUID of the host, which is IOPlatformUUID of the platform expert device on macOS.
+func HostUUID() (string, error) {
+	output, err := exec.Command("ioreg", "-rd1", "-c", "IOPlatformExpertDevice").Output()
+	if err != nil {
+		return "", err
+	}
+	return parseIOPlatformUUID(string(output))
+}
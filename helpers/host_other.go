@@ -0,0 +1,12 @@
+//go:build !windows && !darwin
+// +build !windows,!darwin
+
+package helpers
+
+const hostUUIDSource = "host uuid"
+
+// HostUUID returns the UUID of the host, which is MachineGuid on Windows and IOPlatformUUID on macOS.
+// On the other platforms, HostUUID returns ErrNoHostUUID.
+func HostUUID() (string, error) {
+	return "", ErrNoHostUUID
+}
@@ -0,0 +1,67 @@
+package helpers
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseMachineGUID(t *testing.T) {
+	output := "\r\nHKEY_LOCAL_MACHINE\\SOFTWARE\\Microsoft\\Cryptography\r\n" +
+		"    MachineGuid    REG_SZ    8d3f4c2a-1b2c-4d5e-9f00-112233445566\r\n\r\n"
+	guid, err := parseMachineGUID(output)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if guid != "8d3f4c2a-1b2c-4d5e-9f00-112233445566" {
+		t.Errorf("unexpected guid: %s", guid)
+	}
+
+	if _, err := parseMachineGUID("ERROR: The system was unable to find the specified registry key or value."); err != ErrNoHostUUID {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestParseIOPlatformUUID(t *testing.T) {
+	output := `+-o MacBookPro18,3  <class IOPlatformExpertDevice, id 0x100000219, registered, matched, active, busy 0 (0 ms), retain 37>
+    {
+      "IOPlatformSerialNumber" = "C02XXXXXXXXX"
+      "IOPlatformUUID" = "5E1D4A3B-7C2F-5A9E-8B1D-0F2E3D4C5B6A"
+      "model" = <"MacBookPro18,3">
+    }
+`
+	uuid, err := parseIOPlatformUUID(output)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if uuid != "5E1D4A3B-7C2F-5A9E-8B1D-0F2E3D4C5B6A" {
+		t.Errorf("unexpected uuid: %s", uuid)
+	}
+
+	if _, err := parseIOPlatformUUID(""); err != ErrNoHostUUID {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestHashMachineID(t *testing.T) {
+	id := hashMachineID("5E1D4A3B-7C2F-5A9E-8B1D-0F2E3D4C5B6A", 16)
+	if normalized := hashMachineID(" {5e1d4a3b-7c2f-5a9e-8b1d-0f2e3d4c5b6a}\n", 16); normalized != id {
+		t.Errorf("case and braces must not matter: %d != %d", normalized, id)
+	}
+	if other := hashMachineID("8d3f4c2a-1b2c-4d5e-9f00-112233445566", 16); other == id {
+		t.Errorf("different uuids must differ: %d", other)
+	}
+	if narrow := hashMachineID("5E1D4A3B-7C2F-5A9E-8B1D-0F2E3D4C5B6A", 10); narrow >= 1<<10 || narrow != id&(1<<10-1) {
+		t.Errorf("unexpected machine id of 10 bits: %d", narrow)
+	}
+}
+
+func TestHostMachineID(t *testing.T) {
+	_, err := HostUUID()
+	if err != nil {
+		_, err := HostMachineID(0)()
+		var pe *ProviderError
+		if !errors.As(err, &pe) || pe.Provider != "host" {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package helpers
+
+import "os/exec"
+
+const hostUUIDSource = `HKLM\SOFTWARE\Microsoft\Cryptography\MachineGuid`
+
+// HostUUID returns the UUID of the host, which is the registry value MachineGuid on Windows.
+func HostUUID() (string, error) {
+	output, err := exec.Command("reg", "query", `HKLM\SOFTWARE\Microsoft\Cryptography`, "/v", "MachineGuid").Output()
+	if err != nil {
+		return "", err
+	}
+	return parseMachineGUID(string(output))
+}
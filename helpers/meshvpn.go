@@ -0,0 +1,85 @@
+package helpers
+
+import (
+	"errors"
+	"net"
+	"strings"
+)
+
+// ErrNoOverlayAddress is returned by MeshVPNMachineID when no overlay IP address of a mesh VPN is found.
+var ErrNoOverlayAddress = errors.New("no overlay ip address")
+
+// tailscaleNet is the CGNAT range 100.64.0.0/10, from which Tailscale assigns addresses.
+var tailscaleNet = &net.IPNet{IP: net.IPv4(100, 64, 0, 0).To4(), Mask: net.CIDRMask(10, 32)}
+
+// MeshVPNMachineID returns a function usable as Settings.MachineID
+// that returns the lower 16 bits of the overlay IPv4 address of a mesh VPN,
+// since mesh-VPN fleets often have stable overlay addresses but unstable LAN addresses.
+// It prefers an address in 100.64.0.0/10, which Tailscale assigns, on any interface,
+// and then an address of the given WireGuard interfaces, or of those named with the prefix "wg" if none is given.
+// Its errors are ProviderErrors, which wrap ErrNoOverlayAddress if no such address is found.
+func MeshVPNMachineID(wireGuardInterfaces ...string) func() (uint16, error) {
+	return func() (uint16, error) {
+		ifs, err := net.Interfaces()
+		if err != nil {
+			return 0, &ProviderError{Provider: "meshvpn", Err: err}
+		}
+
+		var addrs []interfaceAddrs
+		for _, ifi := range ifs {
+			as, err := ifi.Addrs()
+			if err != nil {
+				continue
+			}
+			addrs = append(addrs, interfaceAddrs{name: ifi.Name, addrs: as})
+		}
+
+		ip := overlayIP(addrs, wireGuardInterfaces)
+		if ip == nil {
+			return 0, &ProviderError{Provider: "meshvpn", Param: strings.Join(wireGuardInterfaces, ","), Err: ErrNoOverlayAddress}
+		}
+		return uint16(ip[2])<<8 + uint16(ip[3]), nil
+	}
+}
+
+type interfaceAddrs struct {
+	name  string
+	addrs []net.Addr
+}
+
+// overlayIP returns the first IPv4 address in tailscaleNet,
+// or else the first IPv4 address of the given WireGuard interfaces, or of those named with the prefix "wg" if none is given.
+func overlayIP(ifs []interfaceAddrs, wireGuardInterfaces []string) net.IP {
+	isWireGuard := func(name string) bool {
+		if len(wireGuardInterfaces) == 0 {
+			return strings.HasPrefix(name, "wg")
+		}
+		for _, wg := range wireGuardInterfaces {
+			if name == wg {
+				return true
+			}
+		}
+		return false
+	}
+
+	var wireGuardIP net.IP
+	for _, ifi := range ifs {
+		for _, a := range ifi.addrs {
+			ipnet, ok := a.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			ip := ipnet.IP.To4()
+			if ip == nil {
+				continue
+			}
+			if tailscaleNet.Contains(ip) {
+				return ip
+			}
+			if wireGuardIP == nil && isWireGuard(ifi.name) {
+				wireGuardIP = ip
+			}
+		}
+	}
+	return wireGuardIP
+}
@@ -0,0 +1,41 @@
+package helpers
+
+import (
+	"net"
+	"testing"
+)
+
+func ipNet(s string) net.Addr {
+	ip, ipnet, _ := net.ParseCIDR(s)
+	ipnet.IP = ip
+	return ipnet
+}
+
+func TestOverlayIP(t *testing.T) {
+	eth := interfaceAddrs{name: "eth0", addrs: []net.Addr{ipNet("192.168.1.10/24")}}
+	wg := interfaceAddrs{name: "wg0", addrs: []net.Addr{ipNet("fd00::1/64"), ipNet("10.8.0.3/24")}}
+	ts := interfaceAddrs{name: "tailscale0", addrs: []net.Addr{ipNet("100.101.102.103/32")}}
+	cgnatEdge := interfaceAddrs{name: "eth1", addrs: []net.Addr{ipNet("100.128.0.1/24")}}
+
+	tests := []struct {
+		name      string
+		ifs       []interfaceAddrs
+		wireGuard []string
+		expected  net.IP
+	}{
+		{"tailscale preferred", []interfaceAddrs{eth, wg, ts}, nil, net.IPv4(100, 101, 102, 103)},
+		{"wireguard by prefix", []interfaceAddrs{eth, wg}, nil, net.IPv4(10, 8, 0, 3)},
+		{"wireguard by name", []interfaceAddrs{eth, wg}, []string{"eth0"}, net.IPv4(192, 168, 1, 10)},
+		{"outside of cgnat", []interfaceAddrs{cgnatEdge}, nil, nil},
+		{"none", []interfaceAddrs{eth}, nil, nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ip := overlayIP(tc.ifs, tc.wireGuard)
+			if !ip.Equal(tc.expected) {
+				t.Errorf("unexpected ip: %v", ip)
+			}
+		})
+	}
+}
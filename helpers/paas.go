@@ -0,0 +1,104 @@
+package helpers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrNoDyno is returned by PaaSMachineID when no dyno or instance metadata of a known platform is found.
+var ErrNoDyno = errors.New("no dyno metadata")
+
+// ErrDynoIndexOverflow is returned by PaaSMachineID if the index of the dyno does not fit in its bits.
+var ErrDynoIndexOverflow = errors.New("dyno index overflows its bits")
+
+// PaaSMachineID returns a function usable as Settings.MachineID
+// that derives the machine ID from the dyno metadata in the environment variables of a PaaS,
+// since such platforms expose neither private IPv4 addresses nor stable network interfaces.
+// The machine ID of bitsMachineID bits has the index of the dyno in the lower bitsIndex bits
+// and a hash of its process type and release in the remaining upper bits.
+// If bitsMachineID is 0, it is 16, and if bitsIndex is 0, it is 8.
+//
+// It recognizes the following platforms in order:
+// - Heroku and Heroku-compatible platforms such as Dokku, by DYNO (e.g. "web.1") and HEROKU_RELEASE_VERSION.
+// - Cloud Foundry, by CF_INSTANCE_INDEX and the application name in VCAP_APPLICATION.
+//
+// Hashing the release separates the old and new dynos of the same name that overlap during preboot.
+// Its errors are ProviderErrors, which wrap ErrNoDyno if no platform is recognized
+// and ErrDynoIndexOverflow if the index does not fit in bitsIndex bits, as for one-off dynos such as "run.4242".
+func PaaSMachineID(bitsMachineID, bitsIndex int) func() (uint16, error) {
+	if bitsMachineID == 0 {
+		bitsMachineID = 16
+	}
+	if bitsIndex == 0 {
+		bitsIndex = 8
+	}
+	return func() (uint16, error) {
+		return paasMachineID(os.Getenv, bitsMachineID, bitsIndex)
+	}
+}
+
+// paasMachineID composes the machine ID from the dyno metadata returned by getenv.
+func paasMachineID(getenv func(string) string, bitsMachineID, bitsIndex int) (uint16, error) {
+	if bitsMachineID < 0 || bitsMachineID > 16 {
+		return 0, &ProviderError{Provider: "paas", Err: fmt.Errorf("invalid bits of machine id: %d", bitsMachineID)}
+	}
+	if bitsIndex < 0 || bitsIndex > bitsMachineID {
+		return 0, &ProviderError{Provider: "paas", Err: fmt.Errorf("invalid bits of dyno index: %d", bitsIndex)}
+	}
+
+	param, group, index, err := dynoFromEnv(getenv)
+	if err != nil {
+		return 0, &ProviderError{Provider: "paas", Param: param, Err: err}
+	}
+	if index >= 1<<uint(bitsIndex) {
+		return 0, &ProviderError{Provider: "paas", Param: param, Err: ErrDynoIndexOverflow}
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(group))
+	hash := h.Sum32() & (1<<uint(bitsMachineID-bitsIndex) - 1)
+	return uint16(hash<<uint(bitsIndex) | uint32(index)), nil
+}
+
+// dynoFromEnv returns the environment variable naming the dyno,
+// the group of dynos sharing its process type and release, and its index within the group.
+func dynoFromEnv(getenv func(string) string) (param, group string, index uint64, err error) {
+	if dyno := getenv("DYNO"); dyno != "" {
+		i := strings.LastIndexByte(dyno, '.')
+		if i < 0 {
+			return "DYNO", "", 0, fmt.Errorf("invalid dyno name %q", dyno)
+		}
+		index, err = strconv.ParseUint(dyno[i+1:], 10, 16)
+		if err != nil {
+			return "DYNO", "", 0, fmt.Errorf("invalid dyno name %q", dyno)
+		}
+		return "DYNO", dyno[:i] + "@" + getenv("HEROKU_RELEASE_VERSION"), index, nil
+	}
+
+	if s := getenv("CF_INSTANCE_INDEX"); s != "" {
+		index, err = strconv.ParseUint(s, 10, 16)
+		if err != nil {
+			return "CF_INSTANCE_INDEX", "", 0, err
+		}
+		return "CF_INSTANCE_INDEX", cloudFoundryApplicationName(getenv("VCAP_APPLICATION")), index, nil
+	}
+
+	return "DYNO", "", 0, ErrNoDyno
+}
+
+// cloudFoundryApplicationName returns the application name in the given VCAP_APPLICATION,
+// or an empty string if it is not found.
+func cloudFoundryApplicationName(vcap string) string {
+	var app struct {
+		ApplicationName string `json:"application_name"`
+	}
+	if err := json.Unmarshal([]byte(vcap), &app); err != nil {
+		return ""
+	}
+	return app.ApplicationName
+}
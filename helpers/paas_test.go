@@ -0,0 +1,86 @@
+package helpers
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPaaSMachineID(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string {
+			return vars[key]
+		}
+	}
+
+	web1, err := paasMachineID(env(map[string]string{"DYNO": "web.1", "HEROKU_RELEASE_VERSION": "v42"}), 16, 8)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if web1&0xff != 1 {
+		t.Errorf("unexpected dyno index: %d", web1&0xff)
+	}
+
+	web2, err := paasMachineID(env(map[string]string{"DYNO": "web.2", "HEROKU_RELEASE_VERSION": "v42"}), 16, 8)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if web2 != web1+1 {
+		t.Errorf("dynos of the same group must differ only in the index: %d, %d", web1, web2)
+	}
+
+	next, err := paasMachineID(env(map[string]string{"DYNO": "web.1", "HEROKU_RELEASE_VERSION": "v43"}), 16, 8)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if next == web1 {
+		t.Errorf("dynos of different releases must differ: %d", next)
+	}
+
+	cf, err := paasMachineID(env(map[string]string{
+		"CF_INSTANCE_INDEX": "3",
+		"VCAP_APPLICATION":  `{"application_name": "api", "instance_index": 3}`,
+	}), 16, 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if cf&0xf != 3 {
+		t.Errorf("unexpected instance index: %d", cf&0xf)
+	}
+
+	narrow, err := paasMachineID(env(map[string]string{"DYNO": "web.1", "HEROKU_RELEASE_VERSION": "v42"}), 10, 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if narrow >= 1<<10 || narrow&0xf != 1 {
+		t.Errorf("unexpected machine id of 10 bits: %d", narrow)
+	}
+
+	_, err = paasMachineID(env(map[string]string{"DYNO": "web.1"}), 4, 8)
+	if err == nil {
+		t.Errorf("index wider than the machine id must be rejected")
+	}
+
+	_, err = paasMachineID(env(map[string]string{"DYNO": "run.4242"}), 16, 8)
+	if !errors.Is(err, ErrDynoIndexOverflow) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	_, err = paasMachineID(env(map[string]string{"DYNO": "web"}), 16, 8)
+	if err == nil {
+		t.Errorf("invalid dyno name must be rejected")
+	}
+
+	_, err = paasMachineID(env(nil), 16, 8)
+	if !errors.Is(err, ErrNoDyno) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCloudFoundryApplicationName(t *testing.T) {
+	if name := cloudFoundryApplicationName(`{"application_id":"1b2c","application_name":"api"}`); name != "api" {
+		t.Errorf("unexpected application name: %s", name)
+	}
+	if name := cloudFoundryApplicationName("not json"); name != "" {
+		t.Errorf("unexpected application name: %s", name)
+	}
+}
@@ -0,0 +1,96 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// Registry is a client of a central registry service enforcing the uniqueness of machine IDs.
+// Its method CheckMachineID is usable as Settings.CheckMachineID.
+//
+// URL is the endpoint to which a candidate machine ID is POSTed as {"machine_id": <id>, "owner": <owner>} in JSON.
+// The registry must respond 2xx if the machine ID is granted and 409 Conflict if it is taken.
+//
+// Owner identifies the instance claiming machine IDs.
+// The registry must grant a machine ID again to its owner, so that CheckMachineID is usable for Settings.RecheckInterval
+// and Settings.RecheckEvery as well as at startup.
+// If Owner is empty, the owner is omitted and a second claim of the same machine ID is taken.
+//
+// Client is the HTTP client used for requests.
+// If Client is nil, http.DefaultClient is used.
+//
+// Retries is the number of retries after a network error, 429, or 5xx response.
+// If Retries is 0, requests are retried 3 times. If Retries is negative, requests are not retried.
+//
+// Backoff is the wait before the first retry, doubled for each subsequent retry.
+// If Backoff is 0, it is 100 msec.
+type Registry struct {
+	URL     string
+	Owner   string
+	Client  *http.Client
+	Retries int
+	Backoff time.Duration
+}
+
+const (
+	defaultRegistryRetries = 3
+	defaultRegistryBackoff = 100 * time.Millisecond
+)
+
+// CheckMachineID registers the given machine ID with the registry.
+// CheckMachineID returns false if the machine ID is taken or the registry cannot be reached.
+func (r Registry) CheckMachineID(id uint16) bool {
+	retries := r.Retries
+	if retries == 0 {
+		retries = defaultRegistryRetries
+	}
+	backoff := r.Backoff
+	if backoff == 0 {
+		backoff = defaultRegistryBackoff
+	}
+
+	for attempt := 0; ; attempt++ {
+		granted, retryable := r.register(id)
+		if !retryable || attempt >= retries {
+			return granted
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (r Registry) register(id uint16) (granted, retryable bool) {
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	body, err := json.Marshal(struct {
+		MachineID uint16 `json:"machine_id"`
+		Owner     string `json:"owner,omitempty"`
+	}{id, r.Owner})
+	if err != nil {
+		return false, false
+	}
+
+	res, err := client.Post(r.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return false, true
+	}
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, res.Body)
+
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode < 300:
+		return true, false
+	case res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500:
+		return false, true
+	default: // including 409 Conflict
+		return false, false
+	}
+}
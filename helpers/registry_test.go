@@ -0,0 +1,74 @@
+package helpers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestRegistryCheckMachineID(t *testing.T) {
+	var mutex sync.Mutex
+	owners := map[uint16]string{1: "other"}
+	failures := map[uint16]int{3: 2, 4: 10}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			MachineID uint16 `json:"machine_id"`
+			Owner     string `json:"owner"`
+		}
+		if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&body) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		if failures[body.MachineID] > 0 {
+			failures[body.MachineID]--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if owner, ok := owners[body.MachineID]; ok {
+			if owner == "" || owner != body.Owner {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		owners[body.MachineID] = body.Owner
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	anonymous := Registry{URL: server.URL, Backoff: 1}
+	owned := Registry{URL: server.URL, Owner: "instance-1", Backoff: 1}
+
+	tests := []struct {
+		name      string
+		registry  Registry
+		machineID uint16
+		granted   bool
+	}{
+		{"failure: taken", anonymous, 1, false},
+		{"failure: taken by another owner", owned, 1, false},
+		{"success: granted", anonymous, 2, true},
+		{"failure: granted twice", anonymous, 2, false},
+		{"failure: granted to no owner", owned, 2, false},
+		{"success: granted to an owner", owned, 5, true},
+		{"success: granted twice to the owner", owned, 5, true},
+		{"failure: granted to another owner", anonymous, 5, false},
+		{"success: retried", anonymous, 3, true},
+		{"failure: retries exhausted", anonymous, 4, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if granted := test.registry.CheckMachineID(test.machineID); granted != test.granted {
+				t.Errorf("unexpected value, want %t, got %t", test.granted, granted)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package sonyflake
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+)
+
+// ID is a Sonyflake ID, which is formatted and scanned by fmt in the default encoding.
+type ID uint64
+
+var (
+	defaultEncodingMutex sync.RWMutex
+	defaultEncoding      *Encoding
+)
+
+// SetDefaultEncoding sets the encoding in which IDs are formatted and scanned.
+// If the encoding is nil, which is the default, IDs are formatted in decimal and scanned by Parse.
+func SetDefaultEncoding(e *Encoding) {
+	defaultEncodingMutex.Lock()
+	defer defaultEncodingMutex.Unlock()
+
+	defaultEncoding = e
+}
+
+// DefaultEncoding returns the encoding set by SetDefaultEncoding.
+func DefaultEncoding() *Encoding {
+	defaultEncodingMutex.RLock()
+	defer defaultEncodingMutex.RUnlock()
+
+	return defaultEncoding
+}
+
+// String returns the ID in the default encoding.
+func (id ID) String() string {
+	if e := DefaultEncoding(); e != nil {
+		return e.Encode(uint64(id))
+	}
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// Scan implements fmt.Scanner.
+// Scan decodes a token in the default encoding, or by Parse if no default encoding is set.
+func (id *ID) Scan(state fmt.ScanState, verb rune) error {
+	token, err := state.Token(true, nil)
+	if err != nil {
+		return err
+	}
+	return id.decode(string(token))
+}
+
+func (id *ID) decode(s string) error {
+	var v uint64
+	var err error
+	if e := DefaultEncoding(); e != nil {
+		v, err = e.Decode(s)
+	} else {
+		v, err = Parse(s)
+	}
+	if err != nil {
+		return err
+	}
+
+	*id = ID(v)
+	return nil
+}
+
+// MarshalGQL writes the ID as a GraphQL string scalar in the default encoding,
+// since GraphQL Int cannot represent IDs of more than 32 bits.
+// It is compatible with gqlgen.
+func (id ID) MarshalGQL(w io.Writer) {
+	io.WriteString(w, strconv.Quote(id.String()))
+}
+
+// UnmarshalGQL reads the ID from a GraphQL input value.
+// It accepts a string in the default encoding, or an integer for compatibility with clients sending Int.
+// It is compatible with gqlgen.
+func (id *ID) UnmarshalGQL(v interface{}) error {
+	switch v := v.(type) {
+	case string:
+		return id.decode(v)
+	case json.Number:
+		n, err := strconv.ParseUint(string(v), 10, 64)
+		if err != nil {
+			return ErrInvalidID
+		}
+		*id = ID(n)
+	case int:
+		if v < 0 {
+			return ErrInvalidID
+		}
+		*id = ID(v)
+	case int64:
+		if v < 0 {
+			return ErrInvalidID
+		}
+		*id = ID(v)
+	default:
+		return fmt.Errorf("%w: %T is not a string", ErrInvalidID, v)
+	}
+	return nil
+}
+
+// GobEncode encodes the ID in 8 bytes in big endian.
+func (id ID) GobEncode() ([]byte, error) {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(id))
+	return b, nil
+}
+
+// GobDecode decodes the ID encoded by GobEncode.
+func (id *ID) GobDecode(b []byte) error {
+	if len(b) != 8 {
+		return ErrInvalidID
+	}
+	*id = ID(binary.BigEndian.Uint64(b))
+	return nil
+}
@@ -0,0 +1,107 @@
+package sonyflake
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestIDFormatAndScan(t *testing.T) {
+	defer SetDefaultEncoding(nil)
+
+	id := ID(1 << 40)
+	for _, e := range []*Encoding{nil, Base32, Base58, Base62} {
+		SetDefaultEncoding(e)
+
+		s := fmt.Sprintf("%v", id)
+		if e == nil && s != "1099511627776" {
+			t.Errorf("unexpected string: %s", s)
+		}
+		if e != nil && s != e.Encode(uint64(id)) {
+			t.Errorf("unexpected string: %s", s)
+		}
+
+		var scanned ID
+		if _, err := fmt.Sscan(s, &scanned); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if scanned != id {
+			t.Errorf("unexpected id: %d", scanned)
+		}
+	}
+
+	SetDefaultEncoding(Base62)
+	if s := fmt.Sprintf("%d", id); s != "1099511627776" {
+		t.Errorf("unexpected decimal: %s", s)
+	}
+}
+
+func TestIDGQL(t *testing.T) {
+	defer SetDefaultEncoding(nil)
+	SetDefaultEncoding(Base62)
+
+	id := ID(1 << 40)
+
+	var b strings.Builder
+	id.MarshalGQL(&b)
+	if want := `"` + Base62.Encode(1<<40) + `"`; b.String() != want {
+		t.Errorf("unexpected scalar: %s", b.String())
+	}
+
+	inputs := []interface{}{Base62.Encode(1 << 40), json.Number("1099511627776"), int64(1 << 40)}
+	for _, input := range inputs {
+		var got ID
+		if err := got.UnmarshalGQL(input); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if got != id {
+			t.Errorf("unexpected id: %d", got)
+		}
+	}
+
+	var got ID
+	if err := got.UnmarshalGQL(true); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := got.UnmarshalGQL(-1); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestIDGob(t *testing.T) {
+	type message struct {
+		ID    ID
+		Parts map[string]uint64
+	}
+
+	sf, err := New(Settings{MachineID: func() (uint16, error) { return 1, nil }})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	id, err := sf.NextID()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	in := message{ID: ID(id), Parts: sf.Decompose(id)}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(in); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var out message
+	if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if out.ID != in.ID || out.Parts["machine-id"] != 1 || out.Parts["time"] != in.Parts["time"] {
+		t.Errorf("unexpected message: %+v", out)
+	}
+
+	if err := out.ID.GobDecode([]byte{1}); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
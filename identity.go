@@ -0,0 +1,113 @@
+package sonyflake
+
+import (
+	"net"
+	"strconv"
+	"time"
+)
+
+// IdentitySource is where the machine ID of a Sonyflake came from.
+type IdentitySource int
+
+const (
+	// SourceIPAddress is the IP address of a network interface, from which default MachineID derives the machine ID.
+	SourceIPAddress IdentitySource = iota
+	// SourceSettings is Settings.MachineID.
+	SourceSettings
+	// SourceSetMachineID is SetMachineID.
+	SourceSetMachineID
+	// SourceFallback is Settings.FallbackMachineID, called since the primary machine ID provider failed.
+	SourceFallback
+)
+
+var identitySourceNames = map[IdentitySource]string{
+	SourceIPAddress:    "ip-address",
+	SourceSettings:     "settings",
+	SourceSetMachineID: "set-machine-id",
+	SourceFallback:     "fallback",
+}
+
+// String returns the name of the IdentitySource, such as "ip-address".
+func (s IdentitySource) String() string {
+	if name, ok := identitySourceNames[s]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// Identity is the resolved identity of the machine from which the machine ID of a Sonyflake was derived.
+//
+// Source is where the machine ID came from.
+//
+// Value is the raw value from which the machine ID was derived,
+// which is the IP address for SourceIPAddress and the machine ID in decimal otherwise.
+//
+// MachineID is the machine ID derived from Value.
+//
+// ResolvedAt is when the identity was resolved.
+type Identity struct {
+	Source     IdentitySource
+	Value      string
+	MachineID  uint16
+	ResolvedAt time.Time
+}
+
+func newIdentity(source IdentitySource, machineID uint16, ip net.IP) Identity {
+	identity := Identity{
+		Source:     source,
+		Value:      strconv.Itoa(int(machineID)),
+		MachineID:  machineID,
+		ResolvedAt: time.Now(),
+	}
+	if ip != nil {
+		identity.Value = ip.String()
+	}
+	return identity
+}
+
+// ResolvedIdentity returns the Identity of the current machine ID of the Sonyflake,
+// so that operators can log and verify where the machine ID came from.
+func (sf *Sonyflake) ResolvedIdentity() Identity {
+	sf.mutex.Lock()
+	defer sf.mutex.Unlock()
+
+	return sf.identity
+}
+
+// Refresh re-resolves the machine ID in the same way as New and returns the resolved Identity.
+// If the machine ID is unchanged, Refresh replaces the Identity returned by ResolvedIdentity,
+// such as when the IP address has changed only in the bits not used for the machine ID.
+// Otherwise Refresh returns ErrMachineIDChanged with the resolved Identity without changing the Sonyflake,
+// which keeps generating IDs with the current machine ID until SetMachineID is called.
+// Refresh returns an error if the machine ID cannot be resolved.
+func (sf *Sonyflake) Refresh() (Identity, error) {
+	machineID, ip, source, err := sf.resolve()
+	if err != nil {
+		return Identity{}, err
+	}
+	identity := newIdentity(source, machineID, ip)
+
+	sf.mutex.Lock()
+	defer sf.mutex.Unlock()
+
+	if machineID != sf.machineID {
+		return identity, ErrMachineIDChanged
+	}
+	sf.identity = identity
+	sf.machineIP = ip
+	return identity, nil
+}
+
+// resolve resolves the machine ID by the primary provider, or by Settings.FallbackMachineID if it fails,
+// and returns the source of the machine ID.
+func (sf *Sonyflake) resolve() (uint16, net.IP, IdentitySource, error) {
+	if sf.resolveMachineID != nil {
+		machineID, ip, err := sf.resolveMachineID()
+		if err == nil || sf.fallbackMachineID == nil {
+			return machineID, ip, sf.identitySource, err
+		}
+	}
+
+	machineID, err := sf.fallbackMachineID()
+	return machineID, nil, SourceFallback, err
+}
@@ -0,0 +1,93 @@
+package sonyflake
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestResolvedIdentity(t *testing.T) {
+	machineID := uint16(5)
+	sf, err := New(Settings{
+		StartTime: time.Now(),
+		MachineID: func() (uint16, error) { return machineID, nil },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	identity := sf.ResolvedIdentity()
+	if identity.Source != SourceSettings || identity.Value != "5" || identity.MachineID != 5 || identity.ResolvedAt.IsZero() {
+		t.Errorf("unexpected identity: %+v", identity)
+	}
+
+	refreshed, err := sf.Refresh()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if refreshed.MachineID != 5 || sf.ResolvedIdentity() != refreshed {
+		t.Errorf("unexpected refreshed identity: %+v", refreshed)
+	}
+
+	machineID = 6
+	changed, err := sf.Refresh()
+	if err != ErrMachineIDChanged {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if changed.MachineID != 6 || sf.ResolvedIdentity() != refreshed {
+		t.Errorf("unexpected identities: %+v, %+v", changed, sf.ResolvedIdentity())
+	}
+
+	if err := sf.SetMachineID(6, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if identity := sf.ResolvedIdentity(); identity.Source != SourceSetMachineID || identity.MachineID != 6 {
+		t.Errorf("unexpected identity: %+v", identity)
+	}
+}
+
+func TestFallbackMachineID(t *testing.T) {
+	errPrimary := errors.New("primary error")
+	errFallback := errors.New("fallback error")
+	primaryErr := errPrimary
+	fallbackErr := error(nil)
+	st := Settings{
+		StartTime:         time.Now(),
+		MachineID:         func() (uint16, error) { return 1, primaryErr },
+		FallbackMachineID: func() (uint16, error) { return 2, fallbackErr },
+	}
+
+	sf, err := New(st)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if identity := sf.ResolvedIdentity(); identity.Source != SourceFallback || identity.MachineID != 2 {
+		t.Errorf("unexpected identity: %+v", identity)
+	}
+
+	primaryErr = nil
+	if identity, err := sf.Refresh(); err != ErrMachineIDChanged || identity.Source != SourceSettings || identity.MachineID != 1 {
+		t.Errorf("unexpected refresh: %+v, %v", identity, err)
+	}
+
+	sf, err = New(st)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if identity := sf.ResolvedIdentity(); identity.Source != SourceSettings || identity.MachineID != 1 {
+		t.Errorf("fallback must not be called if the primary succeeds: %+v", identity)
+	}
+
+	primaryErr, fallbackErr = errPrimary, errFallback
+	if _, err := New(st); err != errFallback {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestNewIdentity(t *testing.T) {
+	identity := newIdentity(SourceIPAddress, 0x0102, net.IPv4(10, 0, 1, 2))
+	if identity.Value != "10.0.1.2" || identity.MachineID != 0x0102 || identity.Source.String() != "ip-address" {
+		t.Errorf("unexpected identity: %+v", identity)
+	}
+}
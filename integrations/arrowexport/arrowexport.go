@@ -0,0 +1,138 @@
+// Package arrowexport writes the decomposed parts of Sonyflake IDs as Apache Arrow record batches
+// by github.com/apache/arrow/go, in the Arrow IPC stream format or as Parquet files,
+// so that ID metadata can be loaded into analytics warehouses.
+//
+// The columns are those of the rows of the export package:
+// the ID, its time as a timestamp in nanoseconds in UTC, its sequence number, namespace ID and machine ID.
+package arrowexport
+
+import (
+	"errors"
+	"io"
+
+	"github.com/apache/arrow/go/v15/arrow"
+	"github.com/apache/arrow/go/v15/arrow/array"
+	"github.com/apache/arrow/go/v15/arrow/ipc"
+	"github.com/apache/arrow/go/v15/arrow/memory"
+	"github.com/apache/arrow/go/v15/parquet"
+	"github.com/apache/arrow/go/v15/parquet/pqarrow"
+
+	"github.com/sony/sonyflake"
+	"github.com/sony/sonyflake/export"
+)
+
+// ErrUnknownFormat is returned by ParseFormat if the format is neither "arrow" nor "parquet".
+var ErrUnknownFormat = errors.New("unknown columnar format")
+
+// Format is the format of written record batches.
+type Format int
+
+const (
+	// IPC writes record batches in the Arrow IPC stream format.
+	IPC Format = iota
+	// Parquet writes a Parquet file with a row group per record batch.
+	Parquet
+)
+
+// ParseFormat returns the Format of the given name, "arrow" or "parquet".
+func ParseFormat(name string) (Format, error) {
+	switch name {
+	case "arrow":
+		return IPC, nil
+	case "parquet":
+		return Parquet, nil
+	}
+	return 0, ErrUnknownFormat
+}
+
+// Schema is the Arrow schema of the record batches.
+var Schema = arrow.NewSchema([]arrow.Field{
+	{Name: "id", Type: arrow.PrimitiveTypes.Uint64},
+	{Name: "time", Type: arrow.FixedWidthTypes.Timestamp_ns},
+	{Name: "sequence", Type: arrow.PrimitiveTypes.Uint64},
+	{Name: "namespace", Type: arrow.PrimitiveTypes.Uint64},
+	{Name: "machine_id", Type: arrow.PrimitiveTypes.Uint64},
+}, nil)
+
+// NewRecord returns the record batch of the given IDs in the given Layout allocated by mem.
+// The record must be released by the caller.
+// NewRecord returns the error of Layout.Validate if the Layout is invalid.
+func NewRecord(mem memory.Allocator, l sonyflake.Layout, ids []uint64) (arrow.Record, error) {
+	if err := l.Validate(); err != nil {
+		return nil, err
+	}
+
+	b := array.NewRecordBuilder(mem, Schema)
+	defer b.Release()
+	b.Reserve(len(ids))
+
+	id := b.Field(0).(*array.Uint64Builder)
+	t := b.Field(1).(*array.TimestampBuilder)
+	sequence := b.Field(2).(*array.Uint64Builder)
+	namespace := b.Field(3).(*array.Uint64Builder)
+	machineID := b.Field(4).(*array.Uint64Builder)
+	for _, v := range ids {
+		row := export.NewRow(l, v)
+		id.Append(row.ID)
+		t.Append(arrow.Timestamp(row.Time.UnixNano()))
+		sequence.Append(row.Sequence)
+		namespace.Append(row.Namespace)
+		machineID.Append(row.MachineID)
+	}
+	return b.NewRecord(), nil
+}
+
+// Writer writes batches of IDs as record batches in a Format.
+// Close must be called after the last batch to complete the stream or file.
+type Writer struct {
+	layout  sonyflake.Layout
+	mem     memory.Allocator
+	ipc     *ipc.Writer
+	parquet *pqarrow.FileWriter
+}
+
+// NewWriter returns a new Writer of the record batches of IDs in the given Layout to w in the given Format.
+// It returns the error of Layout.Validate if the Layout is invalid.
+func NewWriter(w io.Writer, l sonyflake.Layout, f Format) (*Writer, error) {
+	if err := l.Validate(); err != nil {
+		return nil, err
+	}
+
+	mem := memory.NewGoAllocator()
+	bw := &Writer{layout: l, mem: mem}
+	switch f {
+	case Parquet:
+		// hide Close of w, which the Parquet writer would call
+		fw, err := pqarrow.NewFileWriter(Schema, struct{ io.Writer }{w},
+			parquet.NewWriterProperties(parquet.WithAllocator(mem)), pqarrow.DefaultWriterProps())
+		if err != nil {
+			return nil, err
+		}
+		bw.parquet = fw
+	default:
+		bw.ipc = ipc.NewWriter(w, ipc.WithSchema(Schema), ipc.WithAllocator(mem))
+	}
+	return bw, nil
+}
+
+// WriteBatch writes the given IDs as a record batch.
+func (w *Writer) WriteBatch(ids []uint64) error {
+	rec, err := NewRecord(w.mem, w.layout, ids)
+	if err != nil {
+		return err
+	}
+	defer rec.Release()
+
+	if w.parquet != nil {
+		return w.parquet.Write(rec)
+	}
+	return w.ipc.Write(rec)
+}
+
+// Close completes the stream or file. It does not close the underlying io.Writer.
+func (w *Writer) Close() error {
+	if w.parquet != nil {
+		return w.parquet.Close()
+	}
+	return w.ipc.Close()
+}
@@ -0,0 +1,181 @@
+package arrowexport
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v15/arrow"
+	"github.com/apache/arrow/go/v15/arrow/array"
+	"github.com/apache/arrow/go/v15/arrow/ipc"
+	"github.com/apache/arrow/go/v15/arrow/memory"
+	"github.com/apache/arrow/go/v15/parquet/file"
+	"github.com/apache/arrow/go/v15/parquet/pqarrow"
+
+	"github.com/sony/sonyflake"
+	"github.com/sony/sonyflake/export"
+)
+
+func newIDs(t *testing.T, n int) (sonyflake.Layout, []uint64) {
+	sf, err := sonyflake.New(sonyflake.Settings{
+		MachineID:     func() (uint16, error) { return 0x2ab, nil },
+		BitsNamespace: 2,
+		NamespaceID:   3,
+		BitsMachineID: 14,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ids := make([]uint64, n)
+	for i := range ids {
+		id, err := sf.NextID()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		ids[i] = id
+	}
+	return sf.Layout(), ids
+}
+
+func checkTable(t *testing.T, tbl arrow.Table, l sonyflake.Layout, ids []uint64) {
+	// Parquet adds field IDs as metadata
+	for i, f := range tbl.Schema().Fields() {
+		if expected := Schema.Field(i); f.Name != expected.Name || !arrow.TypeEqual(f.Type, expected.Type) {
+			t.Fatalf("unexpected field: %s", f)
+		}
+	}
+	if tbl.NumRows() != int64(len(ids)) {
+		t.Fatalf("unexpected number of rows: %d", tbl.NumRows())
+	}
+
+	rows := make([]export.Row, 0, len(ids))
+	tr := array.NewTableReader(tbl, 0)
+	defer tr.Release()
+	for tr.Next() {
+		rec := tr.Record()
+		id := rec.Column(0).(*array.Uint64)
+		ts := rec.Column(1).(*array.Timestamp)
+		sequence := rec.Column(2).(*array.Uint64)
+		namespace := rec.Column(3).(*array.Uint64)
+		machineID := rec.Column(4).(*array.Uint64)
+		for i := 0; i < int(rec.NumRows()); i++ {
+			rows = append(rows, export.Row{
+				ID:        id.Value(i),
+				Time:      time.Unix(0, int64(ts.Value(i))).UTC(),
+				Sequence:  sequence.Value(i),
+				Namespace: namespace.Value(i),
+				MachineID: machineID.Value(i),
+			})
+		}
+	}
+
+	for i, row := range rows {
+		if expected := export.NewRow(l, ids[i]); row != expected {
+			t.Errorf("unexpected row: %+v, expected %+v", row, expected)
+		}
+	}
+}
+
+func TestIPC(t *testing.T) {
+	l, ids := newIDs(t, 300)
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, l, IPC)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, batch := range [][]uint64{ids[:100], ids[100:]} {
+		if err := w.WriteBatch(batch); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	r, err := ipc.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer r.Release()
+	var recs []arrow.Record
+	for r.Next() {
+		rec := r.Record()
+		rec.Retain()
+		defer rec.Release()
+		recs = append(recs, rec)
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(recs) != 2 {
+		t.Fatalf("unexpected number of record batches: %d", len(recs))
+	}
+
+	tbl := array.NewTableFromRecords(Schema, recs)
+	defer tbl.Release()
+	checkTable(t, tbl, l, ids)
+}
+
+func TestParquet(t *testing.T) {
+	l, ids := newIDs(t, 300)
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, l, Parquet)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, batch := range [][]uint64{ids[:100], ids[100:]} {
+		if err := w.WriteBatch(batch); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	pf, err := file.NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer pf.Close()
+	if pf.NumRowGroups() != 2 {
+		t.Errorf("unexpected number of row groups: %d", pf.NumRowGroups())
+	}
+
+	fr, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	tbl, err := fr.ReadTable(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer tbl.Release()
+	checkTable(t, tbl, l, ids)
+}
+
+func TestNewWriterInvalidLayout(t *testing.T) {
+	l, _ := newIDs(t, 0)
+	l.TimeUnit = 0
+	if _, err := NewWriter(&bytes.Buffer{}, l, IPC); err != sonyflake.ErrInvalidTimeUnit {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := NewRecord(memory.DefaultAllocator, l, nil); err != sonyflake.ErrInvalidTimeUnit {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for name, expected := range map[string]Format{"arrow": IPC, "parquet": Parquet} {
+		f, err := ParseFormat(name)
+		if err != nil || f != expected {
+			t.Errorf("unexpected format of %s: %d, %v", name, f, err)
+		}
+	}
+	if _, err := ParseFormat("orc"); err != ErrUnknownFormat {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+// Package avro provides the Avro logical type "sonyflake-id" of Sonyflake IDs,
+// so that Kafka pipelines with Avro schemas can tag and validate the fields holding IDs.
+//
+// The logical type annotates an Avro long:
+//
+//	{"type": "long", "logicalType": "sonyflake-id"}
+//
+// Avro libraries, such as github.com/hamba/avro and github.com/linkedin/goavro, ignore unknown logical types
+// and read and write such fields as int64, which ToLong and FromLong convert from and to IDs.
+// Since long is signed, an ID with the most significant bit set, which Settings.UseMSB allows,
+// cannot be stored and is rejected with sonyflake.ErrInvalidID.
+package avro
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/sony/sonyflake"
+)
+
+// LogicalType is the name of the Avro logical type of Sonyflake IDs.
+const LogicalType = "sonyflake-id"
+
+// These are the schema snippets of the logical type, a long and an optional long defaulting to null.
+const (
+	Schema         = `{"type":"long","logicalType":"sonyflake-id"}`
+	NullableSchema = `["null",{"type":"long","logicalType":"sonyflake-id"}]`
+)
+
+// ErrInvalidSchema is returned by IDFields if a field of the logical type is not a long.
+var ErrInvalidSchema = errors.New("invalid schema of sonyflake-id")
+
+// Field returns the schema snippet of a record field of the given name holding an ID, such as:
+//
+//	{"name":"id","type":{"type":"long","logicalType":"sonyflake-id"}}
+//
+// If nullable is true, the field is optional and defaults to null.
+func Field(name string, nullable bool) string {
+	quoted, _ := json.Marshal(name)
+	if nullable {
+		return `{"name":` + string(quoted) + `,"type":` + NullableSchema + `,"default":null}`
+	}
+	return `{"name":` + string(quoted) + `,"type":` + Schema + `}`
+}
+
+// ToLong returns the Avro long of the given ID.
+// It returns sonyflake.ErrInvalidID if the ID overflows long.
+func ToLong(id uint64) (int64, error) {
+	if id > math.MaxInt64 {
+		return 0, fmt.Errorf("%w: %d overflows long", sonyflake.ErrInvalidID, id)
+	}
+	return int64(id), nil
+}
+
+// FromLong returns the ID of the given Avro long.
+// It returns sonyflake.ErrInvalidID for a negative value.
+func FromLong(v int64) (uint64, error) {
+	if v < 0 {
+		return 0, fmt.Errorf("%w: %d is negative", sonyflake.ErrInvalidID, v)
+	}
+	return uint64(v), nil
+}
+
+// IDFields returns the names of the fields of the logical type in the given Avro record schema,
+// including optional fields of a union with null, so that a pipeline can validate the IDs in records.
+// It returns ErrInvalidSchema if such a field is not a long, and an error if the schema is not a JSON record schema.
+// Nested records are not searched.
+func IDFields(schema []byte) ([]string, error) {
+	var record struct {
+		Type   string `json:"type"`
+		Fields []struct {
+			Name string          `json:"name"`
+			Type json.RawMessage `json:"type"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal(schema, &record); err != nil {
+		return nil, err
+	}
+	if record.Type != "record" {
+		return nil, fmt.Errorf("schema of type %q is not a record", record.Type)
+	}
+
+	var names []string
+	for _, f := range record.Fields {
+		ok, err := isID(f.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+		if ok {
+			names = append(names, f.Name)
+		}
+	}
+	return names, nil
+}
+
+// isID reports whether the given field type is the logical type or a union of null and the logical type.
+func isID(typ json.RawMessage) (bool, error) {
+	var union []json.RawMessage
+	if json.Unmarshal(typ, &union) == nil {
+		for _, t := range union {
+			if ok, err := isID(t); ok || err != nil {
+				return ok, err
+			}
+		}
+		return false, nil
+	}
+
+	var t struct {
+		Type        interface{} `json:"type"`
+		LogicalType string      `json:"logicalType"`
+	}
+	if json.Unmarshal(typ, &t) != nil || t.LogicalType != LogicalType {
+		return false, nil // a primitive type name or a type of another logical type
+	}
+	if t.Type != "long" {
+		return false, ErrInvalidSchema
+	}
+	return true, nil
+}
@@ -0,0 +1,65 @@
+package avro
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/sony/sonyflake"
+)
+
+func TestLong(t *testing.T) {
+	v, err := ToLong(123456789012345)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	id, err := FromLong(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if id != 123456789012345 {
+		t.Errorf("unexpected id: %d", id)
+	}
+
+	if _, err := ToLong(math.MaxInt64 + 1); !errors.Is(err, sonyflake.ErrInvalidID) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := FromLong(-1); !errors.Is(err, sonyflake.ErrInvalidID) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestField(t *testing.T) {
+	for _, nullable := range []bool{false, true} {
+		var v interface{}
+		if err := json.Unmarshal([]byte(Field("order_id", nullable)), &v); err != nil {
+			t.Fatalf("invalid field schema: %s", err)
+		}
+	}
+
+	schema := `{"type":"record","name":"Order","fields":[` +
+		Field("id", false) + `,` +
+		Field("parent_id", true) + `,` +
+		`{"name":"created_at","type":{"type":"long","logicalType":"timestamp-millis"}},` +
+		`{"name":"amount","type":"long"}]}`
+	names, err := IDFields([]byte(schema))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if expected := []string{"id", "parent_id"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("unexpected fields: %v", names)
+	}
+}
+
+func TestIDFieldsError(t *testing.T) {
+	schema := `{"type":"record","name":"Order","fields":[{"name":"id","type":{"type":"string","logicalType":"sonyflake-id"}}]}`
+	if _, err := IDFields([]byte(schema)); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := IDFields([]byte(Schema)); err == nil {
+		t.Error("schema other than a record must be rejected")
+	}
+}
@@ -0,0 +1,99 @@
+// Package boltstore provides a coordinator.Store in a bbolt database,
+// which fits a coordinator running on a single node.
+package boltstore
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/sony/sonyflake/coordinator"
+)
+
+// DefaultBucket is the name of the bucket in which the allocations are stored by default.
+const DefaultBucket = "sonyflake_allocations"
+
+// Store is a coordinator.Store keeping the allocations in a bucket of a bbolt database,
+// keyed by the machine IDs in big endian.
+type Store struct {
+	db     *bolt.DB
+	bucket []byte
+}
+
+var _ coordinator.Store = (*Store)(nil)
+
+// New returns a new Store in the given bucket of db, creating the bucket if it does not exist.
+// If bucket is empty, DefaultBucket is used.
+func New(db *bolt.DB, bucket string) (*Store, error) {
+	if bucket == "" {
+		bucket = DefaultBucket
+	}
+	s := &Store{db: db, bucket: []byte(bucket)}
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(s.bucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func key(machineID uint16) []byte {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, machineID)
+	return b
+}
+
+// List implements coordinator.Store.
+// The allocations are in the order of machine IDs since bbolt sorts the keys in big endian.
+func (s *Store) List(ctx context.Context) ([]coordinator.Allocation, error) {
+	var list []coordinator.Allocation
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(s.bucket).ForEach(func(k, v []byte) error {
+			var a coordinator.Allocation
+			if err := json.Unmarshal(v, &a); err != nil {
+				return err
+			}
+			list = append(list, a)
+			return nil
+		})
+	})
+	return list, err
+}
+
+// Get implements coordinator.Store.
+func (s *Store) Get(ctx context.Context, machineID uint16) (coordinator.Allocation, bool, error) {
+	var a coordinator.Allocation
+	var ok bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(s.bucket).Get(key(machineID))
+		if v == nil {
+			return nil
+		}
+		ok = true
+		return json.Unmarshal(v, &a)
+	})
+	return a, ok, err
+}
+
+// Put implements coordinator.Store.
+func (s *Store) Put(ctx context.Context, a coordinator.Allocation) error {
+	v, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(s.bucket).Put(key(a.MachineID), v)
+	})
+}
+
+// Delete implements coordinator.Store.
+func (s *Store) Delete(ctx context.Context, machineID uint16) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(s.bucket).Delete(key(machineID))
+	})
+}
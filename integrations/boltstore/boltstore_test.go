@@ -0,0 +1,35 @@
+package boltstore
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/sony/sonyflake/coordinator/storetest"
+)
+
+func TestStore(t *testing.T) {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "coordinator.db"), 0600, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer db.Close()
+
+	s, err := New(db, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	storetest.Run(t, s)
+
+	// the allocations persist in the bucket
+	s, err = New(db, DefaultBucket)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	list, err := s.List(context.Background())
+	if err != nil || len(list) != 2 {
+		t.Errorf("unexpected allocations: %v, %v", list, err)
+	}
+}
@@ -0,0 +1,12 @@
+module github.com/sony/sonyflake/integrations/boltstore
+
+go 1.22
+
+require (
+	github.com/sony/sonyflake v1.0.0
+	go.etcd.io/bbolt v1.3.11
+)
+
+require golang.org/x/sys v0.4.0 // indirect
+
+replace github.com/sony/sonyflake => ../..
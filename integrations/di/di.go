@@ -0,0 +1,49 @@
+// Package di provides constructors of Sonyflake for dependency injection frameworks,
+// such as google/wire and uber/fx, so that applications need not write the providers and the hooks of Close.
+package di
+
+import (
+	"context"
+
+	"go.uber.org/fx"
+
+	"github.com/sony/sonyflake"
+)
+
+// Provide returns a new Sonyflake configured with the given Settings and a cleanup function which closes it.
+// It is a provider of google/wire, such as in wire.Build(di.Provide, ...).
+func Provide(st sonyflake.Settings) (*sonyflake.Sonyflake, func(), error) {
+	sf, err := sonyflake.New(st)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sf, func() { sf.Close() }, nil
+}
+
+// ProvideFromEnv is the same as Provide but configures the Sonyflake by sonyflake.SettingsFromEnv,
+// for applications which do not inject Settings.
+func ProvideFromEnv() (*sonyflake.Sonyflake, func(), error) {
+	st, err := sonyflake.SettingsFromEnv()
+	if err != nil {
+		return nil, nil, err
+	}
+	return Provide(st)
+}
+
+// New returns a new Sonyflake configured with the given Settings and closes it when the given lifecycle stops.
+// It is a constructor of uber/fx, such as in fx.Provide(di.New).
+func New(lc fx.Lifecycle, st sonyflake.Settings) (*sonyflake.Sonyflake, error) {
+	sf, err := sonyflake.New(st)
+	if err != nil {
+		return nil, err
+	}
+	lc.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			return sf.Close()
+		},
+	})
+	return sf, nil
+}
+
+// Module is the module of uber/fx which provides a Sonyflake by New from the sonyflake.Settings in the container.
+var Module = fx.Module("sonyflake", fx.Provide(New))
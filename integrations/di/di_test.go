@@ -0,0 +1,61 @@
+package di
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/fx"
+
+	"github.com/sony/sonyflake"
+)
+
+var settings = sonyflake.Settings{
+	MachineID: func() (uint16, error) { return 1, nil },
+}
+
+func TestProvide(t *testing.T) {
+	sf, cleanup, err := Provide(settings)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := sf.NextID(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	cleanup()
+	if err := sf.Err(); err != sonyflake.ErrClosed {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, _, err := Provide(sonyflake.Settings{BitsSequence: -1}); err != sonyflake.ErrInvalidBitsSequence {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestModule(t *testing.T) {
+	var sf *sonyflake.Sonyflake
+	app := fx.New(
+		fx.NopLogger,
+		fx.Supply(settings),
+		Module,
+		fx.Populate(&sf),
+	)
+	if err := app.Err(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ctx := context.Background()
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := sf.NextID(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := app.Stop(ctx); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := sf.Err(); err != sonyflake.ErrClosed {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
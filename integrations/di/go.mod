@@ -0,0 +1,17 @@
+module github.com/sony/sonyflake/integrations/di
+
+go 1.22
+
+require (
+	github.com/sony/sonyflake v1.0.0
+	go.uber.org/fx v1.24.0
+)
+
+require (
+	go.uber.org/dig v1.19.0 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+	go.uber.org/zap v1.26.0 // indirect
+	golang.org/x/sys v0.0.0-20220412211240-33da011f77ad // indirect
+)
+
+replace github.com/sony/sonyflake => ../..
@@ -0,0 +1,70 @@
+// Package dynamodb provides attribute value marshalers of Sonyflake IDs
+// for the Amazon DynamoDB attributevalue package of aws-sdk-go-v2.
+package dynamodb
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/sony/sonyflake"
+)
+
+// ErrUnexpectedAttributeType is returned when an attribute value is not of the expected type.
+var ErrUnexpectedAttributeType = errors.New("unexpected attribute type")
+
+var (
+	_ attributevalue.Marshaler   = NumberID(0)
+	_ attributevalue.Unmarshaler = (*NumberID)(nil)
+	_ attributevalue.Marshaler   = StringID(0)
+	_ attributevalue.Unmarshaler = (*StringID)(nil)
+)
+
+// NumberID is a Sonyflake ID stored as a number attribute.
+type NumberID sonyflake.ID
+
+// MarshalDynamoDBAttributeValue implements attributevalue.Marshaler.
+func (id NumberID) MarshalDynamoDBAttributeValue() (types.AttributeValue, error) {
+	return &types.AttributeValueMemberN{Value: strconv.FormatUint(uint64(id), 10)}, nil
+}
+
+// UnmarshalDynamoDBAttributeValue implements attributevalue.Unmarshaler.
+func (id *NumberID) UnmarshalDynamoDBAttributeValue(av types.AttributeValue) error {
+	n, ok := av.(*types.AttributeValueMemberN)
+	if !ok {
+		return fmt.Errorf("%w: %T, want number", ErrUnexpectedAttributeType, av)
+	}
+
+	v, err := strconv.ParseUint(n.Value, 10, 64)
+	if err != nil {
+		return err
+	}
+	*id = NumberID(v)
+	return nil
+}
+
+// StringID is a Sonyflake ID stored as a string attribute in the default encoding of sonyflake.ID.
+type StringID sonyflake.ID
+
+// MarshalDynamoDBAttributeValue implements attributevalue.Marshaler.
+func (id StringID) MarshalDynamoDBAttributeValue() (types.AttributeValue, error) {
+	return &types.AttributeValueMemberS{Value: sonyflake.ID(id).String()}, nil
+}
+
+// UnmarshalDynamoDBAttributeValue implements attributevalue.Unmarshaler.
+func (id *StringID) UnmarshalDynamoDBAttributeValue(av types.AttributeValue) error {
+	s, ok := av.(*types.AttributeValueMemberS)
+	if !ok {
+		return fmt.Errorf("%w: %T, want string", ErrUnexpectedAttributeType, av)
+	}
+
+	var v sonyflake.ID
+	if _, err := fmt.Sscan(s.Value, &v); err != nil {
+		return err
+	}
+	*id = StringID(v)
+	return nil
+}
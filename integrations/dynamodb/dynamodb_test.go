@@ -0,0 +1,54 @@
+package dynamodb
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/sony/sonyflake"
+)
+
+type item struct {
+	PK  NumberID `dynamodbav:"pk"`
+	Ref StringID `dynamodbav:"ref"`
+}
+
+func TestMarshal(t *testing.T) {
+	defer sonyflake.SetDefaultEncoding(nil)
+	sonyflake.SetDefaultEncoding(sonyflake.Base62)
+
+	in := item{PK: 1 << 62, Ref: 1 << 40}
+	av, err := attributevalue.MarshalMap(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if n, ok := av["pk"].(*types.AttributeValueMemberN); !ok || n.Value != "4611686018427387904" {
+		t.Errorf("unexpected pk: %#v", av["pk"])
+	}
+	if s, ok := av["ref"].(*types.AttributeValueMemberS); !ok || s.Value != sonyflake.Base62.Encode(1<<40) {
+		t.Errorf("unexpected ref: %#v", av["ref"])
+	}
+
+	var out item
+	if err := attributevalue.UnmarshalMap(av, &out); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if out != in {
+		t.Errorf("unexpected item: %+v", out)
+	}
+}
+
+func TestUnmarshalUnexpectedType(t *testing.T) {
+	var n NumberID
+	if err := n.UnmarshalDynamoDBAttributeValue(&types.AttributeValueMemberS{Value: "1"}); !errors.Is(err, ErrUnexpectedAttributeType) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	var s StringID
+	if err := s.UnmarshalDynamoDBAttributeValue(&types.AttributeValueMemberN{Value: "1"}); !errors.Is(err, ErrUnexpectedAttributeType) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,16 @@
+module github.com/sony/sonyflake/integrations/dynamodb
+
+go 1.24
+
+require (
+	github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue v1.21.7
+	github.com/aws/aws-sdk-go-v2/service/dynamodb v1.69.1
+	github.com/sony/sonyflake v1.0.0
+)
+
+require (
+	github.com/aws/aws-sdk-go-v2/service/dynamodbstreams v1.43.0 // indirect
+	github.com/aws/smithy-go v1.28.1 // indirect
+)
+
+replace github.com/sony/sonyflake => ../..
@@ -0,0 +1,56 @@
+// Package ent provides an ent mixin declaring an int64 ID field whose default is a Sonyflake ID.
+//
+// Mix it into a schema in the same way as other ID schemes of ent:
+//
+//	var sf = sonyflake.NewSonyflake(sonyflake.Settings{})
+//
+//	func (User) Mixin() []ent.Mixin {
+//		return []ent.Mixin{
+//			entsonyflake.NewMixin(sf),
+//		}
+//	}
+package ent
+
+import (
+	"entgo.io/ent"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/mixin"
+
+	"github.com/sony/sonyflake"
+)
+
+// FieldName is the name of the ID field declared by Mixin.
+const FieldName = "id"
+
+// Mixin declares an immutable int64 ID field defaulting to IDs generated by a shared Sonyflake.
+type Mixin struct {
+	mixin.Schema
+	sf *sonyflake.Sonyflake
+}
+
+var _ ent.Mixin = Mixin{}
+
+// NewMixin returns a new Mixin generating IDs by the given Sonyflake.
+func NewMixin(sf *sonyflake.Sonyflake) Mixin {
+	return Mixin{sf: sf}
+}
+
+// Fields implements ent.Mixin.
+func (m Mixin) Fields() []ent.Field {
+	return []ent.Field{
+		field.Int64(FieldName).
+			DefaultFunc(m.NextID).
+			Immutable(),
+	}
+}
+
+// NextID returns a new ID as int64.
+// NextID panics if the Sonyflake fails to generate an ID,
+// because ent does not allow default functions to return errors.
+func (m Mixin) NextID() int64 {
+	id, err := m.sf.NextID()
+	if err != nil {
+		panic(err)
+	}
+	return int64(id)
+}
@@ -0,0 +1,59 @@
+package ent
+
+import (
+	"testing"
+
+	"github.com/sony/sonyflake"
+)
+
+func newMixin(t *testing.T) Mixin {
+	sf, err := sonyflake.New(sonyflake.Settings{
+		MachineID: func() (uint16, error) { return 1, nil },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return NewMixin(sf)
+}
+
+func TestMixinFields(t *testing.T) {
+	fields := newMixin(t).Fields()
+	if len(fields) != 1 {
+		t.Fatalf("unexpected number of fields: %d", len(fields))
+	}
+
+	desc := fields[0].Descriptor()
+	if desc.Err != nil {
+		t.Fatalf("unexpected error: %s", desc.Err)
+	}
+	if desc.Name != FieldName {
+		t.Errorf("unexpected name: %s", desc.Name)
+	}
+	if !desc.Immutable {
+		t.Error("id field must be immutable")
+	}
+
+	fn, ok := desc.Default.(func() int64)
+	if !ok {
+		t.Fatalf("unexpected default: %T", desc.Default)
+	}
+	id1, id2 := fn(), fn()
+	if id1 <= 0 || id2 <= id1 {
+		t.Errorf("unexpected ids: %d, %d", id1, id2)
+	}
+	if machineID := sonyflake.MachineID(uint64(id1)); machineID != 1 {
+		t.Errorf("unexpected machine id: %d", machineID)
+	}
+}
+
+func TestMixinNextIDPanics(t *testing.T) {
+	m := newMixin(t)
+	m.sf.Close()
+
+	defer func() {
+		if r := recover(); r != sonyflake.ErrClosed {
+			t.Errorf("unexpected panic: %v", r)
+		}
+	}()
+	m.NextID()
+}
@@ -0,0 +1,9 @@
+module github.com/sony/sonyflake/integrations/ent
+
+go 1.24
+
+require github.com/sony/sonyflake v1.0.0
+
+require entgo.io/ent v0.14.6
+
+replace github.com/sony/sonyflake => ../..
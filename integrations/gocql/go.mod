@@ -0,0 +1,16 @@
+module github.com/sony/sonyflake/integrations/gocql
+
+go 1.21
+
+require (
+	github.com/gocql/gocql v1.6.0
+	github.com/sony/sonyflake v1.0.0
+)
+
+require (
+	github.com/golang/snappy v0.0.3 // indirect
+	github.com/hailocab/go-hostpool v0.0.0-20160125115350-e80d13ce29ed // indirect
+	gopkg.in/inf.v0 v0.9.1 // indirect
+)
+
+replace github.com/sony/sonyflake => ../..
@@ -0,0 +1,46 @@
+// Package gocql provides CQL marshalers of Sonyflake IDs for github.com/gocql/gocql,
+// so that IDs are written to and read from bigint columns of Cassandra and ScyllaDB without custom codecs.
+package gocql
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gocql/gocql"
+
+	"github.com/sony/sonyflake"
+)
+
+var (
+	_ gocql.Marshaler   = ID(0)
+	_ gocql.Unmarshaler = (*ID)(nil)
+)
+
+// ID is a Sonyflake ID stored in a bigint column.
+//
+// Since bigint is signed, an ID with the most significant bit set, which Settings.UseMSB allows,
+// cannot be stored and is rejected with sonyflake.ErrInvalidID instead of being stored as a negative number.
+type ID sonyflake.ID
+
+// MarshalCQL implements gocql.Marshaler.
+// It marshals the ID as an int64, which is also accepted by varint columns.
+func (id ID) MarshalCQL(info gocql.TypeInfo) ([]byte, error) {
+	if uint64(id) > math.MaxInt64 {
+		return nil, fmt.Errorf("%w: %d overflows bigint", sonyflake.ErrInvalidID, uint64(id))
+	}
+	return gocql.Marshal(info, int64(id))
+}
+
+// UnmarshalCQL implements gocql.Unmarshaler.
+// It returns sonyflake.ErrInvalidID for a negative value.
+func (id *ID) UnmarshalCQL(info gocql.TypeInfo, data []byte) error {
+	var v int64
+	if err := gocql.Unmarshal(info, data, &v); err != nil {
+		return err
+	}
+	if v < 0 {
+		return fmt.Errorf("%w: %d is negative", sonyflake.ErrInvalidID, v)
+	}
+	*id = ID(v)
+	return nil
+}
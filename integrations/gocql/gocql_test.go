@@ -0,0 +1,48 @@
+package gocql
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/gocql/gocql"
+
+	"github.com/sony/sonyflake"
+)
+
+var bigint = gocql.NewNativeType(4, gocql.TypeBigInt, "")
+
+func TestMarshalCQL(t *testing.T) {
+	data, err := gocql.Marshal(bigint, ID(123456789012345))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var v int64
+	if err := gocql.Unmarshal(bigint, data, &v); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if v != 123456789012345 {
+		t.Errorf("unexpected value: %d", v)
+	}
+
+	var id ID
+	if err := gocql.Unmarshal(bigint, data, &id); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if id != 123456789012345 {
+		t.Errorf("unexpected id: %d", id)
+	}
+
+	if _, err := gocql.Marshal(bigint, ID(math.MaxInt64+1)); !errors.Is(err, sonyflake.ErrInvalidID) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	negative, err := gocql.Marshal(bigint, int64(-1))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := gocql.Unmarshal(bigint, negative, &id); !errors.Is(err, sonyflake.ErrInvalidID) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
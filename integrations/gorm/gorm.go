@@ -0,0 +1,106 @@
+// Package gorm provides a GORM plugin assigning Sonyflake IDs to primary keys on create.
+//
+// The plugin assigns an ID to every zero-valued int64 or uint64 field tagged with sonyflake:
+//
+//	type Order struct {
+//		ID   int64 `gorm:"primaryKey" sonyflake:""`
+//		Item string
+//	}
+//
+//	db.Use(gorm.NewPlugin(sf))
+package gorm
+
+import (
+	"errors"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"github.com/sony/sonyflake"
+)
+
+// TagName is the name of the struct tag marking fields to which the plugin assigns IDs.
+const TagName = "sonyflake"
+
+// ErrUnsupportedFieldType is added to the statement when a tagged field is neither int64 nor uint64.
+var ErrUnsupportedFieldType = errors.New("sonyflake field must be int64 or uint64")
+
+// Plugin is a GORM plugin assigning IDs generated by a shared Sonyflake.
+type Plugin struct {
+	sf *sonyflake.Sonyflake
+}
+
+var _ gorm.Plugin = (*Plugin)(nil)
+
+// NewPlugin returns a new Plugin generating IDs by the given Sonyflake.
+func NewPlugin(sf *sonyflake.Sonyflake) *Plugin {
+	return &Plugin{sf: sf}
+}
+
+// Name implements gorm.Plugin.
+func (p *Plugin) Name() string {
+	return "sonyflake"
+}
+
+// Initialize implements gorm.Plugin.
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	return db.Callback().Create().Before("gorm:create").Register("sonyflake:assign_id", p.assignID)
+}
+
+func (p *Plugin) assignID(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+
+	var fields []*schema.Field
+	for _, field := range db.Statement.Schema.Fields {
+		if _, ok := field.Tag.Lookup(TagName); ok {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return
+	}
+
+	rv := db.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			p.assignFields(db, fields, reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		p.assignFields(db, fields, rv)
+	}
+}
+
+func (p *Plugin) assignFields(db *gorm.DB, fields []*schema.Field, rv reflect.Value) {
+	ctx := db.Statement.Context
+	for _, field := range fields {
+		if _, zero := field.ValueOf(ctx, rv); !zero {
+			continue
+		}
+
+		id, err := p.sf.NextID()
+		if err != nil {
+			db.AddError(err)
+			return
+		}
+
+		var value interface{}
+		switch field.FieldType.Kind() {
+		case reflect.Int64:
+			value = int64(id)
+		case reflect.Uint64:
+			value = id
+		default:
+			db.AddError(ErrUnsupportedFieldType)
+			return
+		}
+
+		if err := field.Set(ctx, rv, value); err != nil {
+			db.AddError(err)
+			return
+		}
+	}
+}
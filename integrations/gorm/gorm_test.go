@@ -0,0 +1,70 @@
+package gorm
+
+import (
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/sony/sonyflake"
+)
+
+type order struct {
+	ID   int64 `gorm:"primaryKey" sonyflake:""`
+	Item string
+}
+
+func openDB(t *testing.T) *gorm.DB {
+	sf, err := sonyflake.New(sonyflake.Settings{
+		MachineID: func() (uint16, error) { return 1, nil },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := db.Use(NewPlugin(sf)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := db.AutoMigrate(&order{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return db
+}
+
+func TestPlugin(t *testing.T) {
+	db := openDB(t)
+
+	o := order{Item: "book"}
+	if err := db.Create(&o).Error; err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if o.ID == 0 || sonyflake.MachineID(uint64(o.ID)) != 1 {
+		t.Errorf("unexpected id: %d", o.ID)
+	}
+
+	orders := []order{{Item: "pen"}, {Item: "ink"}}
+	if err := db.Create(&orders).Error; err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if orders[0].ID == 0 || orders[1].ID <= orders[0].ID {
+		t.Errorf("unexpected ids: %d, %d", orders[0].ID, orders[1].ID)
+	}
+
+	preset := order{ID: 42, Item: "preset"}
+	if err := db.Create(&preset).Error; err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if preset.ID != 42 {
+		t.Errorf("preset id overwritten: %d", preset.ID)
+	}
+
+	var count int64
+	db.Model(&order{}).Count(&count)
+	if count != 4 {
+		t.Errorf("unexpected count: %d", count)
+	}
+}
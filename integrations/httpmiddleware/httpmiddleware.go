@@ -0,0 +1,31 @@
+// Package httpmiddleware provides an HTTP middleware issuing a Sonyflake ID for each request.
+//
+// Unlike UUIDs, the request IDs are sortable by the time at which the requests were received.
+package httpmiddleware
+
+import (
+	"net/http"
+
+	"github.com/sony/sonyflake"
+)
+
+// Header is the name of the header in which the request ID is sent.
+const Header = "X-Request-ID"
+
+// Handler returns an http.Handler which generates an ID by the given Sonyflake for each request,
+// sets it to the X-Request-ID header of the response in the default encoding of sonyflake.ID,
+// and stores it in the context of the request passed to next, from which sonyflake.FromContext returns it.
+// If the Sonyflake fails to generate an ID, Handler responds 500 Internal Server Error without calling next.
+func Handler(sf *sonyflake.Sonyflake, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := sf.NextID()
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		requestID := sonyflake.ID(id)
+		w.Header().Set(Header, requestID.String())
+		next.ServeHTTP(w, r.WithContext(sonyflake.NewContext(r.Context(), requestID)))
+	})
+}
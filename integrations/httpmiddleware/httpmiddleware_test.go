@@ -0,0 +1,72 @@
+package httpmiddleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sony/sonyflake"
+)
+
+func newSonyflake(t *testing.T) *sonyflake.Sonyflake {
+	sf, err := sonyflake.New(sonyflake.Settings{
+		MachineID: func() (uint16, error) { return 1, nil },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return sf
+}
+
+func TestHandler(t *testing.T) {
+	var got sonyflake.ID
+	h := Handler(newSonyflake(t), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := sonyflake.FromContext(r.Context())
+		if !ok {
+			t.Error("no request id in the context")
+		}
+		got = id
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("unexpected status: %d", rec.Code)
+	}
+	if header := rec.Header().Get(Header); header != got.String() {
+		t.Errorf("unexpected header: %s, expected %s", header, got)
+	}
+	if machineID := sonyflake.MachineID(uint64(got)); machineID != 1 {
+		t.Errorf("unexpected machine id: %d", machineID)
+	}
+
+	rec2 := httptest.NewRecorder()
+	h.ServeHTTP(rec2, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec2.Header().Get(Header) == rec.Header().Get(Header) {
+		t.Error("request ids must be unique")
+	}
+}
+
+func TestHandlerError(t *testing.T) {
+	sf := newSonyflake(t)
+	sf.Close()
+
+	called := false
+	h := Handler(sf, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("unexpected status: %d", rec.Code)
+	}
+	if called {
+		t.Error("next must not be called")
+	}
+	if header := rec.Header().Get(Header); header != "" {
+		t.Errorf("unexpected header: %s", header)
+	}
+}
@@ -0,0 +1,34 @@
+// Package msgpack provides MessagePack encoding of Sonyflake IDs
+// for github.com/vmihailenco/msgpack/v5.
+package msgpack
+
+import (
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/sony/sonyflake"
+)
+
+var (
+	_ msgpack.CustomEncoder = ID(0)
+	_ msgpack.CustomDecoder = (*ID)(nil)
+)
+
+// ID is a Sonyflake ID encoded as a MessagePack unsigned integer in the smallest format which holds it,
+// regardless of the options of the encoder.
+type ID sonyflake.ID
+
+// EncodeMsgpack implements msgpack.CustomEncoder.
+func (id ID) EncodeMsgpack(enc *msgpack.Encoder) error {
+	return enc.EncodeUint(uint64(id))
+}
+
+// DecodeMsgpack implements msgpack.CustomDecoder.
+// It accepts any MessagePack integer format.
+func (id *ID) DecodeMsgpack(dec *msgpack.Decoder) error {
+	v, err := dec.DecodeUint64()
+	if err != nil {
+		return err
+	}
+	*id = ID(v)
+	return nil
+}
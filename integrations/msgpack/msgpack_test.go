@@ -0,0 +1,41 @@
+package msgpack
+
+import (
+	"testing"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestID(t *testing.T) {
+	type event struct {
+		ID   ID     `msgpack:"id"`
+		Name string `msgpack:"name"`
+	}
+
+	for _, id := range []ID{0, 1, 1 << 40, 1<<63 - 1} {
+		in := event{ID: id, Name: "created"}
+
+		b, err := msgpack.Marshal(in)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		var out event
+		if err := msgpack.Unmarshal(b, &out); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if out != in {
+			t.Errorf("unexpected event: %+v", out)
+		}
+	}
+}
+
+func TestIDSize(t *testing.T) {
+	b, err := msgpack.Marshal(ID(1))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(b) != 1 {
+		t.Errorf("unexpected size: %d", len(b))
+	}
+}
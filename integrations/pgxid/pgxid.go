@@ -0,0 +1,143 @@
+// Package pgxid provides PostgreSQL bigint codecs of Sonyflake IDs for github.com/jackc/pgx/v5
+// and for code generated by sqlc, so that bigint columns are scanned into IDs without converting int64 by hand.
+package pgxid
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"math"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/sony/sonyflake"
+)
+
+var (
+	_ pgtype.Int64Scanner = (*ID)(nil)
+	_ pgtype.Int64Valuer  = ID(0)
+	_ sql.Scanner         = (*ID)(nil)
+	_ driver.Valuer       = ID(0)
+)
+
+// ID is a Sonyflake ID stored in a PostgreSQL bigint column.
+// It implements the interfaces of pgtype for pgx and those of database/sql,
+// so that it can be the go_type of bigint columns in the overrides of sqlc.
+// Convert it by sonyflake.ID(id) for the methods of sonyflake.ID, such as String.
+// With pgx, sonyflake.ID itself can be scanned and encoded in the same way after Register.
+//
+// Since bigint is signed, an ID with the most significant bit set, which Settings.UseMSB allows,
+// cannot be stored and is rejected with sonyflake.ErrInvalidID instead of being stored as a negative number.
+type ID sonyflake.ID
+
+// ScanInt64 implements pgtype.Int64Scanner.
+// It returns sonyflake.ErrInvalidID for a negative value.
+func (id *ID) ScanInt64(v pgtype.Int8) error {
+	if !v.Valid {
+		return fmt.Errorf("%w: cannot scan NULL", sonyflake.ErrInvalidID)
+	}
+	return id.scan(v.Int64)
+}
+
+// Int64Value implements pgtype.Int64Valuer.
+// It returns sonyflake.ErrInvalidID if the ID overflows bigint.
+func (id ID) Int64Value() (pgtype.Int8, error) {
+	if uint64(id) > math.MaxInt64 {
+		return pgtype.Int8{}, fmt.Errorf("%w: %d overflows bigint", sonyflake.ErrInvalidID, uint64(id))
+	}
+	return pgtype.Int8{Int64: int64(id), Valid: true}, nil
+}
+
+// Scan implements sql.Scanner.
+// It accepts an int64, which database/sql drivers return for bigint.
+func (id *ID) Scan(src interface{}) error {
+	v, ok := src.(int64)
+	if !ok {
+		return fmt.Errorf("%w: cannot scan %T", sonyflake.ErrInvalidID, src)
+	}
+	return id.scan(v)
+}
+
+// Value implements driver.Valuer.
+func (id ID) Value() (driver.Value, error) {
+	v, err := id.Int64Value()
+	if err != nil {
+		return nil, err
+	}
+	return v.Int64, nil
+}
+
+func (id *ID) scan(v int64) error {
+	if v < 0 {
+		return fmt.Errorf("%w: %d is negative", sonyflake.ErrInvalidID, v)
+	}
+	*id = ID(v)
+	return nil
+}
+
+// String returns the ID in the default encoding of sonyflake.ID.
+func (id ID) String() string {
+	return sonyflake.ID(id).String()
+}
+
+// Register registers ID and sonyflake.ID to the given type map so that they and their slices are encoded
+// as bigint and bigint[] when the type of a parameter is unknown, such as in the simple protocol or CopyFrom.
+// It also makes the map scan and encode sonyflake.ID as ID, so that it is checked in the same way.
+func Register(m *pgtype.Map) {
+	m.TryWrapEncodePlanFuncs = append([]pgtype.TryWrapEncodePlanFunc{tryWrapSonyflakeIDEncodePlan}, m.TryWrapEncodePlanFuncs...)
+	m.TryWrapScanPlanFuncs = append([]pgtype.TryWrapScanPlanFunc{tryWrapSonyflakeIDScanPlan}, m.TryWrapScanPlanFuncs...)
+
+	m.RegisterDefaultPgType(ID(0), "int8")
+	m.RegisterDefaultPgType([]ID(nil), "_int8")
+	m.RegisterDefaultPgType(sonyflake.ID(0), "int8")
+	m.RegisterDefaultPgType([]sonyflake.ID(nil), "_int8")
+}
+
+func tryWrapSonyflakeIDEncodePlan(value interface{}) (pgtype.WrappedEncodePlanNextSetter, interface{}, bool) {
+	if id, ok := value.(sonyflake.ID); ok {
+		return &sonyflakeIDEncodePlan{}, ID(id), true
+	}
+	return nil, nil, false
+}
+
+// sonyflakeIDEncodePlan encodes a sonyflake.ID by the plan of ID.
+type sonyflakeIDEncodePlan struct {
+	next pgtype.EncodePlan
+}
+
+func (p *sonyflakeIDEncodePlan) SetNext(next pgtype.EncodePlan) {
+	p.next = next
+}
+
+func (p *sonyflakeIDEncodePlan) Encode(value interface{}, buf []byte) ([]byte, error) {
+	return p.next.Encode(ID(value.(sonyflake.ID)), buf)
+}
+
+func tryWrapSonyflakeIDScanPlan(target interface{}) (pgtype.WrappedScanPlanNextSetter, interface{}, bool) {
+	if id, ok := target.(*sonyflake.ID); ok {
+		return &sonyflakeIDScanPlan{}, (*ID)(id), true
+	}
+	return nil, nil, false
+}
+
+// sonyflakeIDScanPlan scans into a sonyflake.ID by the plan of ID.
+type sonyflakeIDScanPlan struct {
+	next pgtype.ScanPlan
+}
+
+func (p *sonyflakeIDScanPlan) SetNext(next pgtype.ScanPlan) {
+	p.next = next
+}
+
+func (p *sonyflakeIDScanPlan) Scan(src []byte, target interface{}) error {
+	return p.next.Scan(src, (*ID)(target.(*sonyflake.ID)))
+}
+
+// AfterConnect calls Register on the type map of the given connection.
+// It is usable as AfterConnect of pgxpool.Config.
+func AfterConnect(ctx context.Context, conn *pgx.Conn) error {
+	Register(conn.TypeMap())
+	return nil
+}
@@ -0,0 +1,101 @@
+package pgxid
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/sony/sonyflake"
+)
+
+func TestCodec(t *testing.T) {
+	m := pgtype.NewMap()
+	Register(m)
+
+	for _, format := range []int16{pgtype.BinaryFormatCode, pgtype.TextFormatCode} {
+		buf, err := m.Encode(pgtype.Int8OID, format, ID(123456789012345), nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		var id ID
+		if err := m.Scan(pgtype.Int8OID, format, buf, &id); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if id != 123456789012345 {
+			t.Errorf("unexpected id: %d", id)
+		}
+
+		var sid sonyflake.ID
+		if err := m.Scan(pgtype.Int8OID, format, buf, &sid); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if sid != 123456789012345 {
+			t.Errorf("unexpected id: %d", sid)
+		}
+	}
+
+	if _, err := m.Encode(pgtype.Int8OID, pgtype.BinaryFormatCode, ID(math.MaxInt64+1), nil); !errors.Is(err, sonyflake.ErrInvalidID) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := m.Encode(pgtype.Int8OID, pgtype.BinaryFormatCode, sonyflake.ID(math.MaxInt64+1), nil); !errors.Is(err, sonyflake.ErrInvalidID) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	buf, err := m.Encode(pgtype.Int8OID, pgtype.BinaryFormatCode, int64(-1), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var id ID
+	if err := m.Scan(pgtype.Int8OID, pgtype.BinaryFormatCode, buf, &id); !errors.Is(err, sonyflake.ErrInvalidID) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	var sid sonyflake.ID
+	if err := m.Scan(pgtype.Int8OID, pgtype.BinaryFormatCode, buf, &sid); !errors.Is(err, sonyflake.ErrInvalidID) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestString(t *testing.T) {
+	if s := ID(123456789012345).String(); s != sonyflake.ID(123456789012345).String() {
+		t.Errorf("unexpected string: %s", s)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	m := pgtype.NewMap()
+	Register(m)
+
+	for _, v := range []interface{}{ID(1), sonyflake.ID(1), []ID{1}, []sonyflake.ID{1}} {
+		if _, ok := m.TypeForValue(v); !ok {
+			t.Errorf("%T not registered", v)
+		}
+	}
+}
+
+func TestSQL(t *testing.T) {
+	v, err := ID(42).Value()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if v != int64(42) {
+		t.Errorf("unexpected value: %v", v)
+	}
+
+	var id ID
+	if err := id.Scan(int64(42)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if id != 42 {
+		t.Errorf("unexpected id: %d", id)
+	}
+	if err := id.Scan("42"); !errors.Is(err, sonyflake.ErrInvalidID) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := ID(math.MaxInt64 + 1).Value(); !errors.Is(err, sonyflake.ErrInvalidID) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
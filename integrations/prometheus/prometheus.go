@@ -0,0 +1,206 @@
+// Package prometheus provides Prometheus collectors of the metrics of Sonyflake and of machine ID coordination,
+// since exhausted sequence numbers and silent churn of leases are invisible otherwise.
+package prometheus
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/sony/sonyflake"
+	"github.com/sony/sonyflake/coordinator"
+)
+
+var (
+	generatedDesc = prometheus.NewDesc(
+		"sonyflake_ids_generated_total",
+		"Number of IDs generated by NextID.",
+		nil, nil)
+	exhaustedDesc = prometheus.NewDesc(
+		"sonyflake_sequence_exhausted_total",
+		"Number of times NextID ran out of sequence numbers in a time unit and waited for the next one.",
+		nil, nil)
+	ticksDesc = prometheus.NewDesc(
+		"sonyflake_ticks_total",
+		"Number of time units in which IDs were generated.",
+		nil, nil)
+	utilizationDesc = prometheus.NewDesc(
+		"sonyflake_sequence_utilization_ratio",
+		"Fraction of the sequence numbers consumed per time unit, averaged over the recent time units.",
+		nil, nil)
+	sleepDesc = prometheus.NewDesc(
+		"sonyflake_sleep_duration_seconds",
+		"Time for which NextID slept after running out of sequence numbers.",
+		nil, nil)
+
+	allocatedDesc = prometheus.NewDesc(
+		"sonyflake_coordinator_allocated_machine_ids",
+		"Number of machine IDs allocated by the coordinator, by state: leased, reserved or quarantined.",
+		[]string{"state"}, nil)
+	grantedDesc = prometheus.NewDesc(
+		"sonyflake_coordinator_leases_granted_total",
+		"Number of leases granted by the coordinator.",
+		nil, nil)
+	renewedDesc = prometheus.NewDesc(
+		"sonyflake_coordinator_leases_renewed_total",
+		"Number of leases renewed by the coordinator.",
+		nil, nil)
+	renewFailuresDesc = prometheus.NewDesc(
+		"sonyflake_coordinator_lease_renew_failures_total",
+		"Number of attempts to renew leases which had been lost.",
+		nil, nil)
+	releasedDesc = prometheus.NewDesc(
+		"sonyflake_coordinator_leases_released_total",
+		"Number of leases released, by their owners or forcibly by operators.",
+		[]string{"by"}, nil)
+	reclaimedDesc = prometheus.NewDesc(
+		"sonyflake_coordinator_leases_reclaimed_total",
+		"Number of machine IDs leased again after their previous leases expired without release.",
+		nil, nil)
+
+	leaseAgeDesc = prometheus.NewDesc(
+		"sonyflake_lease_age_seconds",
+		"Time since the lease of the machine ID was acquired.",
+		nil, nil)
+	leaseRenewalsDesc = prometheus.NewDesc(
+		"sonyflake_lease_renewals_total",
+		"Number of successful renewals of the lease.",
+		nil, nil)
+	leaseRenewFailuresDesc = prometheus.NewDesc(
+		"sonyflake_lease_renew_failures_total",
+		"Number of failed renewals of the lease.",
+		nil, nil)
+	leaseRenewLatencyDesc = prometheus.NewDesc(
+		"sonyflake_lease_renew_duration_seconds",
+		"Time taken by renewals of the lease.",
+		nil, nil)
+)
+
+// Collector is a prometheus.Collector of the metrics of a sonyflake.Sonyflake.
+type Collector struct {
+	sf *sonyflake.Sonyflake
+}
+
+var _ prometheus.Collector = (*Collector)(nil)
+
+// NewCollector returns a new Collector of the given Sonyflake.
+func NewCollector(sf *sonyflake.Sonyflake) *Collector {
+	return &Collector{sf: sf}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- generatedDesc
+	ch <- exhaustedDesc
+	ch <- ticksDesc
+	ch <- utilizationDesc
+	ch <- sleepDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.sf.Stats()
+	ch <- prometheus.MustNewConstMetric(generatedDesc, prometheus.CounterValue, float64(stats.Generated))
+	ch <- prometheus.MustNewConstMetric(exhaustedDesc, prometheus.CounterValue, float64(stats.Exhausted))
+	ch <- prometheus.MustNewConstMetric(ticksDesc, prometheus.CounterValue, float64(stats.Ticks))
+	ch <- prometheus.MustNewConstMetric(utilizationDesc, prometheus.GaugeValue, stats.Utilization)
+
+	buckets := make(map[float64]uint64, len(sonyflake.SleepBuckets))
+	var count uint64
+	for i, bound := range sonyflake.SleepBuckets {
+		count += stats.Sleeps[i]
+		buckets[bound.Seconds()] = count
+	}
+	count += stats.Sleeps[len(sonyflake.SleepBuckets)]
+	ch <- prometheus.MustNewConstHistogram(sleepDesc, count, stats.SleepTime.Seconds(), buckets)
+}
+
+// CoordinatorCollector is a prometheus.Collector of the metrics of a coordinator.Coordinator.
+type CoordinatorCollector struct {
+	c *coordinator.Coordinator
+}
+
+var _ prometheus.Collector = (*CoordinatorCollector)(nil)
+
+// NewCoordinatorCollector returns a new CoordinatorCollector of the given Coordinator.
+func NewCoordinatorCollector(c *coordinator.Coordinator) *CoordinatorCollector {
+	return &CoordinatorCollector{c: c}
+}
+
+// Describe implements prometheus.Collector.
+func (cc *CoordinatorCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- allocatedDesc
+	ch <- grantedDesc
+	ch <- renewedDesc
+	ch <- renewFailuresDesc
+	ch <- releasedDesc
+	ch <- reclaimedDesc
+}
+
+// Collect implements prometheus.Collector.
+// The allocated machine IDs are collected only by the leader among replicas of the coordinator.
+func (cc *CoordinatorCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := cc.c.Stats()
+	ch <- prometheus.MustNewConstMetric(grantedDesc, prometheus.CounterValue, float64(stats.Granted))
+	ch <- prometheus.MustNewConstMetric(renewedDesc, prometheus.CounterValue, float64(stats.Renewed))
+	ch <- prometheus.MustNewConstMetric(renewFailuresDesc, prometheus.CounterValue, float64(stats.RenewFailures))
+	ch <- prometheus.MustNewConstMetric(releasedDesc, prometheus.CounterValue, float64(stats.Released), "owner")
+	ch <- prometheus.MustNewConstMetric(releasedDesc, prometheus.CounterValue, float64(stats.ForceReleased), "operator")
+	ch <- prometheus.MustNewConstMetric(reclaimedDesc, prometheus.CounterValue, float64(stats.Reclaimed))
+
+	list, err := cc.c.Allocations(context.Background())
+	if err != nil {
+		if err != coordinator.ErrNotLeader {
+			ch <- prometheus.NewInvalidMetric(allocatedDesc, err)
+		}
+		return
+	}
+	var leased, reserved, quarantined int
+	for _, a := range list {
+		switch {
+		case a.Reserved:
+			reserved++
+		case a.Quarantined:
+			quarantined++
+		default:
+			leased++
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(allocatedDesc, prometheus.GaugeValue, float64(leased), "leased")
+	ch <- prometheus.MustNewConstMetric(allocatedDesc, prometheus.GaugeValue, float64(reserved), "reserved")
+	ch <- prometheus.MustNewConstMetric(allocatedDesc, prometheus.GaugeValue, float64(quarantined), "quarantined")
+}
+
+// LeaseCollector is a prometheus.Collector of the metrics of a coordinator.Lease.
+type LeaseCollector struct {
+	l *coordinator.Lease
+}
+
+var _ prometheus.Collector = (*LeaseCollector)(nil)
+
+// NewLeaseCollector returns a new LeaseCollector of the given Lease.
+func NewLeaseCollector(l *coordinator.Lease) *LeaseCollector {
+	return &LeaseCollector{l: l}
+}
+
+// Describe implements prometheus.Collector.
+func (lc *LeaseCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- leaseAgeDesc
+	ch <- leaseRenewalsDesc
+	ch <- leaseRenewFailuresDesc
+	ch <- leaseRenewLatencyDesc
+}
+
+// Collect implements prometheus.Collector.
+// The age of the lease is collected only while the lease is acquired.
+func (lc *LeaseCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := lc.l.Stats()
+	if !stats.AcquiredAt.IsZero() {
+		ch <- prometheus.MustNewConstMetric(leaseAgeDesc, prometheus.GaugeValue, time.Since(stats.AcquiredAt).Seconds())
+	}
+	ch <- prometheus.MustNewConstMetric(leaseRenewalsDesc, prometheus.CounterValue, float64(stats.Renewals))
+	ch <- prometheus.MustNewConstMetric(leaseRenewFailuresDesc, prometheus.CounterValue, float64(stats.RenewFailures))
+	ch <- prometheus.MustNewConstSummary(leaseRenewLatencyDesc,
+		stats.Renewals+stats.RenewFailures, stats.RenewLatency.Seconds(), nil)
+}
@@ -0,0 +1,115 @@
+package prometheus
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/sony/sonyflake"
+	"github.com/sony/sonyflake/coordinator"
+)
+
+func TestCollector(t *testing.T) {
+	sf, err := sonyflake.New(sonyflake.Settings{
+		StartTime:    time.Now(),
+		MachineID:    func() (uint16, error) { return 1, nil },
+		BitsSequence: 1,
+		TimeUnit:     time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := sf.NextID(); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	expected := `
+# HELP sonyflake_ids_generated_total Number of IDs generated by NextID.
+# TYPE sonyflake_ids_generated_total counter
+sonyflake_ids_generated_total 3
+`
+	err = testutil.CollectAndCompare(NewCollector(sf), strings.NewReader(expected), "sonyflake_ids_generated_total")
+	if err != nil {
+		t.Error(err)
+	}
+	if n := testutil.CollectAndCount(NewCollector(sf)); n != 5 {
+		t.Errorf("unexpected number of metrics: %d", n)
+	}
+	if problems, err := testutil.CollectAndLint(NewCollector(sf)); err != nil || len(problems) != 0 {
+		t.Errorf("unexpected lint: %v, %v", problems, err)
+	}
+}
+
+func TestCoordinatorCollector(t *testing.T) {
+	ctx := context.Background()
+	c, err := coordinator.New(coordinator.Settings{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	a, _ := c.Acquire(ctx, "a")
+	c.Acquire(ctx, "b")
+	c.Renew(ctx, a.MachineID, "a")
+	c.Release(ctx, a.MachineID, "a")
+	c.Reserve(ctx, 10, 11, "spare")
+
+	expected := `
+# HELP sonyflake_coordinator_allocated_machine_ids Number of machine IDs allocated by the coordinator, by state: leased, reserved or quarantined.
+# TYPE sonyflake_coordinator_allocated_machine_ids gauge
+sonyflake_coordinator_allocated_machine_ids{state="leased"} 1
+sonyflake_coordinator_allocated_machine_ids{state="quarantined"} 0
+sonyflake_coordinator_allocated_machine_ids{state="reserved"} 2
+# HELP sonyflake_coordinator_leases_granted_total Number of leases granted by the coordinator.
+# TYPE sonyflake_coordinator_leases_granted_total counter
+sonyflake_coordinator_leases_granted_total 2
+# HELP sonyflake_coordinator_leases_released_total Number of leases released, by their owners or forcibly by operators.
+# TYPE sonyflake_coordinator_leases_released_total counter
+sonyflake_coordinator_leases_released_total{by="operator"} 0
+sonyflake_coordinator_leases_released_total{by="owner"} 1
+# HELP sonyflake_coordinator_leases_renewed_total Number of leases renewed by the coordinator.
+# TYPE sonyflake_coordinator_leases_renewed_total counter
+sonyflake_coordinator_leases_renewed_total 1
+`
+	err = testutil.CollectAndCompare(NewCoordinatorCollector(c), strings.NewReader(expected),
+		"sonyflake_coordinator_allocated_machine_ids",
+		"sonyflake_coordinator_leases_granted_total",
+		"sonyflake_coordinator_leases_released_total",
+		"sonyflake_coordinator_leases_renewed_total")
+	if err != nil {
+		t.Error(err)
+	}
+	if problems, err := testutil.CollectAndLint(NewCoordinatorCollector(c)); err != nil || len(problems) != 0 {
+		t.Errorf("unexpected lint: %v, %v", problems, err)
+	}
+}
+
+func TestLeaseCollector(t *testing.T) {
+	c, err := coordinator.New(coordinator.Settings{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	ts := httptest.NewServer(coordinator.Handler(c))
+	defer ts.Close()
+
+	l := coordinator.NewLease(coordinator.Client{URL: ts.URL}, "a", nil)
+	defer l.Close()
+
+	lc := NewLeaseCollector(l)
+	if n := testutil.CollectAndCount(lc, "sonyflake_lease_age_seconds"); n != 0 {
+		t.Errorf("age of an unacquired lease must not be collected: %d", n)
+	}
+	if _, err := l.MachineID(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if n := testutil.CollectAndCount(lc); n != 4 {
+		t.Errorf("unexpected number of metrics: %d", n)
+	}
+	if problems, err := testutil.CollectAndLint(lc); err != nil || len(problems) != 0 {
+		t.Errorf("unexpected lint: %v, %v", problems, err)
+	}
+}
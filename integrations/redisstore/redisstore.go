@@ -0,0 +1,123 @@
+// Package redisstore provides a coordinator.Store in Redis,
+// which fits a coordinator on infrastructure already running Redis.
+package redisstore
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/sony/sonyflake/coordinator"
+)
+
+// DefaultKey is the key of the hash in which the allocations are stored by default.
+const DefaultKey = "sonyflake:allocations"
+
+// Store is a coordinator.Store keeping the allocations in a hash of Redis,
+// whose fields are the machine IDs in decimal and whose values are the allocations in JSON.
+//
+// Store is also a coordinator.Lock by the key of the hash suffixed with ":leader",
+// which holds the holder of the lock and expires with it.
+type Store struct {
+	client redis.UniversalClient
+	key    string
+}
+
+var (
+	_ coordinator.Store = (*Store)(nil)
+	_ coordinator.Lock  = (*Store)(nil)
+)
+
+// New returns a new Store in the hash of the given key.
+// If key is empty, DefaultKey is used.
+func New(client redis.UniversalClient, key string) *Store {
+	if key == "" {
+		key = DefaultKey
+	}
+	return &Store{client: client, key: key}
+}
+
+// List implements coordinator.Store.
+func (s *Store) List(ctx context.Context) ([]coordinator.Allocation, error) {
+	values, err := s.client.HVals(ctx, s.key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]coordinator.Allocation, len(values))
+	for i, v := range values {
+		if err := json.Unmarshal([]byte(v), &list[i]); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].MachineID < list[j].MachineID })
+	return list, nil
+}
+
+// Get implements coordinator.Store.
+func (s *Store) Get(ctx context.Context, machineID uint16) (coordinator.Allocation, bool, error) {
+	var a coordinator.Allocation
+	v, err := s.client.HGet(ctx, s.key, field(machineID)).Bytes()
+	if err == redis.Nil {
+		return a, false, nil
+	}
+	if err != nil {
+		return a, false, err
+	}
+	if err := json.Unmarshal(v, &a); err != nil {
+		return a, false, err
+	}
+	return a, true, nil
+}
+
+// Put implements coordinator.Store.
+func (s *Store) Put(ctx context.Context, a coordinator.Allocation) error {
+	v, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	return s.client.HSet(ctx, s.key, field(a.MachineID), v).Err()
+}
+
+// Delete implements coordinator.Store.
+func (s *Store) Delete(ctx context.Context, machineID uint16) error {
+	return s.client.HDel(ctx, s.key, field(machineID)).Err()
+}
+
+func field(machineID uint16) string {
+	return strconv.FormatUint(uint64(machineID), 10)
+}
+
+var tryLock = redis.NewScript(`
+local holder = redis.call("GET", KEYS[1])
+if not holder or holder == ARGV[1] then
+	redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+	return ARGV[1]
+end
+return holder
+`)
+
+var unlock = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// TryLock implements coordinator.Lock.
+func (s *Store) TryLock(ctx context.Context, holder string, ttl time.Duration) (string, error) {
+	ms := int64(ttl / time.Millisecond)
+	if ms < 1 {
+		ms = 1
+	}
+	return tryLock.Run(ctx, s.client, []string{s.key + ":leader"}, holder, ms).Text()
+}
+
+// Unlock implements coordinator.Lock.
+func (s *Store) Unlock(ctx context.Context, holder string) error {
+	return unlock.Run(ctx, s.client, []string{s.key + ":leader"}, holder).Err()
+}
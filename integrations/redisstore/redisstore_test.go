@@ -0,0 +1,59 @@
+package redisstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/sony/sonyflake/coordinator/storetest"
+)
+
+func TestStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	s := New(client, "")
+	storetest.Run(t, s)
+
+	n, err := client.HLen(context.Background(), DefaultKey).Result()
+	if err != nil || n != 2 {
+		t.Errorf("unexpected length of the hash: %d, %v", n, err)
+	}
+}
+
+func TestLock(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	s := New(client, "")
+	if holder, err := s.TryLock(ctx, "a", time.Second); err != nil || holder != "a" {
+		t.Fatalf("unexpected holder: %s, %v", holder, err)
+	}
+	if holder, err := s.TryLock(ctx, "b", time.Second); err != nil || holder != "a" {
+		t.Errorf("unexpected holder: %s, %v", holder, err)
+	}
+
+	mr.FastForward(time.Second)
+	if holder, err := s.TryLock(ctx, "b", time.Second); err != nil || holder != "b" {
+		t.Errorf("the expired lock must be taken over: %s, %v", holder, err)
+	}
+
+	if err := s.Unlock(ctx, "a"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if holder, err := s.TryLock(ctx, "a", time.Second); err != nil || holder != "b" {
+		t.Errorf("unlock by another holder must be ignored: %s, %v", holder, err)
+	}
+	if err := s.Unlock(ctx, "b"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if holder, err := s.TryLock(ctx, "a", time.Second); err != nil || holder != "a" {
+		t.Errorf("unexpected holder: %s, %v", holder, err)
+	}
+}
@@ -0,0 +1,7 @@
+module github.com/sony/sonyflake/integrations/slogtrace
+
+go 1.21
+
+require github.com/sony/sonyflake v1.0.0
+
+replace github.com/sony/sonyflake => ../..
@@ -0,0 +1,30 @@
+// Package slogtrace logs the trace events of Sonyflake by log/slog.
+package slogtrace
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/sony/sonyflake"
+)
+
+// Trace returns a function usable as Settings.Trace,
+// which logs each TraceEvent to the given logger at the given level, such as slog.LevelDebug.
+func Trace(logger *slog.Logger, level slog.Level) func(sonyflake.TraceEvent) {
+	return func(e sonyflake.TraceEvent) {
+		ctx := context.Background()
+		if !logger.Enabled(ctx, level) {
+			return
+		}
+
+		attrs := []slog.Attr{
+			slog.String("event", e.Kind.String()),
+			slog.Time("at", e.Time),
+			slog.Int64("elapsed_time", e.ElapsedTime),
+		}
+		if e.Kind != sonyflake.TraceRollover {
+			attrs = append(attrs, slog.Duration("duration", e.Duration))
+		}
+		logger.LogAttrs(ctx, level, "sonyflake trace", attrs...)
+	}
+}
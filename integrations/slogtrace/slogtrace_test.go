@@ -0,0 +1,47 @@
+package slogtrace
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sony/sonyflake"
+)
+
+func TestTrace(t *testing.T) {
+	var b bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&b, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	sf, err := sonyflake.New(sonyflake.Settings{
+		StartTime:    time.Now(),
+		MachineID:    func() (uint16, error) { return 1, nil },
+		BitsSequence: 1,
+		TimeUnit:     time.Millisecond,
+		Trace:        Trace(logger, slog.LevelDebug),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := sf.NextID(); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	out := b.String()
+	if !strings.Contains(out, "event=rollover") || !strings.Contains(out, "event=sleep") {
+		t.Errorf("unexpected log: %s", out)
+	}
+}
+
+func TestTraceDisabled(t *testing.T) {
+	var b bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&b, nil))
+
+	Trace(logger, slog.LevelDebug)(sonyflake.TraceEvent{Kind: sonyflake.TraceSleep})
+	if b.Len() != 0 {
+		t.Errorf("unexpected log: %s", b.String())
+	}
+}
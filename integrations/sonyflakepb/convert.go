@@ -0,0 +1,70 @@
+// Package sonyflakepb defines the canonical Protocol Buffers representation of Sonyflake IDs
+// in sonyflake.proto, along with conversion helpers.
+package sonyflakepb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative sonyflake.proto
+
+import (
+	"time"
+
+	"github.com/sony/sonyflake"
+)
+
+// FromID returns the SonyflakeID message of the given ID.
+func FromID(id uint64) *SonyflakeID {
+	return &SonyflakeID{Id: id}
+}
+
+// ToID returns the ID of the SonyflakeID message.
+func ToID(x *SonyflakeID) uint64 {
+	return x.GetId()
+}
+
+// FromLayout returns the Layout message of the given Layout.
+func FromLayout(layout sonyflake.Layout) *Layout {
+	return &Layout{
+		BitsTime:       uint32(layout.BitsTime),
+		BitsSequence:   uint32(layout.BitsSequence),
+		BitsNamespace:  uint32(layout.BitsNamespace),
+		BitsMachineId:  uint32(layout.BitsMachineID),
+		TimeUnit:       int64(layout.TimeUnit),
+		StartTime:      layout.StartTime.UnixNano(),
+		MachineIdFirst: layout.FieldOrder == sonyflake.FieldOrderMachineIDFirst,
+		UseMsb:         layout.UseMSB,
+	}
+}
+
+// ToLayout returns the Layout of the Layout message.
+// It returns the error of Layout.Validate if the message describes an invalid Layout.
+func ToLayout(x *Layout) (sonyflake.Layout, error) {
+	layout := sonyflake.Layout{
+		BitsTime:      int(x.GetBitsTime()),
+		BitsSequence:  int(x.GetBitsSequence()),
+		BitsNamespace: int(x.GetBitsNamespace()),
+		BitsMachineID: int(x.GetBitsMachineId()),
+		TimeUnit:      time.Duration(x.GetTimeUnit()),
+		StartTime:     time.Unix(0, x.GetStartTime()).UTC(),
+		UseMSB:        x.GetUseMsb(),
+	}
+	if x.GetMachineIdFirst() {
+		layout.FieldOrder = sonyflake.FieldOrderMachineIDFirst
+	}
+	if err := layout.Validate(); err != nil {
+		return sonyflake.Layout{}, err
+	}
+	return layout, nil
+}
+
+// Decompose returns the DecomposedID message of the given ID generated by the given Sonyflake.
+func Decompose(sf *sonyflake.Sonyflake, id uint64) *DecomposedID {
+	parts := sf.Decompose(id)
+	return &DecomposedID{
+		Id:          id,
+		Msb:         parts["msb"],
+		Time:        parts["time"],
+		Sequence:    parts["sequence"],
+		NamespaceId: parts["namespace"],
+		MachineId:   parts["machine-id"],
+		Layout:      FromLayout(sf.Layout()),
+	}
+}
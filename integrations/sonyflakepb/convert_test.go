@@ -0,0 +1,57 @@
+package sonyflakepb
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sony/sonyflake"
+)
+
+func TestID(t *testing.T) {
+	b, err := proto.Marshal(FromID(1 << 40))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var x SonyflakeID
+	if err := proto.Unmarshal(b, &x); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if ToID(&x) != 1<<40 {
+		t.Errorf("unexpected id: %d", ToID(&x))
+	}
+}
+
+func TestDecompose(t *testing.T) {
+	sf, err := sonyflake.New(sonyflake.Settings{
+		MachineID:  func() (uint16, error) { return 3, nil },
+		TimeUnit:   time.Millisecond,
+		FieldOrder: sonyflake.FieldOrderMachineIDFirst,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	id, err := sf.NextID()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	x := Decompose(sf, id)
+	if x.GetId() != id || x.GetMachineId() != 3 || x.GetSequence() != 0 {
+		t.Errorf("unexpected parts: %v", x)
+	}
+	layout, err := ToLayout(x.GetLayout())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if layout != sf.Layout() {
+		t.Errorf("unexpected layout: %+v", layout)
+	}
+
+	if _, err := ToLayout(&Layout{}); err != sonyflake.ErrInvalidTimeUnit {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
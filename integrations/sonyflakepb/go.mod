@@ -0,0 +1,10 @@
+module github.com/sony/sonyflake/integrations/sonyflakepb
+
+go 1.23
+
+require (
+	github.com/sony/sonyflake v1.0.0
+	google.golang.org/protobuf v1.36.12
+)
+
+replace github.com/sony/sonyflake => ../..
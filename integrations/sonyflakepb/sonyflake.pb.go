@@ -0,0 +1,345 @@
+// Canonical wire representation of Sonyflake IDs.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: sonyflake.proto
+
+package sonyflakepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// SonyflakeID is a Sonyflake ID.
+type SonyflakeID struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SonyflakeID) Reset() {
+	*x = SonyflakeID{}
+	mi := &file_sonyflake_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SonyflakeID) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SonyflakeID) ProtoMessage() {}
+
+func (x *SonyflakeID) ProtoReflect() protoreflect.Message {
+	mi := &file_sonyflake_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SonyflakeID.ProtoReflect.Descriptor instead.
+func (*SonyflakeID) Descriptor() ([]byte, []int) {
+	return file_sonyflake_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SonyflakeID) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+// Layout describes how Sonyflake IDs are composed.
+type Layout struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BitsTime      uint32                 `protobuf:"varint,1,opt,name=bits_time,json=bitsTime,proto3" json:"bits_time,omitempty"`
+	BitsSequence  uint32                 `protobuf:"varint,2,opt,name=bits_sequence,json=bitsSequence,proto3" json:"bits_sequence,omitempty"`
+	BitsNamespace uint32                 `protobuf:"varint,3,opt,name=bits_namespace,json=bitsNamespace,proto3" json:"bits_namespace,omitempty"`
+	BitsMachineId uint32                 `protobuf:"varint,4,opt,name=bits_machine_id,json=bitsMachineId,proto3" json:"bits_machine_id,omitempty"`
+	// The time unit in nanoseconds.
+	TimeUnit int64 `protobuf:"varint,5,opt,name=time_unit,json=timeUnit,proto3" json:"time_unit,omitempty"`
+	// The start time in nanoseconds since the Unix epoch.
+	StartTime int64 `protobuf:"varint,6,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	// Whether the machine ID is placed above the sequence number.
+	MachineIdFirst bool `protobuf:"varint,7,opt,name=machine_id_first,json=machineIdFirst,proto3" json:"machine_id_first,omitempty"`
+	// Whether the most significant bit is used for time.
+	UseMsb        bool `protobuf:"varint,8,opt,name=use_msb,json=useMsb,proto3" json:"use_msb,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Layout) Reset() {
+	*x = Layout{}
+	mi := &file_sonyflake_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Layout) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Layout) ProtoMessage() {}
+
+func (x *Layout) ProtoReflect() protoreflect.Message {
+	mi := &file_sonyflake_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Layout.ProtoReflect.Descriptor instead.
+func (*Layout) Descriptor() ([]byte, []int) {
+	return file_sonyflake_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Layout) GetBitsTime() uint32 {
+	if x != nil {
+		return x.BitsTime
+	}
+	return 0
+}
+
+func (x *Layout) GetBitsSequence() uint32 {
+	if x != nil {
+		return x.BitsSequence
+	}
+	return 0
+}
+
+func (x *Layout) GetBitsNamespace() uint32 {
+	if x != nil {
+		return x.BitsNamespace
+	}
+	return 0
+}
+
+func (x *Layout) GetBitsMachineId() uint32 {
+	if x != nil {
+		return x.BitsMachineId
+	}
+	return 0
+}
+
+func (x *Layout) GetTimeUnit() int64 {
+	if x != nil {
+		return x.TimeUnit
+	}
+	return 0
+}
+
+func (x *Layout) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *Layout) GetMachineIdFirst() bool {
+	if x != nil {
+		return x.MachineIdFirst
+	}
+	return false
+}
+
+func (x *Layout) GetUseMsb() bool {
+	if x != nil {
+		return x.UseMsb
+	}
+	return false
+}
+
+// DecomposedID is a Sonyflake ID with its parts and the layout it was composed in.
+type DecomposedID struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Msb           uint64                 `protobuf:"varint,2,opt,name=msb,proto3" json:"msb,omitempty"`
+	Time          uint64                 `protobuf:"varint,3,opt,name=time,proto3" json:"time,omitempty"`
+	Sequence      uint64                 `protobuf:"varint,4,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	NamespaceId   uint64                 `protobuf:"varint,5,opt,name=namespace_id,json=namespaceId,proto3" json:"namespace_id,omitempty"`
+	MachineId     uint64                 `protobuf:"varint,6,opt,name=machine_id,json=machineId,proto3" json:"machine_id,omitempty"`
+	Layout        *Layout                `protobuf:"bytes,7,opt,name=layout,proto3" json:"layout,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DecomposedID) Reset() {
+	*x = DecomposedID{}
+	mi := &file_sonyflake_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DecomposedID) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DecomposedID) ProtoMessage() {}
+
+func (x *DecomposedID) ProtoReflect() protoreflect.Message {
+	mi := &file_sonyflake_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DecomposedID.ProtoReflect.Descriptor instead.
+func (*DecomposedID) Descriptor() ([]byte, []int) {
+	return file_sonyflake_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *DecomposedID) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *DecomposedID) GetMsb() uint64 {
+	if x != nil {
+		return x.Msb
+	}
+	return 0
+}
+
+func (x *DecomposedID) GetTime() uint64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *DecomposedID) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *DecomposedID) GetNamespaceId() uint64 {
+	if x != nil {
+		return x.NamespaceId
+	}
+	return 0
+}
+
+func (x *DecomposedID) GetMachineId() uint64 {
+	if x != nil {
+		return x.MachineId
+	}
+	return 0
+}
+
+func (x *DecomposedID) GetLayout() *Layout {
+	if x != nil {
+		return x.Layout
+	}
+	return nil
+}
+
+var File_sonyflake_proto protoreflect.FileDescriptor
+
+const file_sonyflake_proto_rawDesc = "" +
+	"\n" +
+	"\x0fsonyflake.proto\x12\fsonyflake.v1\"\x1d\n" +
+	"\vSonyflakeID\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\"\x98\x02\n" +
+	"\x06Layout\x12\x1b\n" +
+	"\tbits_time\x18\x01 \x01(\rR\bbitsTime\x12#\n" +
+	"\rbits_sequence\x18\x02 \x01(\rR\fbitsSequence\x12%\n" +
+	"\x0ebits_namespace\x18\x03 \x01(\rR\rbitsNamespace\x12&\n" +
+	"\x0fbits_machine_id\x18\x04 \x01(\rR\rbitsMachineId\x12\x1b\n" +
+	"\ttime_unit\x18\x05 \x01(\x03R\btimeUnit\x12\x1d\n" +
+	"\n" +
+	"start_time\x18\x06 \x01(\x03R\tstartTime\x12(\n" +
+	"\x10machine_id_first\x18\a \x01(\bR\x0emachineIdFirst\x12\x17\n" +
+	"\ause_msb\x18\b \x01(\bR\x06useMsb\"\xd0\x01\n" +
+	"\fDecomposedID\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x10\n" +
+	"\x03msb\x18\x02 \x01(\x04R\x03msb\x12\x12\n" +
+	"\x04time\x18\x03 \x01(\x04R\x04time\x12\x1a\n" +
+	"\bsequence\x18\x04 \x01(\x04R\bsequence\x12!\n" +
+	"\fnamespace_id\x18\x05 \x01(\x04R\vnamespaceId\x12\x1d\n" +
+	"\n" +
+	"machine_id\x18\x06 \x01(\x04R\tmachineId\x12,\n" +
+	"\x06layout\x18\a \x01(\v2\x14.sonyflake.v1.LayoutR\x06layoutB4Z2github.com/sony/sonyflake/integrations/sonyflakepbb\x06proto3"
+
+var (
+	file_sonyflake_proto_rawDescOnce sync.Once
+	file_sonyflake_proto_rawDescData []byte
+)
+
+func file_sonyflake_proto_rawDescGZIP() []byte {
+	file_sonyflake_proto_rawDescOnce.Do(func() {
+		file_sonyflake_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_sonyflake_proto_rawDesc), len(file_sonyflake_proto_rawDesc)))
+	})
+	return file_sonyflake_proto_rawDescData
+}
+
+var file_sonyflake_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_sonyflake_proto_goTypes = []any{
+	(*SonyflakeID)(nil),  // 0: sonyflake.v1.SonyflakeID
+	(*Layout)(nil),       // 1: sonyflake.v1.Layout
+	(*DecomposedID)(nil), // 2: sonyflake.v1.DecomposedID
+}
+var file_sonyflake_proto_depIdxs = []int32{
+	1, // 0: sonyflake.v1.DecomposedID.layout:type_name -> sonyflake.v1.Layout
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_sonyflake_proto_init() }
+func file_sonyflake_proto_init() {
+	if File_sonyflake_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_sonyflake_proto_rawDesc), len(file_sonyflake_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_sonyflake_proto_goTypes,
+		DependencyIndexes: file_sonyflake_proto_depIdxs,
+		MessageInfos:      file_sonyflake_proto_msgTypes,
+	}.Build()
+	File_sonyflake_proto = out.File
+	file_sonyflake_proto_goTypes = nil
+	file_sonyflake_proto_depIdxs = nil
+}
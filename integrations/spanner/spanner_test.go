@@ -0,0 +1,43 @@
+package spanner
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+
+	"github.com/sony/sonyflake"
+)
+
+func TestID(t *testing.T) {
+	row, err := spanner.NewRow([]string{"id", "plain"}, []interface{}{ID(123456789012345), int64(123456789012345)})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var id ID
+	var plain int64
+	if err := row.Columns(&id, &plain); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if id != 123456789012345 || plain != 123456789012345 {
+		t.Errorf("unexpected values: %d, %d", id, plain)
+	}
+
+	if _, err := spanner.NewRow([]string{"id"}, []interface{}{ID(math.MaxInt64 + 1)}); !errors.Is(err, sonyflake.ErrInvalidID) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	row, err = spanner.NewRow([]string{"id"}, []interface{}{int64(-1)})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := row.Column(0, &id); err == nil {
+		t.Error("negative value must be rejected")
+	}
+	// Row does not wrap the errors of decoders.
+	if err := id.DecodeSpanner("-1"); !errors.Is(err, sonyflake.ErrInvalidID) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,268 @@
+// Package sqlstore provides a coordinator.Store in a SQL database by database/sql,
+// which fits a coordinator on infrastructure already running a relational database.
+//
+// The queries are portable among PostgreSQL, MySQL and SQLite.
+// The Store needs a table created by CreateTable or by a migration such as:
+//
+//	CREATE TABLE sonyflake_allocations (
+//		machine_id INTEGER PRIMARY KEY,
+//		owner VARCHAR(255) NOT NULL,
+//		expires_at BIGINT NOT NULL,
+//		reserved SMALLINT NOT NULL,
+//		released SMALLINT NOT NULL
+//	)
+//
+// The column expires_at is in Unix nanoseconds, and 0 for reservations.
+//
+// The Store is also a coordinator.Lock by a row of another table, named after the table of the allocations:
+//
+//	CREATE TABLE sonyflake_leader (
+//		name VARCHAR(255) PRIMARY KEY,
+//		holder VARCHAR(255) NOT NULL,
+//		expires_at BIGINT NOT NULL
+//	)
+//
+// The expiration of the lock is decided by the clocks of the replicas, which should be synchronized.
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sony/sonyflake/coordinator"
+)
+
+// Settings configures Store:
+//
+// Table is the name of the table in which the allocations are stored.
+// If Table is empty, it is "sonyflake_allocations".
+//
+// LockTable is the name of the table in which the lock of the leader is stored.
+// If LockTable is empty, it is "sonyflake_leader".
+//
+// DollarPlaceholders selects the placeholders $1, $2, ... of PostgreSQL instead of ?.
+type Settings struct {
+	Table              string
+	LockTable          string
+	DollarPlaceholders bool
+}
+
+const (
+	defaultTable     = "sonyflake_allocations"
+	defaultLockTable = "sonyflake_leader"
+)
+
+// Store is a coordinator.Store keeping the allocations in a table of a SQL database.
+type Store struct {
+	db  *sql.DB
+	st  Settings
+	now func() time.Time
+}
+
+var (
+	_ coordinator.Store = (*Store)(nil)
+	_ coordinator.Lock  = (*Store)(nil)
+)
+
+// New returns a new Store in db configured with the given Settings.
+func New(db *sql.DB, st Settings) *Store {
+	if st.Table == "" {
+		st.Table = defaultTable
+	}
+	if st.LockTable == "" {
+		st.LockTable = defaultLockTable
+	}
+	return &Store{db: db, st: st, now: time.Now}
+}
+
+// query returns the given query with the table name and the placeholders of the Settings.
+func (s *Store) query(q string) string {
+	q = strings.Replace(q, "{table}", s.st.Table, -1)
+	q = strings.Replace(q, "{lock_table}", s.st.LockTable, -1)
+	if !s.st.DollarPlaceholders {
+		return q
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range q {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// CreateTable creates the tables of the Store if they do not exist.
+func (s *Store) CreateTable(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, s.query(`CREATE TABLE IF NOT EXISTS {table} (
+	machine_id INTEGER PRIMARY KEY,
+	owner VARCHAR(255) NOT NULL,
+	expires_at BIGINT NOT NULL,
+	reserved SMALLINT NOT NULL,
+	released SMALLINT NOT NULL
+)`))
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, s.query(`CREATE TABLE IF NOT EXISTS {lock_table} (
+	name VARCHAR(255) PRIMARY KEY,
+	holder VARCHAR(255) NOT NULL,
+	expires_at BIGINT NOT NULL
+)`))
+	return err
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scan(row scanner) (coordinator.Allocation, error) {
+	var (
+		a         coordinator.Allocation
+		expiresAt int64
+		reserved  int
+		released  int
+	)
+	if err := row.Scan(&a.MachineID, &a.Owner, &expiresAt, &reserved, &released); err != nil {
+		return a, err
+	}
+	if expiresAt != 0 {
+		a.ExpiresAt = time.Unix(0, expiresAt).UTC()
+	}
+	a.Reserved = reserved != 0
+	a.Released = released != 0
+	return a, nil
+}
+
+// List implements coordinator.Store.
+func (s *Store) List(ctx context.Context) ([]coordinator.Allocation, error) {
+	rows, err := s.db.QueryContext(ctx, s.query(
+		"SELECT machine_id, owner, expires_at, reserved, released FROM {table} ORDER BY machine_id"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []coordinator.Allocation
+	for rows.Next() {
+		a, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+// Get implements coordinator.Store.
+func (s *Store) Get(ctx context.Context, machineID uint16) (coordinator.Allocation, bool, error) {
+	row := s.db.QueryRowContext(ctx, s.query(
+		"SELECT machine_id, owner, expires_at, reserved, released FROM {table} WHERE machine_id = ?"), machineID)
+	a, err := scan(row)
+	if err == sql.ErrNoRows {
+		return a, false, nil
+	}
+	if err != nil {
+		return a, false, err
+	}
+	return a, true, nil
+}
+
+// Put implements coordinator.Store.
+// Put replaces the allocation by a delete and an insert in a transaction,
+// since the syntax of upserts differs among databases.
+func (s *Store) Put(ctx context.Context, a coordinator.Allocation) error {
+	var expiresAt int64
+	if !a.ExpiresAt.IsZero() {
+		expiresAt = a.ExpiresAt.UnixNano()
+	}
+	var reserved, released int
+	if a.Reserved {
+		reserved = 1
+	}
+	if a.Released {
+		released = 1
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, s.query("DELETE FROM {table} WHERE machine_id = ?"), a.MachineID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, s.query(
+		"INSERT INTO {table} (machine_id, owner, expires_at, reserved, released) VALUES (?, ?, ?, ?, ?)"),
+		a.MachineID, a.Owner, expiresAt, reserved, released)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Delete implements coordinator.Store.
+func (s *Store) Delete(ctx context.Context, machineID uint16) error {
+	_, err := s.db.ExecContext(ctx, s.query("DELETE FROM {table} WHERE machine_id = ?"), machineID)
+	return err
+}
+
+// TryLock implements coordinator.Lock.
+// TryLock takes over the lock by an update conditioned on the current row, so that only one replica wins.
+func (s *Store) TryLock(ctx context.Context, holder string, ttl time.Duration) (string, error) {
+	now := s.now().UnixNano()
+	expiresAt := now + int64(ttl)
+
+	var current string
+	var currentExpiresAt int64
+	err := s.db.QueryRowContext(ctx, s.query("SELECT holder, expires_at FROM {lock_table} WHERE name = ?"),
+		s.st.Table).Scan(&current, &currentExpiresAt)
+	if err == sql.ErrNoRows {
+		_, err = s.db.ExecContext(ctx, s.query("INSERT INTO {lock_table} (name, holder, expires_at) VALUES (?, ?, ?)"),
+			s.st.Table, holder, expiresAt)
+		if err != nil {
+			return "", err
+		}
+		return holder, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if current != holder && now < currentExpiresAt {
+		return current, nil
+	}
+
+	res, err := s.db.ExecContext(ctx, s.query(
+		"UPDATE {lock_table} SET holder = ?, expires_at = ? WHERE name = ? AND holder = ? AND expires_at = ?"),
+		holder, expiresAt, s.st.Table, current, currentExpiresAt)
+	if err != nil {
+		return "", err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return "", err
+	}
+	if n == 0 {
+		// another replica has taken over the lock in between
+		err := s.db.QueryRowContext(ctx, s.query("SELECT holder FROM {lock_table} WHERE name = ?"),
+			s.st.Table).Scan(&current)
+		return current, err
+	}
+	return holder, nil
+}
+
+// Unlock implements coordinator.Lock.
+func (s *Store) Unlock(ctx context.Context, holder string) error {
+	_, err := s.db.ExecContext(ctx, s.query("DELETE FROM {lock_table} WHERE name = ? AND holder = ?"),
+		s.st.Table, holder)
+	return err
+}
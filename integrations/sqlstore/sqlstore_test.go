@@ -0,0 +1,88 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	_ "github.com/glebarez/go-sqlite"
+
+	"github.com/sony/sonyflake/coordinator/storetest"
+)
+
+func TestStore(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer db.Close()
+	// each connection to :memory: opens a different database
+	db.SetMaxOpenConns(1)
+
+	s := New(db, Settings{Table: "allocations"})
+	if err := s.CreateTable(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := s.CreateTable(context.Background()); err != nil {
+		t.Errorf("creating an existing table must succeed: %s", err)
+	}
+	storetest.Run(t, s)
+}
+
+func TestQuery(t *testing.T) {
+	s := New(nil, Settings{DollarPlaceholders: true})
+	q := s.query("INSERT INTO {table} (machine_id, owner) VALUES (?, ?)")
+	if q != "INSERT INTO sonyflake_allocations (machine_id, owner) VALUES ($1, $2)" {
+		t.Errorf("unexpected query: %s", q)
+	}
+}
+
+func TestLock(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := New(db, Settings{})
+	s.now = func() time.Time { return now }
+	if err := s.CreateTable(ctx); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if holder, err := s.TryLock(ctx, "a", time.Second); err != nil || holder != "a" {
+		t.Fatalf("unexpected holder: %s, %v", holder, err)
+	}
+	now = now.Add(500 * time.Millisecond)
+	if holder, err := s.TryLock(ctx, "b", time.Second); err != nil || holder != "a" {
+		t.Errorf("unexpected holder: %s, %v", holder, err)
+	}
+	if holder, err := s.TryLock(ctx, "a", time.Second); err != nil || holder != "a" {
+		t.Errorf("unexpected holder: %s, %v", holder, err)
+	}
+	now = now.Add(700 * time.Millisecond)
+	if holder, err := s.TryLock(ctx, "b", time.Second); err != nil || holder != "a" {
+		t.Errorf("the lock must be extended: %s, %v", holder, err)
+	}
+	now = now.Add(300 * time.Millisecond)
+	if holder, err := s.TryLock(ctx, "b", time.Second); err != nil || holder != "b" {
+		t.Errorf("the expired lock must be taken over: %s, %v", holder, err)
+	}
+
+	if err := s.Unlock(ctx, "a"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if holder, err := s.TryLock(ctx, "a", time.Second); err != nil || holder != "b" {
+		t.Errorf("unlock by another holder must be ignored: %s, %v", holder, err)
+	}
+	if err := s.Unlock(ctx, "b"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if holder, err := s.TryLock(ctx, "a", time.Second); err != nil || holder != "a" {
+		t.Errorf("unexpected holder: %s, %v", holder, err)
+	}
+}
@@ -0,0 +1,302 @@
+package sonyflake
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrInvalidLayout is returned by NewFromLayoutJSON if the descriptor lacks a part of the layout.
+var ErrInvalidLayout = errors.New("invalid layout")
+
+// Layout describes how the IDs generated by a Sonyflake are composed.
+type Layout struct {
+	BitsTime      int
+	BitsSequence  int
+	BitsNamespace int
+	BitsMachineID int
+	TimeUnit      time.Duration
+	StartTime     time.Time
+	FieldOrder    FieldOrder
+	UseMSB        bool
+}
+
+// Layout returns the Layout of the IDs generated by the Sonyflake.
+func (sf *Sonyflake) Layout() Layout {
+	return Layout{
+		BitsTime:      sf.bitsTime,
+		BitsSequence:  sf.bitsSequence,
+		BitsNamespace: sf.bitsNamespace,
+		BitsMachineID: sf.bitsMachineID,
+		TimeUnit:      time.Duration(sf.timeUnit),
+		StartTime:     time.Unix(0, sf.startTime*sf.timeUnit).UTC(),
+		FieldOrder:    sf.fieldOrder,
+		UseMSB:        sf.useMSB,
+	}
+}
+
+// Layout returns the Layout of the IDs which a Sonyflake configured with the Settings would generate,
+// without resolving the machine ID.
+// Layout returns an error if Validate returns an error.
+func (st Settings) Layout() (Layout, error) {
+	if err := st.Validate(); err != nil {
+		return Layout{}, err
+	}
+
+	timeUnit := st.TimeUnit
+	if timeUnit == 0 {
+		timeUnit = sonyflakeTimeUnit
+	}
+	startTime := st.StartTime
+	if startTime.IsZero() {
+		startTime = time.Date(2014, 9, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return Layout{
+		BitsTime:      st.bitsTime(),
+		BitsSequence:  st.bitsSequence(),
+		BitsNamespace: st.BitsNamespace,
+		BitsMachineID: st.bitsMachineID(),
+		TimeUnit:      timeUnit,
+		StartTime:     time.Unix(0, startTime.UnixNano()/int64(timeUnit)*int64(timeUnit)).UTC(),
+		FieldOrder:    st.FieldOrder,
+		UseMSB:        st.UseMSB,
+	}, nil
+}
+
+// Validate returns an error if the Layout is inconsistent, such as one decoded from a malformed descriptor:
+// - TimeUnit is not positive.
+// - BitsSequence, BitsNamespace or BitsMachineID is out of range.
+// - BitsTime is not positive, or the bit lengths add up to more than 63, or 64 if UseMSB is true.
+// - FieldOrder is unknown.
+func (l Layout) Validate() error {
+	if l.TimeUnit <= 0 {
+		return ErrInvalidTimeUnit
+	}
+	if l.BitsTime <= 0 || l.BitsTime > 64 {
+		return ErrInvalidBitsTime
+	}
+	if l.BitsSequence < 0 || l.BitsSequence > 63 {
+		return ErrInvalidBitsSequence
+	}
+	if l.BitsNamespace < 0 || l.BitsNamespace > 16 {
+		return ErrInvalidBitsNamespace
+	}
+	if l.BitsMachineID < 0 || l.BitsMachineID > BitLenMachineID {
+		return ErrInvalidBitsMachineID
+	}
+	bits := 63
+	if l.UseMSB {
+		bits = 64
+	}
+	if l.BitsTime+l.BitsSequence+l.BitsNamespace+l.BitsMachineID > bits {
+		return ErrInvalidBitsTime
+	}
+	if _, ok := fieldOrderNames[l.FieldOrder]; !ok {
+		return ErrInvalidFieldOrder
+	}
+	return nil
+}
+
+func (l Layout) shifts() (shiftTime, shiftSequence, shiftNamespace, shiftMachineID int) {
+	switch l.FieldOrder {
+	case FieldOrderMachineIDFirst:
+		shiftSequence = 0
+		shiftMachineID = l.BitsSequence
+		shiftNamespace = shiftMachineID + l.BitsMachineID
+	default:
+		shiftMachineID = 0
+		shiftNamespace = l.BitsMachineID
+		shiftSequence = shiftNamespace + l.BitsNamespace
+	}
+	shiftTime = l.BitsSequence + l.BitsNamespace + l.BitsMachineID
+	return
+}
+
+func (l Layout) compose(elapsedTime, sequence, namespaceID, machineID uint64) uint64 {
+	shiftTime, shiftSequence, shiftNamespace, shiftMachineID := l.shifts()
+	return elapsedTime<<shiftTime |
+		sequence<<shiftSequence |
+		namespaceID<<shiftNamespace |
+		machineID<<shiftMachineID
+}
+
+// Decompose returns a set of parts of the given ID in the Layout,
+// in the same form as the method Decompose of Sonyflake.
+func (l Layout) Decompose(id uint64) map[string]uint64 {
+	shiftTime, shiftSequence, shiftNamespace, shiftMachineID := l.shifts()
+	return map[string]uint64{
+		"id":         id,
+		"msb":        id >> 63,
+		"time":       id >> shiftTime & (1<<l.BitsTime - 1),
+		"sequence":   id >> shiftSequence & (1<<l.BitsSequence - 1),
+		"namespace":  id >> shiftNamespace & (1<<l.BitsNamespace - 1),
+		"machine-id": id >> shiftMachineID & (1<<l.BitsMachineID - 1),
+	}
+}
+
+// ValidateID returns ErrInvalidID if the given ID cannot have been generated in the Layout,
+// that is, if its most significant bit is set though the Layout does not use it,
+// such as for a corrupted ID or an ID of another format, which Decompose would split into nonsense.
+func (l Layout) ValidateID(id uint64) error {
+	if !l.UseMSB && id>>63 != 0 {
+		return fmt.Errorf("%w: most significant bit is set", ErrInvalidID)
+	}
+	return nil
+}
+
+// Time returns the time when the given ID in the Layout was generated, in the location of StartTime.
+// IDs carry no precision below TimeUnit, so the time is truncated to the start of the time unit
+// in which the ID was generated, that is, it is up to TimeUnit earlier than the actual generation.
+func (l Layout) Time(id uint64) time.Time {
+	shiftTime, _, _, _ := l.shifts()
+	elapsedTime := int64(id >> shiftTime & (1<<l.BitsTime - 1))
+
+	// The elapsed time of long lifetimes may overflow time.Duration, so it is added in chunks.
+	t := l.StartTime
+	chunk := int64(math.MaxInt64 / l.TimeUnit)
+	for ; elapsedTime > chunk; elapsedTime -= chunk {
+		t = t.Add(time.Duration(chunk) * l.TimeUnit)
+	}
+	return t.Add(time.Duration(elapsedTime) * l.TimeUnit)
+}
+
+// TimeIn returns the time when the given ID in the Layout was generated, in the given location,
+// truncated to the time unit in the same way as Time.
+// TimeIn panics if loc is nil, as time.Time.In does.
+func (l Layout) TimeIn(id uint64, loc *time.Location) time.Time {
+	return l.Time(id).In(loc)
+}
+
+// Compatible reports whether the IDs generated by a and b are mutually interpretable,
+// that is, whether they have the same Layout.
+// If not, Compatible also returns the reason, such as "time unit differs: 10ms != 1ms".
+func Compatible(a, b *Sonyflake) (bool, string) {
+	la, lb := a.Layout(), b.Layout()
+	switch {
+	case la.BitsTime != lb.BitsTime:
+		return false, fmt.Sprintf("bit length of time differs: %d != %d", la.BitsTime, lb.BitsTime)
+	case la.BitsSequence != lb.BitsSequence:
+		return false, fmt.Sprintf("bit length of sequence number differs: %d != %d", la.BitsSequence, lb.BitsSequence)
+	case la.BitsNamespace != lb.BitsNamespace:
+		return false, fmt.Sprintf("bit length of namespace id differs: %d != %d", la.BitsNamespace, lb.BitsNamespace)
+	case la.BitsMachineID != lb.BitsMachineID:
+		return false, fmt.Sprintf("bit length of machine id differs: %d != %d", la.BitsMachineID, lb.BitsMachineID)
+	case la.TimeUnit != lb.TimeUnit:
+		return false, fmt.Sprintf("time unit differs: %s != %s", la.TimeUnit, lb.TimeUnit)
+	case !la.StartTime.Equal(lb.StartTime):
+		return false, fmt.Sprintf("start time differs: %s != %s", la.StartTime.Format(time.RFC3339Nano), lb.StartTime.Format(time.RFC3339Nano))
+	case la.FieldOrder != lb.FieldOrder:
+		return false, fmt.Sprintf("field order differs: %d != %d", la.FieldOrder, lb.FieldOrder)
+	case la.UseMSB != lb.UseMSB:
+		return false, fmt.Sprintf("use of the most significant bit differs: %t != %t", la.UseMSB, lb.UseMSB)
+	}
+	return true, ""
+}
+
+type layoutJSON struct {
+	StartTime     time.Time `json:"start_time"`
+	TimeUnit      int64     `json:"time_unit"`
+	BitsTime      int       `json:"bits_time"`
+	BitsSequence  int       `json:"bits_sequence"`
+	BitsNamespace int       `json:"bits_namespace"`
+	BitsMachineID int       `json:"bits_machine_id"`
+	FieldOrder    string    `json:"field_order"`
+	UseMSB        bool      `json:"use_msb"`
+}
+
+var fieldOrderNames = map[FieldOrder]string{
+	FieldOrderSequenceFirst:  "sequence-first",
+	FieldOrderMachineIDFirst: "machine-id-first",
+}
+
+// MarshalJSON encodes the Layout as a JSON descriptor such as:
+//
+//	{"start_time":"2014-09-01T00:00:00Z","time_unit":10000000,"bits_time":39,"bits_sequence":8,
+//	 "bits_namespace":0,"bits_machine_id":16,"field_order":"sequence-first","use_msb":false}
+//
+// The time unit is in nanoseconds.
+func (l Layout) MarshalJSON() ([]byte, error) {
+	fieldOrder, ok := fieldOrderNames[l.FieldOrder]
+	if !ok {
+		return nil, ErrInvalidFieldOrder
+	}
+	return json.Marshal(layoutJSON{
+		StartTime:     l.StartTime,
+		TimeUnit:      int64(l.TimeUnit),
+		BitsTime:      l.BitsTime,
+		BitsSequence:  l.BitsSequence,
+		BitsNamespace: l.BitsNamespace,
+		BitsMachineID: l.BitsMachineID,
+		FieldOrder:    fieldOrder,
+		UseMSB:        l.UseMSB,
+	})
+}
+
+// UnmarshalJSON decodes a JSON descriptor encoded by MarshalJSON.
+// It returns the error of Validate if the descriptor is inconsistent.
+func (l *Layout) UnmarshalJSON(data []byte) error {
+	var v layoutJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	fieldOrder := FieldOrder(-1)
+	for order, name := range fieldOrderNames {
+		if name == v.FieldOrder {
+			fieldOrder = order
+		}
+	}
+	if fieldOrder < 0 {
+		return ErrInvalidFieldOrder
+	}
+
+	*l = Layout{
+		BitsTime:      v.BitsTime,
+		BitsSequence:  v.BitsSequence,
+		BitsNamespace: v.BitsNamespace,
+		BitsMachineID: v.BitsMachineID,
+		TimeUnit:      time.Duration(v.TimeUnit),
+		StartTime:     v.StartTime,
+		FieldOrder:    fieldOrder,
+		UseMSB:        v.UseMSB,
+	}
+	return l.Validate()
+}
+
+// LayoutJSON returns the Layout of the IDs generated by the Sonyflake as a JSON descriptor,
+// so that tooling can decompose the IDs without hardcoding bit lengths.
+func (sf *Sonyflake) LayoutJSON() ([]byte, error) {
+	return json.Marshal(sf.Layout())
+}
+
+// NewFromLayoutJSON returns a new Sonyflake generating IDs in the Layout of the given JSON descriptor,
+// with the machine ID returned by machineID.
+// If machineID is nil, default MachineID is used.
+// NewFromLayoutJSON returns an error if the descriptor is malformed or inconsistent, or New returns an error.
+func NewFromLayoutJSON(desc []byte, machineID func() (uint16, error)) (*Sonyflake, error) {
+	var l Layout
+	if err := json.Unmarshal(desc, &l); err != nil {
+		return nil, err
+	}
+
+	st := Settings{
+		StartTime:     l.StartTime,
+		MachineID:     machineID,
+		BitsSequence:  l.BitsSequence,
+		BitsMachineID: l.BitsMachineID,
+		BitsNamespace: l.BitsNamespace,
+		TimeUnit:      l.TimeUnit,
+		FieldOrder:    l.FieldOrder,
+		UseMSB:        l.UseMSB,
+	}
+	if st.BitsSequence == 0 || st.BitsMachineID == 0 || st.TimeUnit == 0 || st.StartTime.IsZero() {
+		// zero values of Settings select the defaults, which the descriptor does not mean
+		return nil, ErrInvalidLayout
+	}
+	if st.bitsTime() != l.BitsTime {
+		return nil, ErrInvalidBitsTime
+	}
+	return New(st)
+}
@@ -0,0 +1,272 @@
+package sonyflake
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLayout(t *testing.T) {
+	sf, err := New(Settings{
+		MachineID:     func() (uint16, error) { return 1, nil },
+		BitsSequence:  12,
+		BitsNamespace: 2,
+		BitsMachineID: 10,
+		TimeUnit:      time.Millisecond,
+		FieldOrder:    FieldOrderMachineIDFirst,
+		UseMSB:        true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := Layout{
+		BitsTime:      40,
+		BitsSequence:  12,
+		BitsNamespace: 2,
+		BitsMachineID: 10,
+		TimeUnit:      time.Millisecond,
+		StartTime:     time.Date(2014, 9, 1, 0, 0, 0, 0, time.UTC),
+		FieldOrder:    FieldOrderMachineIDFirst,
+		UseMSB:        true,
+	}
+	if layout := sf.Layout(); layout != expected {
+		t.Errorf("unexpected layout: %+v", layout)
+	}
+}
+
+func TestSettingsLayout(t *testing.T) {
+	st := Settings{
+		MachineID:     func() (uint16, error) { return 1, nil },
+		BitsSequence:  12,
+		BitsNamespace: 2,
+		BitsMachineID: 10,
+		TimeUnit:      time.Millisecond,
+		FieldOrder:    FieldOrderMachineIDFirst,
+	}
+	sf, err := New(st)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	layout, err := st.Layout()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if layout != sf.Layout() {
+		t.Errorf("unexpected layout: %+v, expected %+v", layout, sf.Layout())
+	}
+
+	if _, err := (Settings{BitsMachineID: 17}).Layout(); err == nil {
+		t.Error("invalid settings must be rejected")
+	}
+}
+
+func TestLayoutDecompose(t *testing.T) {
+	for _, order := range []FieldOrder{FieldOrderSequenceFirst, FieldOrderMachineIDFirst} {
+		st := Settings{
+			StartTime:     time.Now().Add(-time.Hour),
+			MachineID:     func() (uint16, error) { return 0x2ab, nil },
+			BitsSequence:  12,
+			BitsNamespace: 2,
+			NamespaceID:   3,
+			BitsMachineID: 10,
+			TimeUnit:      time.Millisecond,
+			FieldOrder:    order,
+		}
+		sf, err := New(st)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		at := time.Now().Truncate(time.Millisecond)
+		id, err := sf.Compose(at, 5, 0x2ab)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		layout := sf.Layout()
+		if parts, expected := layout.Decompose(id), sf.Decompose(id); !reflect.DeepEqual(parts, expected) {
+			t.Errorf("unexpected parts: %v, expected %v", parts, expected)
+		}
+		if got := layout.Time(id); !got.Equal(at) {
+			t.Errorf("unexpected time: %s, expected %s", got, at)
+		}
+	}
+}
+
+func TestLayoutValidateID(t *testing.T) {
+	layout, err := Settings{}.Layout()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := layout.ValidateID(1<<63 - 1); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if err := layout.ValidateID(1 << 63); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	layout.UseMSB = true
+	if err := layout.ValidateID(1 << 63); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestLayoutTimeIn(t *testing.T) {
+	layout, err := Settings{}.Layout()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	id := uint64(12345)<<(layout.BitsSequence+layout.BitsMachineID) | 0xff
+	loc := time.FixedZone("JST", 9*60*60)
+	got := layout.TimeIn(id, loc)
+	if got.Location() != loc {
+		t.Errorf("unexpected location: %s", got.Location())
+	}
+	if expected := layout.StartTime.Add(12345 * layout.TimeUnit); !got.Equal(expected) {
+		t.Errorf("unexpected time: %s, expected %s", got, expected)
+	}
+}
+
+func TestCompatible(t *testing.T) {
+	newSonyflake := func(st Settings) *Sonyflake {
+		st.MachineID = func() (uint16, error) { return 1, nil }
+		sf, err := New(st)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		return sf
+	}
+
+	base := newSonyflake(Settings{})
+	testCases := []struct {
+		st     Settings
+		reason string
+	}{
+		{Settings{}, ""},
+		{Settings{BitsSequence: 8, BitsMachineID: 16, TimeUnit: 10 * time.Millisecond}, ""},
+		{Settings{BitsSequence: 9, BitsMachineID: 15}, "bit length of sequence number differs: 8 != 9"},
+		{Settings{BitsSequence: 7}, "bit length of time differs: 39 != 40"},
+		{Settings{BitsNamespace: 2, BitsMachineID: 14}, "bit length of namespace id differs: 0 != 2"},
+		{Settings{TimeUnit: time.Millisecond}, "time unit differs: 10ms != 1ms"},
+		{Settings{StartTime: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}, "start time differs: 2014-09-01T00:00:00Z != 2020-01-01T00:00:00Z"},
+		{Settings{FieldOrder: FieldOrderMachineIDFirst}, "field order differs: 0 != 1"},
+	}
+
+	for _, tc := range testCases {
+		ok, reason := Compatible(base, newSonyflake(tc.st))
+		if ok != (tc.reason == "") || reason != tc.reason {
+			t.Errorf("unexpected result of %+v: %t, %q", tc.st, ok, reason)
+		}
+	}
+}
+
+func TestLayoutJSON(t *testing.T) {
+	sf, err := New(Settings{
+		StartTime:     time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		MachineID:     func() (uint16, error) { return 1, nil },
+		BitsSequence:  12,
+		BitsNamespace: 2,
+		BitsMachineID: 10,
+		TimeUnit:      time.Millisecond,
+		FieldOrder:    FieldOrderMachineIDFirst,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	desc, err := sf.LayoutJSON()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := `{"start_time":"2020-01-01T00:00:00Z","time_unit":1000000,"bits_time":39,"bits_sequence":12,` +
+		`"bits_namespace":2,"bits_machine_id":10,"field_order":"machine-id-first","use_msb":false}`
+	if string(desc) != expected {
+		t.Errorf("unexpected descriptor: %s", desc)
+	}
+
+	imported, err := NewFromLayoutJSON(desc, func() (uint16, error) { return 2, nil })
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if ok, reason := Compatible(sf, imported); !ok {
+		t.Errorf("imported layout must be compatible: %s", reason)
+	}
+
+	id, err := imported.NextID()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if machineID := sf.Decompose(id)["machine-id"]; machineID != 2 {
+		t.Errorf("unexpected machine id: %d", machineID)
+	}
+}
+
+func TestLayoutValidate(t *testing.T) {
+	valid, err := Settings{}.Layout()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	testCases := []struct {
+		modify func(*Layout)
+		err    error
+	}{
+		{func(l *Layout) { l.TimeUnit = 0 }, ErrInvalidTimeUnit},
+		{func(l *Layout) { l.BitsTime = 70 }, ErrInvalidBitsTime},
+		{func(l *Layout) { l.BitsTime++ }, ErrInvalidBitsTime},
+		{func(l *Layout) { l.BitsSequence = -1 }, ErrInvalidBitsSequence},
+		{func(l *Layout) { l.BitsNamespace = 17 }, ErrInvalidBitsNamespace},
+		{func(l *Layout) { l.BitsMachineID = 17 }, ErrInvalidBitsMachineID},
+		{func(l *Layout) { l.FieldOrder = -1 }, ErrInvalidFieldOrder},
+	}
+	for _, tc := range testCases {
+		l := valid
+		tc.modify(&l)
+		if err := l.Validate(); err != tc.err {
+			t.Errorf("unexpected error: %v, expected %v", err, tc.err)
+		}
+	}
+
+	msb := valid
+	msb.BitsTime++
+	msb.UseMSB = true
+	if err := msb.Validate(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	var l Layout
+	if err := json.Unmarshal([]byte(`{"bits_time":39,"bits_sequence":8,"bits_machine_id":16,"field_order":"sequence-first"}`), &l); err != ErrInvalidTimeUnit {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestNewFromLayoutJSONError(t *testing.T) {
+	testCases := []struct {
+		desc string
+		err  error
+	}{
+		{`{"start_time":"2020-01-01T00:00:00Z","time_unit":10000000,"bits_time":40,"bits_sequence":8,"bits_machine_id":16,"field_order":"sequence-first"}`, ErrInvalidBitsTime},
+		{`{"start_time":"2020-01-01T00:00:00Z","time_unit":10000000,"bits_time":39,"bits_sequence":8,"bits_machine_id":16,"field_order":"unknown"}`, ErrInvalidFieldOrder},
+		{`{"time_unit":10000000,"bits_time":39,"bits_sequence":8,"bits_machine_id":16,"field_order":"sequence-first"}`, ErrInvalidLayout},
+		{`{"start_time":"2020-01-01T00:00:00Z","time_unit":1000,"bits_time":39,"bits_sequence":8,"bits_machine_id":16,"field_order":"sequence-first"}`, ErrInvalidTimeUnit},
+	}
+
+	for _, tc := range testCases {
+		if _, err := NewFromLayoutJSON([]byte(tc.desc), func() (uint16, error) { return 1, nil }); !errors.Is(err, tc.err) {
+			t.Errorf("unexpected error: %v, expected %v", err, tc.err)
+		}
+	}
+
+	if _, err := NewFromLayoutJSON([]byte("{"), nil); err == nil {
+		t.Error("malformed descriptor must be rejected")
+	}
+}
@@ -0,0 +1,62 @@
+//go:build !nonet && !js && !wasip1
+// +build !nonet,!js,!wasip1
+
+package sonyflake
+
+import (
+	"errors"
+	"net"
+
+	"github.com/sony/sonyflake/types"
+)
+
+var defaultInterfaceAddrsByName types.InterfaceAddrsByName = interfaceAddrsByName
+
+var defaultInterfaces types.Interfaces = net.Interfaces
+
+var defaultInterfaceAddrs = sortedInterfaceAddrs(defaultInterfaces, defaultInterfaceAddrsByName)
+
+// defaultMachineIDResolver returns the resolver of default MachineID,
+// which derives the machine ID from the private IP address of the network interfaces.
+func defaultMachineIDResolver(st Settings) (func() (uint16, net.IP, error), error) {
+	interfaceAddrs := defaultInterfaceAddrs
+	interfaceNames := func() []string {
+		return sortedInterfaceNames(defaultInterfaces)
+	}
+	if len(st.Interfaces) > 0 {
+		interfaceAddrs = namedInterfaceAddrs(defaultInterfaceAddrsByName, st.Interfaces)
+		interfaceNames = func() []string {
+			return st.Interfaces
+		}
+	}
+
+	cidrs, err := parseCIDRs(st.PreferredCIDRs)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() (uint16, net.IP, error) {
+		ip, err := privateIP(interfaceAddrs, cidrs...)
+		if errors.Is(err, ErrNoPrivateAddress) && st.AllowPublicIP {
+			ip, err = publicIPv4(interfaceAddrs)
+		}
+		if errors.Is(err, ErrNoPrivateAddress) {
+			return 0, nil, &NoPrivateAddressError{
+				Interfaces: interfaceNames(),
+				Addresses:  rejectedAddrs(interfaceAddrs),
+			}
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+		return lower16BitIP(ip) & uint16(1<<st.bitsMachineID()-1), ip, nil
+	}, nil
+}
+
+func interfaceAddrsByName(name string) ([]net.Addr, error) {
+	ifi, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, nil // the interface does not exist on this host
+	}
+	return ifi.Addrs()
+}
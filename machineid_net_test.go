@@ -0,0 +1,292 @@
+//go:build !nonet && !js && !wasip1
+// +build !nonet,!js,!wasip1
+
+package sonyflake
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+)
+
+var sf *Sonyflake
+
+var startTime int64
+var machineID uint64
+
+func init() {
+	var st Settings
+	st.StartTime = time.Now()
+
+	sf = NewSonyflake(st)
+	if sf == nil {
+		panic("sonyflake not created")
+	}
+
+	startTime = toSonyflakeTime(st.StartTime)
+
+	ip, _ := lower16BitPrivateIP(defaultInterfaceAddrs)
+	machineID = uint64(ip)
+}
+
+func nextID(t *testing.T) uint64 {
+	id, err := sf.NextID()
+	if err != nil {
+		t.Fatal("id not generated")
+	}
+	return id
+}
+
+func TestSonyflakeOnce(t *testing.T) {
+	// a Sonyflake of its own, so that the time taken by other tests does not matter
+	once := NewSonyflake(Settings{StartTime: time.Now()})
+	if once == nil {
+		t.Fatal("sonyflake not created")
+	}
+
+	sleepTime := time.Duration(50 * sonyflakeTimeUnit)
+	time.Sleep(sleepTime)
+
+	id, err := once.NextID()
+	if err != nil {
+		t.Fatal("id not generated")
+	}
+
+	actualTime := ElapsedTime(id)
+	if actualTime < sleepTime || actualTime > sleepTime+sonyflakeTimeUnit {
+		t.Errorf("unexpected time: %d", actualTime)
+	}
+
+	actualSequence := SequenceNumber(id)
+	if actualSequence != 0 {
+		t.Errorf("unexpected sequence: %d", actualSequence)
+	}
+
+	actualMachineID := MachineID(id)
+	if actualMachineID != machineID {
+		t.Errorf("unexpected machine id: %d", actualMachineID)
+	}
+
+	fmt.Println("sonyflake id:", id)
+	fmt.Println("decompose:", Decompose(id))
+}
+
+func currentTime() int64 {
+	return toSonyflakeTime(time.Now())
+}
+
+func TestSonyflakeFor10Sec(t *testing.T) {
+	var numID uint32
+	var lastID uint64
+	var maxSequence uint64
+
+	initial := currentTime()
+	current := initial
+	for current-initial < 1000 {
+		id := nextID(t)
+		parts := Decompose(id)
+		numID++
+
+		if id == lastID {
+			t.Fatal("duplicated id")
+		}
+		if id < lastID {
+			t.Fatal("must increase with time")
+		}
+		lastID = id
+
+		current = currentTime()
+
+		actualMSB := parts["msb"]
+		if actualMSB != 0 {
+			t.Errorf("unexpected msb: %d", actualMSB)
+		}
+
+		actualTime := int64(parts["time"])
+		overtime := startTime + actualTime - current
+		if overtime > 0 {
+			t.Errorf("unexpected overtime: %d", overtime)
+		}
+
+		actualSequence := parts["sequence"]
+		if maxSequence < actualSequence {
+			maxSequence = actualSequence
+		}
+
+		actualMachineID := parts["machine-id"]
+		if actualMachineID != machineID {
+			t.Errorf("unexpected machine id: %d", actualMachineID)
+		}
+	}
+
+	if maxSequence != 1<<BitLenSequence-1 {
+		t.Errorf("unexpected max sequence: %d", maxSequence)
+	}
+	fmt.Println("max sequence:", maxSequence)
+	fmt.Println("number of id:", numID)
+}
+
+func TestSonyflakeInParallel(t *testing.T) {
+	numCPU := runtime.NumCPU()
+	runtime.GOMAXPROCS(numCPU)
+	fmt.Println("number of cpu:", numCPU)
+
+	consumer := make(chan uint64)
+
+	const numID = 10000
+	generate := func() {
+		for i := 0; i < numID; i++ {
+			consumer <- nextID(t)
+		}
+	}
+
+	const numGenerator = 10
+	for i := 0; i < numGenerator; i++ {
+		go generate()
+	}
+
+	set := make(map[uint64]struct{})
+	for i := 0; i < numID*numGenerator; i++ {
+		id := <-consumer
+		if _, ok := set[id]; ok {
+			t.Fatal("duplicated id")
+		}
+		set[id] = struct{}{}
+	}
+	fmt.Println("number of id:", len(set))
+}
+
+func pseudoSleep(period time.Duration) {
+	sf.startTime -= int64(period) / sonyflakeTimeUnit
+}
+
+func TestNextIDError(t *testing.T) {
+	year := time.Duration(365*24) * time.Hour
+	pseudoSleep(time.Duration(174) * year)
+	nextID(t)
+
+	pseudoSleep(time.Duration(1) * year)
+	_, err := sf.NextID()
+	if err == nil {
+		t.Errorf("time is not over")
+	}
+}
+
+func TestMachineIP(t *testing.T) {
+	ip := sf.MachineIP()
+	if ip == nil {
+		t.Fatal("machine ip not resolved")
+	}
+	if uint64(lower16BitIP(ip)) != machineID {
+		t.Errorf("unexpected machine ip: %s", ip)
+	}
+
+	custom, err := New(Settings{MachineID: func() (uint16, error) { return 1, nil }})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if custom.MachineIP() != nil {
+		t.Errorf("unexpected machine ip: %s", custom.MachineIP())
+	}
+}
+
+func TestNewWithInterfaces(t *testing.T) {
+	_, err := New(Settings{Interfaces: []string{"lo"}})
+	if !errors.Is(err, ErrNoPrivateAddress) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	_, err = New(Settings{Interfaces: []string{"lo"}, AllowPublicIP: true})
+	if !errors.Is(err, ErrNoPrivateAddress) {
+		t.Errorf("loopback must not be allowed: %v", err)
+	}
+}
+
+func TestLowerBitsPrivateIP(t *testing.T) {
+	for _, bits := range []int{1, 8, 12, 16} {
+		actual, err := LowerBitsPrivateIP(bits)()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if expected := uint16(machineID) & uint16(1<<bits-1); actual != expected {
+			t.Errorf("unexpected machine id of %d bits: %d != %d", bits, actual, expected)
+		}
+	}
+
+	for _, bits := range []int{0, 17} {
+		if _, err := LowerBitsPrivateIP(bits)(); err != ErrInvalidBitsMachineID {
+			t.Errorf("unexpected error for %d bits: %v", bits, err)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	genError := fmt.Errorf("an error occurred while generating ID")
+
+	tests := []struct {
+		name     string
+		settings Settings
+		err      error
+	}{
+		{
+			name: "failure: time ahead",
+			settings: Settings{
+				StartTime: time.Now().Add(time.Minute),
+			},
+			err: ErrStartTimeAhead,
+		},
+		{
+			name: "failure: machine ID",
+			settings: Settings{
+				MachineID: func() (uint16, error) {
+					return 0, genError
+				},
+			},
+			err: genError,
+		},
+		{
+			name: "failure: invalid machine ID",
+			settings: Settings{
+				CheckMachineID: func(uint16) bool {
+					return false
+				},
+			},
+			err: ErrInvalidMachineID,
+		},
+		{
+			name:     "success",
+			settings: Settings{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sonyflake, err := New(test.settings)
+
+			if !errors.Is(err, test.err) {
+				t.Fatalf("unexpected value, want %#v, got %#v", test.err, err)
+			}
+
+			if sonyflake == nil && err == nil {
+				t.Fatal("unexpected value, sonyflake should not be nil")
+			}
+		})
+	}
+}
+
+func TestNoPrivateAddressError(t *testing.T) {
+	_, err := New(Settings{Interfaces: []string{"lo"}})
+	if !errors.Is(err, ErrNoPrivateAddress) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var e *NoPrivateAddressError
+	if !errors.As(err, &e) {
+		t.Fatalf("unexpected error type: %T", err)
+	}
+	if len(e.Interfaces) != 1 || e.Interfaces[0] != "lo" {
+		t.Errorf("unexpected interfaces: %v", e.Interfaces)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build nonet || js || wasip1
+// +build nonet js wasip1
+
+package sonyflake
+
+import "net"
+
+// defaultMachineIDResolver returns ErrNoMachineID
+// since network interfaces to derive the machine ID from are unavailable or compiled out by the nonet build tag.
+func defaultMachineIDResolver(st Settings) (func() (uint16, net.IP, error), error) {
+	return nil, ErrNoMachineID
+}
@@ -0,0 +1,23 @@
+//go:build nonet || js || wasip1
+// +build nonet js wasip1
+
+package sonyflake
+
+import "testing"
+
+func TestNoDefaultMachineID(t *testing.T) {
+	if _, err := New(Settings{}); err != ErrNoMachineID {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := LowerBitsPrivateIP(16)(); err != ErrNoMachineID {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	sf, err := New(Settings{FallbackMachineID: func() (uint16, error) { return 1, nil }})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if identity := sf.ResolvedIdentity(); identity.Source != SourceFallback || identity.MachineID != 1 {
+		t.Errorf("unexpected identity: %+v", identity)
+	}
+}
@@ -0,0 +1,83 @@
+package sonyflake
+
+import (
+	"container/heap"
+	"context"
+)
+
+// Merge merges streams of IDs, each sorted in ascending order such as the IDs of one machine,
+// into a single stream sorted by the time when the IDs were generated.
+// Since the time occupies the most significant bits of IDs in every layout,
+// IDs of the same time are ordered by their values, and so the merged stream is sorted in ascending order.
+//
+// Merge waits for the next ID of every stream that has not been closed before sending an ID.
+// The returned stream is closed when all the streams are closed or ctx is done.
+func Merge(ctx context.Context, streams ...<-chan uint64) <-chan uint64 {
+	out := make(chan uint64)
+	go func() {
+		defer close(out)
+
+		h := make(mergeHeap, 0, len(streams))
+		for _, s := range streams {
+			head, ok, err := receiveID(ctx, s)
+			if err != nil {
+				return
+			}
+			if ok {
+				h = append(h, mergeHead{id: head, stream: s})
+			}
+		}
+		heap.Init(&h)
+
+		for h.Len() > 0 {
+			select {
+			case out <- h[0].id:
+			case <-ctx.Done():
+				return
+			}
+
+			next, ok, err := receiveID(ctx, h[0].stream)
+			if err != nil {
+				return
+			}
+			if ok {
+				h[0].id = next
+				heap.Fix(&h, 0)
+			} else {
+				heap.Pop(&h)
+			}
+		}
+	}()
+	return out
+}
+
+func receiveID(ctx context.Context, stream <-chan uint64) (id uint64, ok bool, err error) {
+	select {
+	case id, ok = <-stream:
+		return id, ok, nil
+	case <-ctx.Done():
+		return 0, false, ctx.Err()
+	}
+}
+
+type mergeHead struct {
+	id     uint64
+	stream <-chan uint64
+}
+
+type mergeHeap []mergeHead
+
+func (h mergeHeap) Len() int           { return len(h) }
+func (h mergeHeap) Less(i, j int) bool { return h[i].id < h[j].id }
+func (h mergeHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *mergeHeap) Push(x interface{}) {
+	*h = append(*h, x.(mergeHead))
+}
+
+func (h *mergeHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,89 @@
+package sonyflake
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+)
+
+func sendIDs(ids []uint64) <-chan uint64 {
+	ch := make(chan uint64)
+	go func() {
+		defer close(ch)
+		for _, id := range ids {
+			ch <- id
+		}
+	}()
+	return ch
+}
+
+func TestMerge(t *testing.T) {
+	startTime := time.Now()
+	var streams [][]uint64
+	var expected []uint64
+	for machineID := uint16(1); machineID <= 3; machineID++ {
+		sf, err := New(Settings{
+			StartTime: startTime,
+			MachineID: func() (uint16, error) { return machineID, nil },
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		var ids []uint64
+		for i := 0; i < 1000; i++ {
+			id, err := sf.NextID()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			ids = append(ids, id)
+		}
+		streams = append(streams, ids)
+		expected = append(expected, ids...)
+	}
+	streams = append(streams, nil)
+	sort.Slice(expected, func(i, j int) bool { return expected[i] < expected[j] })
+
+	var chans []<-chan uint64
+	for _, ids := range streams {
+		chans = append(chans, sendIDs(ids))
+	}
+
+	var merged []uint64
+	for id := range Merge(context.Background(), chans...) {
+		merged = append(merged, id)
+	}
+
+	if len(merged) != len(expected) {
+		t.Fatalf("unexpected number of ids: %d, expected %d", len(merged), len(expected))
+	}
+	for i := range merged {
+		if merged[i] != expected[i] {
+			t.Fatalf("unexpected id at %d: %d, expected %d", i, merged[i], expected[i])
+		}
+	}
+}
+
+func TestMergeCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	pending := make(chan uint64)
+
+	out := Merge(ctx, sendIDs([]uint64{1, 2, 3}), pending)
+	cancel()
+
+	select {
+	case _, ok := <-out:
+		if ok {
+			t.Error("no id must be sent before every stream has its next id")
+		}
+	case <-time.After(time.Second):
+		t.Error("merged stream must be closed after cancel")
+	}
+}
+
+func TestMergeNoStream(t *testing.T) {
+	if _, ok := <-Merge(context.Background()); ok {
+		t.Error("merged stream must be closed")
+	}
+}
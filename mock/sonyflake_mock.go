@@ -20,6 +20,30 @@ func NewSuccessfulInterfaceAddrs() types.InterfaceAddrs {
 	}
 }
 
+// NewIPv6InterfaceAddrs returns a link-local, a global and a unique local IPv6 address
+func NewIPv6InterfaceAddrs() types.InterfaceAddrs {
+	ifat := make([]net.Addr, 0, 3)
+	ifat = append(ifat, &net.IPNet{IP: net.ParseIP("fe80::1"), Mask: net.CIDRMask(64, 128)})
+	ifat = append(ifat, &net.IPNet{IP: net.ParseIP("2001:db8::1:2"), Mask: net.CIDRMask(64, 128)})
+	ifat = append(ifat, &net.IPNet{IP: net.ParseIP("fd00::304"), Mask: net.CIDRMask(64, 128)})
+
+	return func() ([]net.Addr, error) {
+		return ifat, nil
+	}
+}
+
+// NewMultiHomedInterfaceAddrs returns private IP addresses of a host network and a pod network
+func NewMultiHomedInterfaceAddrs() types.InterfaceAddrs {
+	ifat := make([]net.Addr, 0, 3)
+	ifat = append(ifat, &net.IPNet{IP: []byte{127, 0, 0, 1}, Mask: []byte{255, 0, 0, 0}})
+	ifat = append(ifat, &net.IPNet{IP: []byte{192, 168, 0, 1}, Mask: []byte{255, 255, 0, 0}})
+	ifat = append(ifat, &net.IPNet{IP: []byte{10, 32, 1, 2}, Mask: []byte{255, 240, 0, 0}})
+
+	return func() ([]net.Addr, error) {
+		return ifat, nil
+	}
+}
+
 // NewFailingInterfaceAddrs returns an error
 func NewFailingInterfaceAddrs() types.InterfaceAddrs {
 	return func() ([]net.Addr, error) {
@@ -33,3 +57,43 @@ func NewNilInterfaceAddrs() types.InterfaceAddrs {
 		return []net.Addr{}, nil
 	}
 }
+
+// NewInterfaces returns network interfaces named "eth1", "tun0" and "eth0" in the reverse order of their indexes
+func NewInterfaces() types.Interfaces {
+	return func() ([]net.Interface, error) {
+		return []net.Interface{
+			{Index: 3, Name: "eth1"},
+			{Index: 2, Name: "tun0"},
+			{Index: 2, Name: "eth0"},
+		}, nil
+	}
+}
+
+// NewInterfaceAddrsByName returns a private IP address for "eth0", a public IP address for "eth1",
+// no address for any other interface and an error for "err0"
+func NewInterfaceAddrsByName() types.InterfaceAddrsByName {
+	return func(name string) ([]net.Addr, error) {
+		switch name {
+		case "eth0":
+			return []net.Addr{&net.IPNet{IP: []byte{10, 0, 1, 2}, Mask: []byte{255, 0, 0, 0}}}, nil
+		case "eth1":
+			return []net.Addr{&net.IPNet{IP: []byte{8, 8, 4, 4}, Mask: []byte{255, 255, 255, 0}}}, nil
+		case "err0":
+			return nil, fmt.Errorf("test error")
+		default:
+			return nil, nil
+		}
+	}
+}
+
+// NewRejectedInterfaceAddrs returns a loopback, a public IPv4 and a link-local IPv6 address
+func NewRejectedInterfaceAddrs() types.InterfaceAddrs {
+	ifat := make([]net.Addr, 0, 3)
+	ifat = append(ifat, &net.IPNet{IP: []byte{127, 0, 0, 1}, Mask: []byte{255, 0, 0, 0}})
+	ifat = append(ifat, &net.IPNet{IP: []byte{8, 8, 8, 8}, Mask: []byte{255, 255, 255, 0}})
+	ifat = append(ifat, &net.IPNet{IP: net.ParseIP("fe80::1"), Mask: net.CIDRMask(64, 128)})
+
+	return func() ([]net.Addr, error) {
+		return ifat, nil
+	}
+}
@@ -0,0 +1,144 @@
+package sonyflake
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrTooManyTenants is returned by Tenant if all the namespace IDs are taken by other tenants.
+var ErrTooManyTenants = errors.New("too many tenants")
+
+// MultiTenant derives per-tenant Sonyflakes from a single Settings.
+// The machine ID is resolved and checked only once and shared by all the tenants.
+// Rechecks of the machine ID by Settings.RecheckInterval and Settings.RecheckEvery are shared as well:
+// the calls of NextID of all the tenants are counted together,
+// and a failed recheck stops issuance of all the tenants as Settings.RecheckPolicy says.
+// The tenants also stop issuing IDs after Close of the MultiTenant.
+//
+// Since the tenants share the machine ID, they differ only in the namespace ID carried by IDs,
+// so that their IDs are unique within one ID space.
+// Namespace obtains the tenant of a given namespace ID,
+// and Tenant assigns a free namespace ID to a named tenant on first use.
+// There are as many tenants as namespace IDs of Settings.BitsNamespace bits at most.
+type MultiTenant struct {
+	mutex      sync.Mutex
+	settings   Settings
+	base       *Sonyflake
+	tenants    map[string]*Sonyflake
+	namespaces map[uint16]*Sonyflake
+}
+
+// NewMultiTenant returns a new MultiTenant configured with the given Settings.
+// NewMultiTenant returns an error in the same cases as New.
+func NewMultiTenant(st Settings) (*MultiTenant, error) {
+	base, err := New(st)
+	if err != nil {
+		return nil, err
+	}
+
+	machineID := base.machineID
+	st.MachineID = func() (uint16, error) {
+		return machineID, nil
+	}
+	st.CheckMachineID = nil
+
+	return &MultiTenant{
+		settings:   st,
+		base:       base,
+		tenants:    make(map[string]*Sonyflake),
+		namespaces: make(map[uint16]*Sonyflake),
+	}, nil
+}
+
+// Tenant returns the Sonyflake of the given tenant, creating it on first use
+// with the lowest namespace ID not taken by Tenant or Namespace.
+// Tenant returns ErrTooManyTenants if all the namespace IDs are taken.
+func (mt *MultiTenant) Tenant(tenant string) (*Sonyflake, error) {
+	mt.mutex.Lock()
+	defer mt.mutex.Unlock()
+
+	if sf, ok := mt.tenants[tenant]; ok {
+		return sf, nil
+	}
+
+	for namespaceID := 0; namespaceID < 1<<uint(mt.settings.BitsNamespace); namespaceID++ {
+		if _, ok := mt.namespaces[uint16(namespaceID)]; ok {
+			continue
+		}
+
+		st := mt.settings
+		st.NamespaceID = uint16(namespaceID)
+		sf, err := mt.newTenant(st)
+		if err != nil {
+			return nil, err
+		}
+
+		mt.tenants[tenant] = sf
+		mt.namespaces[uint16(namespaceID)] = sf
+		return sf, nil
+	}
+	return nil, ErrTooManyTenants
+}
+
+// Namespace returns the Sonyflake of the tenant with the given namespace ID, creating it on first use.
+// It is the Sonyflake of a named tenant if Tenant has assigned the namespace ID to it.
+// Namespace returns ErrInvalidNamespaceID if the namespace ID does not fit in Settings.BitsNamespace bits.
+func (mt *MultiTenant) Namespace(namespaceID uint16) (*Sonyflake, error) {
+	mt.mutex.Lock()
+	defer mt.mutex.Unlock()
+
+	if sf, ok := mt.namespaces[namespaceID]; ok {
+		return sf, nil
+	}
+
+	st := mt.settings
+	st.NamespaceID = namespaceID
+	sf, err := mt.newTenant(st)
+	if err != nil {
+		return nil, err
+	}
+
+	mt.namespaces[namespaceID] = sf
+	return sf, nil
+}
+
+func (mt *MultiTenant) newTenant(st Settings) (*Sonyflake, error) {
+	sf, err := New(st)
+	if err != nil {
+		return nil, err
+	}
+	sf.machineIP = mt.base.machineIP
+	sf.identity = mt.base.identity
+	sf.base = mt.base
+	return sf, nil
+}
+
+// NextID generates a next unique ID of the given tenant.
+func (mt *MultiTenant) NextID(tenant string) (uint64, error) {
+	sf, err := mt.Tenant(tenant)
+	if err != nil {
+		return 0, err
+	}
+	return sf.NextID()
+}
+
+// Close closes the Sonyflakes of all the tenants.
+// Close returns the first error of closing them, if any.
+func (mt *MultiTenant) Close() error {
+	mt.mutex.Lock()
+	tenants := make([]*Sonyflake, 0, len(mt.namespaces))
+	for _, sf := range mt.namespaces { // including the named tenants
+		tenants = append(tenants, sf)
+	}
+	mt.tenants = make(map[string]*Sonyflake)
+	mt.namespaces = make(map[uint16]*Sonyflake)
+	mt.mutex.Unlock()
+
+	err := mt.base.Close()
+	for _, sf := range tenants {
+		if cerr := sf.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,164 @@
+package sonyflake
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMultiTenant(t *testing.T) {
+	var resolved int
+	mt, err := NewMultiTenant(Settings{
+		MachineID: func() (uint16, error) {
+			resolved++
+			return 1, nil
+		},
+		BitsNamespace: 2,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer mt.Close()
+
+	if _, err := mt.Namespace(1); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for i, tenant := range []string{"a", "b", "c"} {
+		sf, err := mt.Tenant(tenant)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		id, err := mt.NextID(tenant)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		// namespace 1 is taken by Namespace
+		parts := sf.Decompose(id)
+		if expected := []uint64{0, 2, 3}[i]; parts["namespace"] != expected || parts["machine-id"] != 1 {
+			t.Errorf("unexpected parts of tenant %s: %v", tenant, parts)
+		}
+	}
+
+	if _, err := mt.Tenant("d"); err != ErrTooManyTenants {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if resolved != 1 {
+		t.Errorf("machine id resolved %d times", resolved)
+	}
+
+	a1, _ := mt.Tenant("a")
+	a2, _ := mt.Tenant("a")
+	if a1 != a2 {
+		t.Errorf("tenant generator recreated")
+	}
+}
+
+func TestMultiTenantNamespace(t *testing.T) {
+	mt, err := NewMultiTenant(Settings{
+		StartTime:     time.Now(),
+		MachineID:     func() (uint16, error) { return 1, nil },
+		BitsNamespace: 4,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer mt.Close()
+
+	set := make(map[uint64]struct{})
+	for namespaceID := uint16(0); namespaceID < 16; namespaceID++ {
+		sf, err := mt.Namespace(namespaceID)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		for i := 0; i < 100; i++ {
+			id, err := sf.NextID()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if _, ok := set[id]; ok {
+				t.Fatal("duplicated id")
+			}
+			set[id] = struct{}{}
+
+			parts := sf.Decompose(id)
+			if parts["namespace"] != uint64(namespaceID) || parts["machine-id"] != 1 {
+				t.Fatalf("unexpected parts: %v", parts)
+			}
+		}
+	}
+
+	if _, err := mt.Namespace(16); !errors.Is(err, ErrInvalidNamespaceID) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestMultiTenantRecheck(t *testing.T) {
+	var valid int32 = 1
+	mt, err := NewMultiTenant(Settings{
+		MachineID:       func() (uint16, error) { return 1, nil },
+		CheckMachineID:  func(uint16) bool { return atomic.LoadInt32(&valid) == 1 },
+		RecheckInterval: time.Millisecond,
+		BitsNamespace:   2,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer mt.Close()
+
+	if _, err := mt.NextID("a"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	atomic.StoreInt32(&valid, 0)
+	deadline := time.Now().Add(time.Second)
+	for {
+		_, err := mt.NextID("a")
+		if err == ErrMachineIDCheckFailed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	sf, err := mt.Tenant("b")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := sf.Err(); err != ErrMachineIDCheckFailed {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestMultiTenantRecheckEvery(t *testing.T) {
+	checks := 0
+	mt, err := NewMultiTenant(Settings{
+		MachineID: func() (uint16, error) { return 1, nil },
+		CheckMachineID: func(uint16) bool {
+			checks++
+			return checks == 1
+		},
+		RecheckEvery:  2,
+		BitsNamespace: 2,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer mt.Close()
+
+	// the calls of NextID of the tenants are counted together
+	if _, err := mt.NextID("a"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := mt.NextID("b"); err != ErrMachineIDCheckFailed {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := mt.NextID("a"); err != ErrMachineIDCheckFailed {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package sonyflake
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+)
+
+// PartitionFor returns the partition of the given ID among numPartitions partitions
+// by a stable hash of the whole ID, so that IDs are spread evenly.
+// PartitionFor panics if numPartitions <= 0.
+func PartitionFor(id uint64, numPartitions int) int {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, id)
+	return partitionFor(b, numPartitions)
+}
+
+// MachinePartitionFor returns the partition of the given ID in the default layout among numPartitions partitions
+// by a stable hash of its machine ID, so that IDs generated by the same machine share a partition.
+// MachinePartitionFor panics if numPartitions <= 0.
+func MachinePartitionFor(id uint64, numPartitions int) int {
+	return partitionFor(MachineAffinityKey(id), numPartitions)
+}
+
+// MachineAffinityKey returns the machine ID of the given ID in the default layout as a 2-byte big-endian key,
+// which is usable as a message key to keep the messages of each machine in order.
+func MachineAffinityKey(id uint64) []byte {
+	return machineAffinityKey(uint16(MachineID(id)))
+}
+
+// MachinePartitionFor returns the partition of the given ID generated by the Sonyflake among numPartitions partitions
+// by a stable hash of its machine ID.
+// MachinePartitionFor panics if numPartitions <= 0.
+func (sf *Sonyflake) MachinePartitionFor(id uint64, numPartitions int) int {
+	return partitionFor(sf.MachineAffinityKey(id), numPartitions)
+}
+
+// MachineAffinityKey returns the machine ID of the given ID generated by the Sonyflake as a 2-byte big-endian key.
+func (sf *Sonyflake) MachineAffinityKey(id uint64) []byte {
+	return machineAffinityKey(uint16(id >> sf.shiftMachineID & (1<<sf.bitsMachineID - 1)))
+}
+
+func machineAffinityKey(machineID uint16) []byte {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, machineID)
+	return b
+}
+
+func partitionFor(key []byte, numPartitions int) int {
+	if numPartitions <= 0 {
+		panic("sonyflake: invalid number of partitions")
+	}
+
+	h := fnv.New64a()
+	h.Write(key)
+	return int(h.Sum64() % uint64(numPartitions))
+}
@@ -0,0 +1,81 @@
+package sonyflake
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestPartitionFor(t *testing.T) {
+	const numPartitions = 8
+
+	counts := make([]int, numPartitions)
+	for id := uint64(0); id < 8000; id++ {
+		p := PartitionFor(id, numPartitions)
+		if p < 0 || p >= numPartitions {
+			t.Fatalf("unexpected partition: %d", p)
+		}
+		if PartitionFor(id, numPartitions) != p {
+			t.Fatalf("unstable partition of %d", id)
+		}
+		counts[p]++
+	}
+	for p, count := range counts {
+		if count == 0 {
+			t.Errorf("no id in partition %d", p)
+		}
+	}
+
+	if p := PartitionFor(12345, 1); p != 0 {
+		t.Errorf("unexpected partition: %d", p)
+	}
+}
+
+func TestPartitionForInvalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("PartitionFor must panic")
+		}
+	}()
+	PartitionFor(12345, 0)
+}
+
+func TestMachinePartitionFor(t *testing.T) {
+	const numPartitions = 16
+
+	a := uint64(1)<<(BitLenSequence+BitLenMachineID) | 0x1234
+	b := uint64(2)<<(BitLenSequence+BitLenMachineID) | uint64(3)<<BitLenMachineID | 0x1234
+	if MachinePartitionFor(a, numPartitions) != MachinePartitionFor(b, numPartitions) {
+		t.Error("ids of the same machine must share a partition")
+	}
+
+	key := MachineAffinityKey(a)
+	if !bytes.Equal(key, []byte{0x12, 0x34}) {
+		t.Errorf("unexpected key: %x", key)
+	}
+}
+
+func TestSonyflakeMachineAffinityKey(t *testing.T) {
+	sf, err := New(Settings{
+		StartTime:     time.Now(),
+		BitsSequence:  12,
+		BitsMachineID: 10,
+		FieldOrder:    FieldOrderMachineIDFirst,
+		MachineID:     func() (uint16, error) { return 0x2ab, nil },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	id, err := sf.NextID()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	key := sf.MachineAffinityKey(id)
+	if !bytes.Equal(key, []byte{0x02, 0xab}) {
+		t.Errorf("unexpected key: %x", key)
+	}
+	if sf.MachinePartitionFor(id, 4) != partitionFor(key, 4) {
+		t.Error("unexpected partition")
+	}
+}
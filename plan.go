@@ -0,0 +1,73 @@
+package sonyflake
+
+import (
+	"errors"
+	"math/bits"
+	"time"
+)
+
+// ErrNoPlan is returned by Plan if no layout satisfies the Requirements.
+var ErrNoPlan = errors.New("no layout satisfies the requirements")
+
+// Requirements is the capacity which a layout planned by Plan must provide:
+//
+// Lifetime is the duration from the start time during which IDs can be generated.
+//
+// MaxRate is the maximum number of IDs generated per second by an instance.
+//
+// Machines is the number of instances, each of which has a distinct machine ID.
+type Requirements struct {
+	Lifetime time.Duration
+	MaxRate  int
+	Machines int
+}
+
+// planTimeUnits are the time units tried by Plan in order of preference.
+var planTimeUnits = []time.Duration{sonyflakeTimeUnit, time.Millisecond}
+
+// Plan returns Settings of a layout satisfying the given Requirements.
+// Plan gives the machine ID and the sequence number as few bits as required and the rest to the time,
+// preferring the default time unit, 10 msec, to 1 msec.
+// Plan returns ErrNoPlan if no layout satisfies the Requirements.
+func Plan(r Requirements) (Settings, error) {
+	bitsMachineID := bitLen(uint64(r.Machines))
+	if bitsMachineID > BitLenMachineID {
+		return Settings{}, ErrNoPlan
+	}
+
+	for _, timeUnit := range planTimeUnits {
+		perTimeUnit := (uint64(r.MaxRate)*uint64(timeUnit) + uint64(time.Second) - 1) / uint64(time.Second)
+		bitsSequence := bitLen(perTimeUnit)
+		bitsTime := 63 - bitsSequence - bitsMachineID
+		if bitsTime < 32 {
+			continue
+		}
+
+		elapsedTime := (uint64(r.Lifetime) + uint64(timeUnit) - 1) / uint64(timeUnit)
+		if elapsedTime > 1<<bitsTime {
+			continue
+		}
+
+		st := Settings{BitsSequence: bitsSequence, BitsMachineID: bitsMachineID}
+		if timeUnit != sonyflakeTimeUnit {
+			st.TimeUnit = timeUnit
+		}
+		return st, nil
+	}
+	return Settings{}, ErrNoPlan
+}
+
+// bitLen returns the number of bits to represent n distinct values, which is at least 1.
+func bitLen(n uint64) int {
+	if n <= 2 {
+		return 1
+	}
+	return bits.Len64(n - 1)
+}
+
+// TimeLimit returns the time at which the time part of IDs in the Layout overflows,
+// after which no more IDs can be generated.
+func (l Layout) TimeLimit() time.Time {
+	shiftTime, _, _, _ := l.shifts()
+	return l.Time(uint64(1<<l.BitsTime-1) << shiftTime).Add(l.TimeUnit)
+}
@@ -0,0 +1,69 @@
+package sonyflake
+
+import (
+	"testing"
+	"time"
+)
+
+const year = 365 * 24 * time.Hour
+
+func TestPlan(t *testing.T) {
+	tests := []struct {
+		name     string
+		r        Requirements
+		expected Settings
+	}{
+		{
+			name:     "default",
+			r:        Requirements{Lifetime: 174 * year, MaxRate: 25600, Machines: 65536},
+			expected: Settings{BitsSequence: 8, BitsMachineID: 16},
+		},
+		{
+			name:     "high throughput",
+			r:        Requirements{Lifetime: 69 * year, MaxRate: 4096000, Machines: 1000},
+			expected: Settings{BitsSequence: 12, BitsMachineID: 10, TimeUnit: time.Millisecond},
+		},
+		{
+			name:     "small",
+			r:        Requirements{Lifetime: year, MaxRate: 1, Machines: 1},
+			expected: Settings{BitsSequence: 1, BitsMachineID: 1},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			st, err := Plan(tc.r)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if st.BitsSequence != tc.expected.BitsSequence ||
+				st.BitsMachineID != tc.expected.BitsMachineID ||
+				st.TimeUnit != tc.expected.TimeUnit {
+				t.Errorf("unexpected settings: %+v", st)
+			}
+		})
+	}
+}
+
+func TestPlanImpossible(t *testing.T) {
+	for _, r := range []Requirements{
+		{Lifetime: year, MaxRate: 1, Machines: 1 << 17},
+		{Lifetime: 200 * year, MaxRate: 4096000, Machines: 1024},
+	} {
+		if _, err := Plan(r); err != ErrNoPlan {
+			t.Errorf("unexpected error for %+v: %v", r, err)
+		}
+	}
+}
+
+func TestTimeLimit(t *testing.T) {
+	l, err := PresetDefault().Layout()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := l.StartTime.Add(time.Duration(1<<39) * sonyflakeTimeUnit)
+	if limit := l.TimeLimit(); !limit.Equal(expected) {
+		t.Errorf("unexpected time limit: %s", limit)
+	}
+}
@@ -0,0 +1,46 @@
+package sonyflake
+
+import "time"
+
+// PresetDefault returns Settings of the default layout:
+//
+//	39 bits for time in units of 10 msec
+//	 8 bits for a sequence number
+//	16 bits for a machine id
+//
+// It generates up to 25600 IDs per second per instance for about 174 years from the start time,
+// on up to 65536 instances.
+func PresetDefault() Settings {
+	return Settings{}
+}
+
+// PresetHighThroughput returns Settings of a layout for high generation rates:
+//
+//	41 bits for time in units of 1 msec
+//	12 bits for a sequence number
+//	10 bits for a machine id
+//
+// It generates up to 4096000 IDs per second per instance for about 69 years from the start time,
+// but only on up to 1024 instances.
+func PresetHighThroughput() Settings {
+	return Settings{
+		BitsSequence:  12,
+		BitsMachineID: 10,
+		TimeUnit:      time.Millisecond,
+	}
+}
+
+// PresetLongLifetime returns Settings of a layout for long lifetimes:
+//
+//	42 bits for time in units of 10 msec
+//	 7 bits for a sequence number
+//	14 bits for a machine id
+//
+// It generates IDs for about 1394 years from the start time,
+// but only up to 12800 IDs per second per instance on up to 16384 instances.
+func PresetLongLifetime() Settings {
+	return Settings{
+		BitsSequence:  7,
+		BitsMachineID: 14,
+	}
+}
@@ -0,0 +1,34 @@
+package sonyflake
+
+import "testing"
+
+func TestPresets(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings Settings
+	}{
+		{name: "default", settings: PresetDefault()},
+		{name: "high throughput", settings: PresetHighThroughput()},
+		{name: "long lifetime", settings: PresetLongLifetime()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			st := test.settings
+			st.MachineID = func() (uint16, error) { return 1, nil }
+
+			sf, err := New(st)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			id, err := sf.NextID()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if sf.Decompose(id)["machine-id"] != 1 {
+				t.Errorf("unexpected machine id: %d", sf.Decompose(id)["machine-id"])
+			}
+		})
+	}
+}
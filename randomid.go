@@ -0,0 +1,50 @@
+package sonyflake
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// PersistentRandomMachineID returns a function usable as Settings.MachineID,
+// which returns a random machine ID of bitsMachineID bits persisted in the file at the given path.
+// The first call generates the machine ID by crypto/rand and writes it to the file if the file does not exist,
+// so that the instance keeps its machine ID across restarts.
+// If bitsMachineID is 0, it is BitLenMachineID.
+//
+// It suits runtimes without network interfaces, such as wasip1 with a preopened directory.
+// Since the machine IDs are random, they collide with a probability of about n*n/2^(bitsMachineID+1) among n instances.
+func PersistentRandomMachineID(path string, bitsMachineID int) func() (uint16, error) {
+	if bitsMachineID == 0 {
+		bitsMachineID = BitLenMachineID
+	}
+	max := uint16(1<<bitsMachineID - 1)
+
+	return func() (uint16, error) {
+		b, err := ioutil.ReadFile(path)
+		if err == nil {
+			n, err := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 16)
+			if err != nil || uint16(n) > max {
+				return 0, fmt.Errorf("%w in %s: %q", ErrInvalidMachineID, path, strings.TrimSpace(string(b)))
+			}
+			return uint16(n), nil
+		}
+		if !os.IsNotExist(err) {
+			return 0, err
+		}
+
+		var r [2]byte
+		if _, err := rand.Read(r[:]); err != nil {
+			return 0, err
+		}
+		machineID := binary.BigEndian.Uint16(r[:]) & max
+		if err := ioutil.WriteFile(path, []byte(strconv.FormatUint(uint64(machineID), 10)+"\n"), 0644); err != nil {
+			return 0, err
+		}
+		return machineID, nil
+	}
+}
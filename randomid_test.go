@@ -0,0 +1,50 @@
+package sonyflake
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPersistentRandomMachineID(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sonyflake")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "machine-id")
+	machineID := PersistentRandomMachineID(path, 10)
+	id, err := machineID()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if id >= 1<<10 {
+		t.Errorf("machine id must fit in 10 bits: %d", id)
+	}
+
+	again, err := PersistentRandomMachineID(path, 10)()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if again != id {
+		t.Errorf("machine id must be persisted: %d != %d", again, id)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("2048\n"), 0644); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := machineID(); !errors.Is(err, ErrInvalidMachineID) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	sf, err := New(Settings{MachineID: PersistentRandomMachineID(filepath.Join(dir, "default"), 0)})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := sf.NextID(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,91 @@
+package sonyflake
+
+import (
+	"sync"
+	"time"
+)
+
+// CheckPolicy is how NextID behaves after Settings.CheckMachineID fails on a recheck.
+type CheckPolicy int
+
+const (
+	// CheckPolicyError makes NextID return ErrMachineIDCheckFailed while the latest recheck fails
+	// and resume issuing IDs once a later recheck passes.
+	CheckPolicyError CheckPolicy = iota
+	// CheckPolicyHalt makes NextID return ErrMachineIDCheckFailed after a recheck fails
+	// until a new machine ID is set by SetMachineID.
+	CheckPolicyHalt
+)
+
+// recheckMachineID calls Settings.CheckMachineID on the current machine ID
+// and records the result according to the CheckPolicy.
+// The Sonyflake must be locked.
+func (sf *Sonyflake) recheckMachineID() {
+	sf.applyCheck(sf.checkMachineID(sf.machineID))
+}
+
+func (sf *Sonyflake) applyCheck(ok bool) {
+	if !ok {
+		sf.checkFailed = true
+	} else if sf.checkPolicy == CheckPolicyError {
+		sf.checkFailed = false
+	}
+}
+
+// countRecheck calls recheckMachineID before every Settings.RecheckEvery calls of NextID.
+// The calls of NextID of a Sonyflake with a base are counted by the base.
+// The Sonyflake must be locked.
+func (sf *Sonyflake) countRecheck() {
+	if sf.base != nil {
+		sf.base.mutex.Lock()
+		if !sf.base.closed {
+			sf.base.countRecheck()
+		}
+		sf.base.mutex.Unlock()
+		return
+	}
+	if sf.recheckEvery == 0 || sf.checkMachineID == nil {
+		return
+	}
+	sf.recheckCalls++
+	if sf.recheckCalls >= sf.recheckEvery {
+		sf.recheckCalls = 0
+		sf.recheckMachineID()
+	}
+}
+
+// startRecheck calls Settings.CheckMachineID every interval in a new goroutine until Close is called.
+// Unlike countRecheck, CheckMachineID is called without the Sonyflake locked.
+func (sf *Sonyflake) startRecheck(interval time.Duration) {
+	done := make(chan struct{})
+	var once sync.Once
+	sf.onClose(func() error {
+		once.Do(func() { close(done) })
+		return nil
+	})
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			sf.mutex.Lock()
+			machineID := sf.machineID
+			sf.mutex.Unlock()
+
+			ok := sf.checkMachineID(machineID)
+
+			sf.mutex.Lock()
+			if sf.machineID == machineID { // the result is stale if SetMachineID has been called meanwhile
+				sf.applyCheck(ok)
+			}
+			sf.mutex.Unlock()
+		}
+	}()
+}
@@ -0,0 +1,89 @@
+package sonyflake
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRecheckEvery(t *testing.T) {
+	for _, policy := range []CheckPolicy{CheckPolicyError, CheckPolicyHalt} {
+		valid := true
+		sf, err := New(Settings{
+			MachineID:      func() (uint16, error) { return 1, nil },
+			CheckMachineID: func(uint16) bool { return valid },
+			RecheckEvery:   2,
+			RecheckPolicy:  policy,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if _, err := sf.NextID(); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		valid = false
+		if _, err := sf.NextID(); err != ErrMachineIDCheckFailed {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if err := sf.Err(); err != ErrMachineIDCheckFailed {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		valid = true
+		sf.NextID()
+		_, err = sf.NextID()
+		switch policy {
+		case CheckPolicyError:
+			if err != nil {
+				t.Errorf("issuance must resume after a passing recheck: %v", err)
+			}
+		case CheckPolicyHalt:
+			if err != ErrMachineIDCheckFailed {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if err := sf.SetMachineID(2, nil); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if _, err := sf.NextID(); err != nil {
+				t.Errorf("issuance must resume after SetMachineID: %v", err)
+			}
+		}
+	}
+}
+
+func TestRecheckInterval(t *testing.T) {
+	var valid int32 = 1
+	sf, err := New(Settings{
+		MachineID:       func() (uint16, error) { return 1, nil },
+		CheckMachineID:  func(uint16) bool { return atomic.LoadInt32(&valid) == 1 },
+		RecheckInterval: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer sf.Close()
+
+	atomic.StoreInt32(&valid, 0)
+	deadline := time.Now().Add(time.Second)
+	for sf.Err() != ErrMachineIDCheckFailed {
+		if time.Now().After(deadline) {
+			t.Fatal("recheck did not fail")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := sf.NextID(); err != ErrMachineIDCheckFailed {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestInvalidRecheck(t *testing.T) {
+	for _, st := range []Settings{
+		{RecheckInterval: -time.Second},
+		{RecheckPolicy: CheckPolicyHalt + 1},
+	} {
+		if err := st.Validate(); err != ErrInvalidRecheck {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package sonyflake
+
+import (
+	"errors"
+	"math/big"
+)
+
+// ErrTimeNotRepresentable is returned by ReEpoch if the time of an ID cannot be represented exactly in the new layout.
+var ErrTimeNotRepresentable = errors.New("time not representable in the layout")
+
+// ReEpoch translates the given ID generated with oldSettings into the ID of the same time and parts under newSettings,
+// such as a later StartTime, so that long-lived systems can rotate the epoch before the time overflows.
+//
+// The translation is collision-safe: distinct IDs are never translated into the same ID.
+// ReEpoch returns an error in the following cases:
+// - oldSettings or newSettings is invalid.
+// - The ID is invalid in the old layout, such as one with the most significant bit set (ErrInvalidID).
+// - The time of the ID is not a multiple of the new time unit after the new start time (ErrTimeNotRepresentable).
+// - The time of the ID is over the time limit of the new layout (ErrOverTimeLimit).
+// - The sequence number, the namespace ID or the machine ID does not fit in its new bit length.
+func ReEpoch(id uint64, oldSettings, newSettings Settings) (uint64, error) {
+	oldLayout, err := oldSettings.Layout()
+	if err != nil {
+		return 0, err
+	}
+	newLayout, err := newSettings.Layout()
+	if err != nil {
+		return 0, err
+	}
+	if err := oldLayout.ValidateID(id); err != nil {
+		return 0, err
+	}
+
+	parts := oldLayout.Decompose(id)
+
+	// elapsed time in nsec since the new start time, which may overflow int64
+	elapsed := new(big.Int).Mul(new(big.Int).SetUint64(parts["time"]), big.NewInt(int64(oldLayout.TimeUnit)))
+	elapsed.Add(elapsed, big.NewInt(oldLayout.StartTime.UnixNano()-newLayout.StartTime.UnixNano()))
+	if elapsed.Sign() < 0 {
+		return 0, ErrTimeNotRepresentable
+	}
+	elapsedTime, rem := new(big.Int).QuoRem(elapsed, big.NewInt(int64(newLayout.TimeUnit)), new(big.Int))
+	if rem.Sign() != 0 {
+		return 0, ErrTimeNotRepresentable
+	}
+	if elapsedTime.BitLen() > newLayout.BitsTime {
+		return 0, ErrOverTimeLimit
+	}
+
+	if parts["sequence"] >= 1<<newLayout.BitsSequence {
+		return 0, ErrInvalidSequence
+	}
+	if parts["namespace"] >= 1<<newLayout.BitsNamespace {
+		return 0, ErrInvalidNamespaceID
+	}
+	if parts["machine-id"] >= 1<<newLayout.BitsMachineID {
+		return 0, &InvalidMachineIDError{Got: uint16(parts["machine-id"]), Max: uint16(1<<newLayout.BitsMachineID - 1)}
+	}
+
+	return newLayout.compose(elapsedTime.Uint64(), parts["sequence"], parts["namespace"], parts["machine-id"]), nil
+}
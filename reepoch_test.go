@@ -0,0 +1,88 @@
+package sonyflake
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReEpoch(t *testing.T) {
+	oldSettings := Settings{
+		StartTime: time.Date(2014, 9, 1, 0, 0, 0, 0, time.UTC),
+		MachineID: func() (uint16, error) { return 0x234, nil },
+	}
+	newSettings := Settings{
+		StartTime:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		MachineID:     func() (uint16, error) { return 0x234, nil },
+		BitsSequence:  12,
+		TimeUnit:      time.Millisecond,
+		FieldOrder:    FieldOrderMachineIDFirst,
+		BitsMachineID: 10,
+	}
+	oldSF, err := New(oldSettings)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	newSF, err := New(newSettings)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	at := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	id, err := oldSF.Compose(at, 200, 0x234)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected, err := newSF.Compose(at, 200, 0x234)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	translated, err := ReEpoch(id, oldSettings, newSettings)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if translated != expected {
+		t.Errorf("unexpected id: %d, expected %d", translated, expected)
+	}
+
+	back, err := ReEpoch(translated, newSettings, oldSettings)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if back != id {
+		t.Errorf("unexpected id: %d, expected %d", back, id)
+	}
+}
+
+func TestReEpochError(t *testing.T) {
+	oldSettings := Settings{StartTime: time.Date(2014, 9, 1, 0, 0, 0, 0, time.UTC)}
+	layout, err := oldSettings.Layout()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	id := layout.compose(5*365*24*360000, 255, 0, 0xffff)
+
+	testCases := []struct {
+		name        string
+		id          uint64
+		newSettings Settings
+		err         error
+	}{
+		{"before start time", id, Settings{StartTime: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}, ErrTimeNotRepresentable},
+		{"coarser time unit", layout.compose(1, 0, 0, 0), Settings{TimeUnit: time.Second}, ErrTimeNotRepresentable},
+		{"over time limit", id, Settings{StartTime: oldSettings.StartTime, TimeUnit: time.Millisecond, BitsSequence: 14}, ErrOverTimeLimit},
+		{"sequence", id, Settings{BitsSequence: 7}, ErrInvalidSequence},
+		{"machine id", id, Settings{BitsMachineID: 15}, ErrInvalidMachineID},
+		{"most significant bit", id | 1<<63, Settings{}, ErrInvalidID},
+		{"invalid settings", id, Settings{BitsMachineID: 17}, ErrInvalidBitsMachineID},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ReEpoch(tc.id, oldSettings, tc.newSettings); !errors.Is(err, tc.err) {
+				t.Errorf("unexpected error: %v, expected %v", err, tc.err)
+			}
+		})
+	}
+}
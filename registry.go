@@ -0,0 +1,122 @@
+package sonyflake
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	ErrDuplicateName = errors.New("duplicate generator name")
+	ErrUnknownName   = errors.New("unknown generator name")
+)
+
+// Registry owns multiple Sonyflakes keyed by name, such as "orders" and "events".
+// Each name is a separate ID space, so Sonyflakes of different names may share a machine ID.
+type Registry struct {
+	mutex      sync.RWMutex
+	generators map[string]*Sonyflake
+	counts     map[string]*uint64 // accessed atomically
+}
+
+// NewRegistry returns a new empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		generators: make(map[string]*Sonyflake),
+		counts:     make(map[string]*uint64),
+	}
+}
+
+// Register creates a new Sonyflake configured with the given Settings under the given name.
+// Register returns ErrDuplicateName if the name is already registered,
+// or the error of New if the Sonyflake is not created.
+func (r *Registry) Register(name string, st Settings) (*Sonyflake, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.generators[name]; ok {
+		return nil, ErrDuplicateName
+	}
+
+	sf, err := New(st)
+	if err != nil {
+		return nil, err
+	}
+
+	r.generators[name] = sf
+	r.counts[name] = new(uint64)
+	return sf, nil
+}
+
+// Get returns the Sonyflake registered under the given name.
+func (r *Registry) Get(name string) (*Sonyflake, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	sf, ok := r.generators[name]
+	return sf, ok
+}
+
+// Names returns the registered names in sorted order.
+func (r *Registry) Names() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	names := make([]string, 0, len(r.generators))
+	for name := range r.generators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NextID generates a next unique ID by the Sonyflake registered under the given name.
+// NextID returns ErrUnknownName if the name is not registered.
+func (r *Registry) NextID(name string) (uint64, error) {
+	r.mutex.RLock()
+	sf, ok := r.generators[name]
+	count := r.counts[name]
+	r.mutex.RUnlock()
+	if !ok {
+		return 0, ErrUnknownName
+	}
+
+	id, err := sf.NextID()
+	if err != nil {
+		return 0, err
+	}
+
+	atomic.AddUint64(count, 1)
+	return id, nil
+}
+
+// Stats returns the number of IDs generated through the Registry by name.
+func (r *Registry) Stats() map[string]uint64 {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	stats := make(map[string]uint64, len(r.counts))
+	for name, count := range r.counts {
+		stats[name] = atomic.LoadUint64(count)
+	}
+	return stats
+}
+
+// Close closes all the registered Sonyflakes and unregisters them.
+// Close returns the first error of closing them, if any.
+func (r *Registry) Close() error {
+	r.mutex.Lock()
+	generators := r.generators
+	r.generators = make(map[string]*Sonyflake)
+	r.counts = make(map[string]*uint64)
+	r.mutex.Unlock()
+
+	var err error
+	for _, sf := range generators {
+		if cerr := sf.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,53 @@
+package sonyflake
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+
+	st := Settings{MachineID: func() (uint16, error) { return 1, nil }}
+	for _, name := range []string{"orders", "events"} {
+		if _, err := r.Register(name, st); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if _, err := r.Register("orders", st); !errors.Is(err, ErrDuplicateName) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if names := r.Names(); len(names) != 2 || names[0] != "events" || names[1] != "orders" {
+		t.Errorf("unexpected names: %v", names)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := r.NextID("orders"); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	if _, err := r.NextID("users"); !errors.Is(err, ErrUnknownName) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if stats := r.Stats(); stats["orders"] != 3 || stats["events"] != 0 {
+		t.Errorf("unexpected stats: %v", stats)
+	}
+
+	sf, ok := r.Get("orders")
+	if !ok {
+		t.Fatal("generator not registered")
+	}
+
+	if err := r.Close(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if _, err := sf.NextID(); !errors.Is(err, ErrClosed) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(r.Names()) != 0 {
+		t.Errorf("unexpected names: %v", r.Names())
+	}
+}
@@ -0,0 +1,93 @@
+package sonyflake
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReplayGenerator(t *testing.T) {
+	for _, order := range []FieldOrder{FieldOrderSequenceFirst, FieldOrderMachineIDFirst} {
+		st := Settings{
+			StartTime:     time.Now().Add(-time.Hour),
+			MachineID:     func() (uint16, error) { return 0x2ab, nil },
+			BitsSequence:  12,
+			BitsNamespace: 2,
+			NamespaceID:   3,
+			BitsMachineID: 10,
+			TimeUnit:      time.Millisecond,
+			FieldOrder:    order,
+		}
+		sf, err := New(st)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		g, err := NewReplayGenerator(st)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if g.Layout() != sf.Layout() {
+			t.Errorf("unexpected layout: %+v", g.Layout())
+		}
+
+		at := time.Now()
+		id, err := g.ID(at, 0x2ab, 5)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		expected, err := sf.Compose(at, 5, 0x2ab)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if id != expected {
+			t.Errorf("unexpected id: %d, expected %d", id, expected)
+		}
+
+		again, err := g.ID(at, 0x2ab, 5)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if again != id {
+			t.Errorf("replayed id differs: %d != %d", again, id)
+		}
+
+		next, err := g.ID(at, 0x2ab, 6)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if next == id {
+			t.Errorf("next counter gives the same id: %d", next)
+		}
+	}
+}
+
+func TestReplayGeneratorError(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	g, err := NewReplayGenerator(Settings{StartTime: start})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := g.ID(start.Add(-time.Second), 1, 0); err != ErrStartTimeAhead {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := g.ID(start.Add(175*365*24*time.Hour), 1, 0); err != ErrOverTimeLimit {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := g.ID(start, 1, 256); err != ErrInvalidSequence {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	g, err = NewReplayGenerator(Settings{StartTime: start, BitsMachineID: 8, BitsSequence: 16})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var invalid *InvalidMachineIDError
+	if _, err := g.ID(start, 256, 0); !errors.As(err, &invalid) || invalid.Max != 255 {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := NewReplayGenerator(Settings{NamespaceID: 1}); err != ErrInvalidNamespaceID {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
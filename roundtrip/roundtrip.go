@@ -0,0 +1,167 @@
+// Package roundtrip checks the properties of composing and decomposing IDs of a Layout with randomized parts,
+// so that authors of custom layouts and of adapters of IDs to foreign formats can validate them in their own tests.
+//
+// Check composes IDs of random parts, including the zero and maximum values of each part,
+// by the bit math of the Layout independently of the Sonyflake, and asserts that:
+// - Decompose of the Layout returns the parts.
+// - Time of the Layout returns the start time plus the elapsed time.
+// - ValidateID of the Layout accepts the IDs.
+// - IDs of a later time are greater, whatever the other parts are.
+// - The Adapter, if any, returns the IDs unchanged.
+package roundtrip
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/sony/sonyflake"
+)
+
+// Parts are the parts of an ID.
+// ElapsedTime is in units of the time unit since the start time.
+type Parts struct {
+	ElapsedTime uint64
+	Sequence    uint64
+	Namespace   uint64
+	MachineID   uint64
+}
+
+// Settings configures Check:
+//
+// Iterations is the number of random IDs checked.
+// If Iterations is 0, it is 1000.
+//
+// Seed is the seed of the random parts, which Check reports on failure to reproduce it.
+// If Seed is 0, the current time is used.
+//
+// Adapter converts an ID to a foreign format and back, such as by encoding and decoding a database value.
+// If Adapter is nil, IDs are not converted.
+type Settings struct {
+	Iterations int
+	Seed       int64
+	Adapter    func(id uint64) (uint64, error)
+}
+
+const defaultIterations = 1000
+
+// Check asserts the round-trip properties of the given Layout with IDs of random parts.
+// It reports failures by Errorf of the given testing.TB and stops at the first one.
+// Check fails immediately if the Layout is invalid.
+func Check(t testing.TB, l sonyflake.Layout, st Settings) {
+	t.Helper()
+
+	if err := l.Validate(); err != nil {
+		t.Fatalf("invalid layout: %s", err)
+	}
+	if st.Iterations == 0 {
+		st.Iterations = defaultIterations
+	}
+	if st.Seed == 0 {
+		st.Seed = time.Now().UnixNano()
+	}
+	r := rand.New(rand.NewSource(st.Seed))
+
+	for i := 0; i < st.Iterations; i++ {
+		p := RandomParts(r, l)
+		if err := check(l, p, st.Adapter); err != "" {
+			t.Errorf("%s with parts %+v (seed %d)", err, p, st.Seed)
+			return
+		}
+	}
+}
+
+func check(l sonyflake.Layout, p Parts, adapter func(uint64) (uint64, error)) string {
+	id := Compose(l, p)
+
+	parts := l.Decompose(id)
+	if got := (Parts{parts["time"], parts["sequence"], parts["namespace"], parts["machine-id"]}); got != p {
+		return fmt.Sprintf("decomposed to %+v", got)
+	}
+	if err := l.ValidateID(id); err != nil {
+		return "rejected: " + err.Error()
+	}
+
+	at := l.Time(id)
+	if p.ElapsedTime <= uint64(math.MaxInt64/l.TimeUnit) {
+		if expected := l.StartTime.Add(time.Duration(p.ElapsedTime) * l.TimeUnit); !at.Equal(expected) {
+			return fmt.Sprintf("time is %s, expected %s", at.Format(time.RFC3339Nano), expected.Format(time.RFC3339Nano))
+		}
+	}
+
+	if p.ElapsedTime > 0 {
+		// the previous time unit with the maximum other parts
+		prev := Compose(l, Parts{
+			ElapsedTime: p.ElapsedTime - 1,
+			Sequence:    mask(l.BitsSequence),
+			Namespace:   mask(l.BitsNamespace),
+			MachineID:   mask(l.BitsMachineID),
+		})
+		if prev >= id {
+			return "id is not greater than that of the previous time unit"
+		}
+		if d := at.Sub(l.Time(prev)); d != l.TimeUnit {
+			return fmt.Sprintf("time is %s after that of the previous time unit", d)
+		}
+	}
+
+	if adapter != nil {
+		got, err := adapter(id)
+		if err != nil {
+			return "adapter failed: " + err.Error()
+		}
+		if got != id {
+			return fmt.Sprintf("adapter changed the id %d to %d", id, got)
+		}
+	}
+	return ""
+}
+
+// Compose returns the ID of the given parts in the given Layout.
+// The parts are masked to their bit lengths.
+func Compose(l sonyflake.Layout, p Parts) uint64 {
+	shiftMachineID := 0
+	shiftNamespace := l.BitsMachineID
+	shiftSequence := shiftNamespace + l.BitsNamespace
+	if l.FieldOrder == sonyflake.FieldOrderMachineIDFirst {
+		shiftSequence = 0
+		shiftMachineID = l.BitsSequence
+		shiftNamespace = shiftMachineID + l.BitsMachineID
+	}
+	shiftTime := l.BitsSequence + l.BitsNamespace + l.BitsMachineID
+
+	return p.ElapsedTime&mask(l.BitsTime)<<shiftTime |
+		p.Sequence&mask(l.BitsSequence)<<shiftSequence |
+		p.Namespace&mask(l.BitsNamespace)<<shiftNamespace |
+		p.MachineID&mask(l.BitsMachineID)<<shiftMachineID
+}
+
+// RandomParts returns random parts of an ID in the given Layout.
+// Each part is 0 or its maximum value with a probability of 1/8 each, so that edge cases are covered.
+func RandomParts(r *rand.Rand, l sonyflake.Layout) Parts {
+	return Parts{
+		ElapsedTime: randomPart(r, l.BitsTime),
+		Sequence:    randomPart(r, l.BitsSequence),
+		Namespace:   randomPart(r, l.BitsNamespace),
+		MachineID:   randomPart(r, l.BitsMachineID),
+	}
+}
+
+func randomPart(r *rand.Rand, bits int) uint64 {
+	switch r.Intn(8) {
+	case 0:
+		return 0
+	case 1:
+		return mask(bits)
+	}
+	return r.Uint64() & mask(bits)
+}
+
+func mask(bits int) uint64 {
+	if bits >= 64 {
+		return math.MaxUint64
+	}
+	return 1<<bits - 1
+}
@@ -0,0 +1,121 @@
+package roundtrip
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sony/sonyflake"
+)
+
+// recorder records the failures reported to it instead of failing the test.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestCheck(t *testing.T) {
+	settings := []sonyflake.Settings{
+		sonyflake.PresetDefault(),
+		sonyflake.PresetHighThroughput(),
+		sonyflake.PresetLongLifetime(),
+		{BitsSequence: 8, BitsNamespace: 4, BitsMachineID: 12},
+		{BitsSequence: 12, BitsNamespace: 2, BitsMachineID: 8, TimeUnit: time.Millisecond, FieldOrder: sonyflake.FieldOrderMachineIDFirst},
+		{UseMSB: true},
+	}
+	for _, st := range settings {
+		l, err := st.Layout()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		Check(t, l, Settings{})
+	}
+}
+
+func TestCheckAdapter(t *testing.T) {
+	l, err := sonyflake.Settings{UseMSB: true}.Layout()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// an adapter storing IDs as int64 loses those with the most significant bit
+	adapter := func(id uint64) (uint64, error) {
+		v := int64(id)
+		if v < 0 {
+			return 0, errors.New("negative")
+		}
+		return uint64(v), nil
+	}
+	r := &recorder{TB: t}
+	Check(r, l, Settings{Seed: 1, Adapter: adapter})
+	if len(r.errors) != 1 || !strings.HasPrefix(r.errors[0], "adapter failed: negative") {
+		t.Errorf("unexpected failures: %v", r.errors)
+	}
+
+	l.UseMSB = false
+	l.BitsTime--
+	r = &recorder{TB: t}
+	Check(r, l, Settings{Seed: 1, Adapter: adapter})
+	if len(r.errors) != 0 {
+		t.Errorf("unexpected failures: %v", r.errors)
+	}
+}
+
+func TestCompose(t *testing.T) {
+	for _, order := range []sonyflake.FieldOrder{sonyflake.FieldOrderSequenceFirst, sonyflake.FieldOrderMachineIDFirst} {
+		st := sonyflake.Settings{
+			StartTime:     time.Now().Add(-time.Hour),
+			MachineID:     func() (uint16, error) { return 0x2ab, nil },
+			BitsSequence:  12,
+			BitsNamespace: 2,
+			NamespaceID:   3,
+			BitsMachineID: 10,
+			TimeUnit:      time.Millisecond,
+			FieldOrder:    order,
+		}
+		sf, err := sonyflake.New(st)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		at := time.Now()
+		expected, err := sf.Compose(at, 5, 0x2ab)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		p := Parts{ElapsedTime: uint64(sf.ElapsedTime(expected) / time.Millisecond), Sequence: 5, Namespace: 3, MachineID: 0x2ab}
+		if id := Compose(sf.Layout(), p); id != expected {
+			t.Errorf("unexpected id: %d, expected %d", id, expected)
+		}
+	}
+}
+
+func TestRandomParts(t *testing.T) {
+	l, err := sonyflake.Settings{}.Layout()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	r := rand.New(rand.NewSource(1))
+	var zero, max bool
+	for i := 0; i < 1000; i++ {
+		p := RandomParts(r, l)
+		if p.Sequence >= 1<<8 || p.MachineID >= 1<<16 || p.ElapsedTime >= 1<<39 || p.Namespace != 0 {
+			t.Fatalf("parts out of range: %+v", p)
+		}
+		zero = zero || p.Sequence == 0
+		max = max || p.Sequence == 1<<8-1
+	}
+	if !zero || !max {
+		t.Errorf("edge cases are not covered: zero %t, max %t", zero, max)
+	}
+}
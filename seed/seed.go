@@ -0,0 +1,104 @@
+// Package seed pre-generates Sonyflake IDs into seed files and verifies them,
+// for offline or air-gapped batch jobs which cannot run a Sonyflake themselves.
+//
+// A seed file holds IDs in decimal, one per line, in the order in which they were generated.
+// Since a seed file is generated by a single Sonyflake, its IDs must be strictly increasing.
+package seed
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/sony/sonyflake"
+	"github.com/sony/sonyflake/audit"
+)
+
+// Generate writes n IDs generated by sf to w as a seed file.
+func Generate(w io.Writer, sf *sonyflake.Sonyflake, n int) error {
+	bw := bufio.NewWriter(w)
+	var b []byte
+	for i := 0; i < n; i++ {
+		id, err := sf.NextID()
+		if err != nil {
+			return err
+		}
+		b = strconv.AppendUint(b[:0], id, 10)
+		b = append(b, '\n')
+		if _, err := bw.Write(b); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// Report is the result of Verify.
+//
+// Report embeds the audit.Report of the IDs against the layout and uniqueness.
+//
+// Unordered lists the IDs not greater than their previous ID in the seed file.
+//
+// Want is the expected number of IDs, or 0 if any number is expected.
+type Report struct {
+	audit.Report
+	Unordered []uint64
+	Want      int
+}
+
+// OK returns true if the verification found no problem.
+func (r Report) OK() bool {
+	return r.Report.OK() && len(r.Unordered) == 0 && (r.Want == 0 || r.Total == r.Want)
+}
+
+// WriteTo writes a human-readable summary of the Report to w.
+func (r Report) WriteTo(w io.Writer) (int64, error) {
+	n, err := r.Report.WriteTo(w)
+	if err != nil {
+		return n, err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "unordered: %d\n", len(r.Unordered))
+	for _, id := range r.Unordered {
+		fmt.Fprintf(&b, "  %d\n", id)
+	}
+	if r.Want != 0 && r.Total != r.Want {
+		fmt.Fprintf(&b, "expected: %d ids\n", r.Want)
+	}
+
+	m, err := io.WriteString(w, b.String())
+	return n + int64(m), err
+}
+
+// Verify verifies the seed file read from r against the given audit.Settings,
+// and checks that its IDs are strictly increasing and, if want is not 0, that it holds want IDs.
+// Verify returns an error if r cannot be read or a line is not a decimal ID.
+func Verify(r io.Reader, st audit.Settings, want int) (Report, error) {
+	a, err := audit.New(st)
+	if err != nil {
+		return Report{}, err
+	}
+	report := Report{Want: want}
+
+	var last uint64
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		id, err := strconv.ParseUint(scanner.Text(), 10, 64)
+		if err != nil {
+			return Report{}, fmt.Errorf("line %d: %w", line, err)
+		}
+		if line > 1 && id <= last {
+			report.Unordered = append(report.Unordered, id)
+		}
+		last = id
+		a.Add(id)
+	}
+	if err := scanner.Err(); err != nil {
+		return Report{}, err
+	}
+
+	report.Report = a.Report()
+	return report, nil
+}
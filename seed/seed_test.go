@@ -0,0 +1,86 @@
+package seed
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sony/sonyflake"
+	"github.com/sony/sonyflake/audit"
+)
+
+func newSonyflake(t *testing.T) *sonyflake.Sonyflake {
+	sf, err := sonyflake.New(sonyflake.Settings{
+		MachineID: func() (uint16, error) { return 1, nil },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return sf
+}
+
+func TestGenerateVerify(t *testing.T) {
+	sf := newSonyflake(t)
+
+	var b bytes.Buffer
+	if err := Generate(&b, sf, 1000); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if lines := strings.Count(b.String(), "\n"); lines != 1000 {
+		t.Errorf("unexpected number of lines: %d", lines)
+	}
+
+	report, err := Verify(&b, audit.Settings{Layout: sf.Layout()}, 1000)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !report.OK() || report.Total != 1000 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+}
+
+func TestVerifyFindings(t *testing.T) {
+	sf := newSonyflake(t)
+	first, err := sf.NextID()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	second, err := sf.NextID()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var b bytes.Buffer
+	for _, id := range []uint64{second, first, first} {
+		b.WriteString(sonyflake.ID(id).String() + "\n")
+	}
+
+	report, err := Verify(&b, audit.Settings{Layout: sf.Layout()}, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if report.OK() {
+		t.Errorf("unexpected ok report: %+v", report)
+	}
+	if len(report.Unordered) != 2 {
+		t.Errorf("unexpected unordered ids: %v", report.Unordered)
+	}
+	if len(report.Duplicates) != 1 || report.Duplicates[0] != first {
+		t.Errorf("unexpected duplicates: %v", report.Duplicates)
+	}
+
+	var out bytes.Buffer
+	if _, err := report.WriteTo(&out); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.Contains(out.String(), "unordered: 2\n") || !strings.Contains(out.String(), "expected: 2 ids\n") {
+		t.Errorf("unexpected summary: %s", out.String())
+	}
+}
+
+func TestVerifyInvalidLine(t *testing.T) {
+	sf := newSonyflake(t)
+	if _, err := Verify(strings.NewReader("1\nx\n"), audit.Settings{Layout: sf.Layout()}, 0); err == nil {
+		t.Errorf("error expected")
+	}
+}
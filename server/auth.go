@@ -0,0 +1,127 @@
+package server
+
+import (
+	"crypto/subtle"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APIKeys returns a function usable as Settings.Authenticate,
+// which accepts requests with one of the given API keys and identifies their clients by the names of the keys.
+// The API key is read from the X-API-Key header or the bearer token of the Authorization header.
+func APIKeys(keys map[string]string) func(r *http.Request) (client string, ok bool) {
+	return func(r *http.Request) (string, bool) {
+		key := r.Header.Get("X-API-Key")
+		if key == "" {
+			auth := r.Header.Get("Authorization")
+			if !strings.HasPrefix(auth, "Bearer ") {
+				return "", false
+			}
+			key = strings.TrimPrefix(auth, "Bearer ")
+		}
+
+		for k, client := range keys {
+			if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+				return client, true
+			}
+		}
+		return "", false
+	}
+}
+
+// guard authenticates and rate-limits requests before passing them to next.
+func (s *Server) guard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var client string
+		if s.st.Authenticate != nil {
+			var ok bool
+			client, ok = s.st.Authenticate(r)
+			if !ok {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+		} else {
+			client = remoteIP(r)
+		}
+
+		if s.limiter != nil {
+			if wait := s.limiter.reserve(client, time.Now()); wait > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// limiter is a set of token buckets keyed by client.
+type limiter struct {
+	mutex   sync.Mutex
+	rate    float64
+	burst   float64
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// maxBuckets is the number of buckets above which full buckets are pruned,
+// so that clients identified by remote addresses do not exhaust memory.
+const maxBuckets = 10000
+
+func newLimiter(rate float64, burst int) *limiter {
+	if burst <= 0 {
+		burst = 1
+	}
+	return &limiter{rate: rate, burst: float64(burst), buckets: make(map[string]*bucket)}
+}
+
+// reserve takes a token of the client at the given time.
+// reserve returns 0 if a token is taken, or the wait until a token is available otherwise.
+func (l *limiter) reserve(client string, now time.Time) time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	b, ok := l.buckets[client]
+	if !ok {
+		if len(l.buckets) >= maxBuckets {
+			l.prune(now)
+		}
+		b = &bucket{tokens: l.burst, updated: now}
+		l.buckets[client] = b
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.updated).Seconds()*l.rate)
+	b.updated = now
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return 0
+}
+
+// prune removes the buckets which would be full at the given time, as they are equivalent to new ones.
+func (l *limiter) prune(now time.Time) {
+	for client, b := range l.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, client)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sony/sonyflake"
+)
+
+func TestAPIKeys(t *testing.T) {
+	authenticate := APIKeys(map[string]string{"key1": "team1", "key2": "team2"})
+
+	testCases := []struct {
+		header string
+		value  string
+		client string
+		ok     bool
+	}{
+		{"X-API-Key", "key1", "team1", true},
+		{"Authorization", "Bearer key2", "team2", true},
+		{"Authorization", "Basic key2", "", false},
+		{"X-API-Key", "key3", "", false},
+		{"", "", "", false},
+	}
+
+	for _, tc := range testCases {
+		r := httptest.NewRequest(http.MethodGet, "/id", nil)
+		if tc.header != "" {
+			r.Header.Set(tc.header, tc.value)
+		}
+		client, ok := authenticate(r)
+		if client != tc.client || ok != tc.ok {
+			t.Errorf("unexpected result of %s: %s: %q, %t", tc.header, tc.value, client, ok)
+		}
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	s := New(newSonyflake(t, sonyflake.Settings{}), Settings{
+		Authenticate: APIKeys(map[string]string{"key1": "team1"}),
+	})
+
+	serve := func(target, key string) int {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		if key != "" {
+			r.Header.Set("X-API-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, r)
+		return rec.Code
+	}
+
+	if code := serve("/id", "key1"); code != http.StatusOK {
+		t.Errorf("unexpected status: %d", code)
+	}
+	if code := serve("/id", "wrong"); code != http.StatusUnauthorized {
+		t.Errorf("unexpected status: %d", code)
+	}
+	if code := serve("/healthz", ""); code != http.StatusOK {
+		t.Errorf("probes must not require authentication: %d", code)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	s := New(newSonyflake(t, sonyflake.Settings{}), Settings{
+		Authenticate: func(r *http.Request) (string, bool) { return r.Header.Get("X-Client"), true },
+		RateLimit:    0.5,
+		RateBurst:    2,
+	})
+
+	serve := func(client string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/id", nil)
+		r.Header.Set("X-Client", client)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, r)
+		return rec
+	}
+
+	for i := 0; i < 2; i++ {
+		if rec := serve("a"); rec.Code != http.StatusOK {
+			t.Fatalf("unexpected status: %d", rec.Code)
+		}
+	}
+	rec := serve("a")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("unexpected status: %d", rec.Code)
+	}
+	if retryAfter, _ := strconv.Atoi(rec.Header().Get("Retry-After")); retryAfter < 1 || retryAfter > 2 {
+		t.Errorf("unexpected Retry-After: %s", rec.Header().Get("Retry-After"))
+	}
+
+	if rec := serve("b"); rec.Code != http.StatusOK {
+		t.Errorf("clients must be limited separately: %d", rec.Code)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter(10, 0)
+	now := time.Now()
+
+	if wait := l.reserve("a", now); wait != 0 {
+		t.Errorf("unexpected wait: %s", wait)
+	}
+	if wait := l.reserve("a", now); wait != 100*time.Millisecond {
+		t.Errorf("unexpected wait: %s", wait)
+	}
+	if wait := l.reserve("a", now.Add(100*time.Millisecond)); wait != 0 {
+		t.Errorf("unexpected wait: %s", wait)
+	}
+
+	l.prune(now.Add(time.Second))
+	if len(l.buckets) != 0 {
+		t.Errorf("full buckets must be pruned: %d", len(l.buckets))
+	}
+}
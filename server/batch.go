@@ -0,0 +1,147 @@
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/sony/sonyflake"
+)
+
+// These are the media types of the responses of /ids.
+const (
+	MediaTypeJSON   = "application/json"
+	MediaTypeCSV    = "text/csv"
+	MediaTypeNDJSON = "application/x-ndjson"
+)
+
+// handleIDs issues the number of IDs given by the query parameter count, 1 by default,
+// in the media type negotiated by the Accept header:
+//
+//	application/json      {"ids":["<id>",...]}
+//	text/csv              a header line "id" followed by an ID per line
+//	application/x-ndjson  {"id":"<id>"} per line
+//
+// IDs are formatted in the default encoding of sonyflake.ID.
+func (s *Server) handleIDs(w http.ResponseWriter, r *http.Request) {
+	count := 1
+	if q := r.URL.Query().Get("count"); q != "" {
+		n, err := strconv.Atoi(q)
+		if err != nil || n < 1 || n > s.st.MaxBatchSize {
+			http.Error(w, fmt.Sprintf("count must be from 1 to %d", s.st.MaxBatchSize), http.StatusBadRequest)
+			return
+		}
+		count = n
+	}
+
+	mediaType, ok := negotiate(r.Header.Get("Accept"))
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusNotAcceptable), http.StatusNotAcceptable)
+		return
+	}
+
+	ids := make([]string, count)
+	for i := range ids {
+		id, err := s.sf.NextID()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		ids[i] = sonyflake.ID(id).String()
+	}
+
+	switch mediaType {
+	case MediaTypeCSV:
+		w.Header()["Content-Type"] = []string{"text/csv; charset=utf-8"}
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"id"})
+		for _, id := range ids {
+			cw.Write([]string{id})
+		}
+		cw.Flush()
+	case MediaTypeNDJSON:
+		w.Header()["Content-Type"] = []string{MediaTypeNDJSON}
+		enc := json.NewEncoder(w)
+		for _, id := range ids {
+			enc.Encode(map[string]string{"id": id})
+		}
+	default:
+		w.Header()["Content-Type"] = []string{"application/json; charset=utf-8"}
+		json.NewEncoder(w).Encode(map[string][]string{"ids": ids})
+	}
+}
+
+// negotiate returns the first media type in the Accept header which the Server supports.
+// Quality values are not taken into account.
+func negotiate(accept string) (string, bool) {
+	if accept == "" {
+		return MediaTypeJSON, true
+	}
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case MediaTypeJSON, "*/*", "application/*":
+			return MediaTypeJSON, true
+		case MediaTypeCSV, "text/*":
+			return MediaTypeCSV, true
+		case MediaTypeNDJSON:
+			return MediaTypeNDJSON, true
+		}
+	}
+	return "", false
+}
+
+// handleStream pushes new IDs continuously as server-sent events, an ID per event,
+// until the client disconnects or the number of IDs given by the query parameter count is reached.
+// The count is MaxBatchSize by default and at most, so that a request charged to the rate limit once
+// cannot drain the generator.
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	count := s.st.MaxBatchSize
+	if q := r.URL.Query().Get("count"); q != "" {
+		n, err := strconv.Atoi(q)
+		if err != nil || n < 1 || n > s.st.MaxBatchSize {
+			http.Error(w, fmt.Sprintf("count must be from 1 to %d", s.st.MaxBatchSize), http.StatusBadRequest)
+			return
+		}
+		count = n
+	}
+
+	w.Header()["Content-Type"] = []string{"text/event-stream"}
+	w.Header()["Cache-Control"] = []string{"no-cache"}
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ctx := r.Context()
+	for i := 0; i < count; i++ {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		id, err := s.sf.NextID()
+		if err != nil {
+			fmt.Fprintf(w, "event: error\ndata: %s\n\n", err)
+			flusher.Flush()
+			return
+		}
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", sonyflake.ID(id)); err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
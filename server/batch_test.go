@@ -0,0 +1,137 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sony/sonyflake"
+)
+
+func getAccept(h http.Handler, target, accept string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	if accept != "" {
+		r.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	return rec
+}
+
+func TestIDsJSON(t *testing.T) {
+	s := New(newSonyflake(t, sonyflake.Settings{}), Settings{})
+
+	rec := getAccept(s, "/ids?count=5", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", rec.Code)
+	}
+
+	var body struct {
+		IDs []string `json:"ids"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(body.IDs) != 5 {
+		t.Fatalf("unexpected number of ids: %d", len(body.IDs))
+	}
+
+	var previous uint64
+	for _, s := range body.IDs {
+		id, err := sonyflake.Parse(s)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if id <= previous {
+			t.Errorf("ids must be increasing: %v", body.IDs)
+		}
+		previous = id
+	}
+}
+
+func TestIDsCSVAndNDJSON(t *testing.T) {
+	s := New(newSonyflake(t, sonyflake.Settings{}), Settings{})
+
+	rec := getAccept(s, "/ids?count=3", "text/csv")
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if rec.Header().Get("Content-Type") != "text/csv; charset=utf-8" || len(lines) != 4 || lines[0] != "id" {
+		t.Errorf("unexpected csv: %q", rec.Body.String())
+	}
+
+	rec = getAccept(s, "/ids?count=3", "application/x-ndjson, application/json;q=0.5")
+	lines = strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if rec.Header().Get("Content-Type") != MediaTypeNDJSON || len(lines) != 3 {
+		t.Fatalf("unexpected ndjson: %q", rec.Body.String())
+	}
+	var line map[string]string
+	if err := json.Unmarshal([]byte(lines[0]), &line); err != nil || line["id"] == "" {
+		t.Errorf("unexpected line: %s", lines[0])
+	}
+}
+
+func TestIDsError(t *testing.T) {
+	s := New(newSonyflake(t, sonyflake.Settings{}), Settings{MaxBatchSize: 10})
+
+	for _, target := range []string{"/ids?count=0", "/ids?count=11", "/ids?count=x"} {
+		if rec := getAccept(s, target, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("unexpected status of %s: %d", target, rec.Code)
+		}
+	}
+	if rec := getAccept(s, "/ids", "image/png"); rec.Code != http.StatusNotAcceptable {
+		t.Errorf("unexpected status: %d", rec.Code)
+	}
+}
+
+func TestStream(t *testing.T) {
+	s := New(newSonyflake(t, sonyflake.Settings{}), Settings{})
+
+	rec := getAccept(s, "/ids/stream?count=3", "text/event-stream")
+	if rec.Header().Get("Content-Type") != "text/event-stream" {
+		t.Errorf("unexpected content type: %s", rec.Header().Get("Content-Type"))
+	}
+
+	var ids []string
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, "data: ") {
+			ids = append(ids, strings.TrimPrefix(line, "data: "))
+		}
+	}
+	if len(ids) != 3 {
+		t.Errorf("unexpected events: %q", rec.Body.String())
+	}
+}
+
+func TestStreamCount(t *testing.T) {
+	s := New(newSonyflake(t, sonyflake.Settings{}), Settings{MaxBatchSize: 5})
+
+	rec := getAccept(s, "/ids/stream", "text/event-stream")
+	if n := strings.Count(rec.Body.String(), "data: "); n != 5 {
+		t.Errorf("unexpected number of events: %d", n)
+	}
+
+	for _, q := range []string{"0", "6", "-1"} {
+		if rec := getAccept(s, "/ids/stream?count="+q, "text/event-stream"); rec.Code != http.StatusBadRequest {
+			t.Errorf("unexpected status of count %s: %d", q, rec.Code)
+		}
+	}
+}
+
+func TestStreamCanceled(t *testing.T) {
+	sf := newSonyflake(t, sonyflake.Settings{})
+	s := New(sf, Settings{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := httptest.NewRequest(http.MethodGet, "/ids/stream", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, r)
+
+	if sf.LastID() != 0 {
+		t.Error("no id must be issued after the client disconnects")
+	}
+}
@@ -0,0 +1,226 @@
+// Package server provides an HTTP service issuing Sonyflake IDs.
+//
+// The service serves the following endpoints:
+//
+//	GET /id          a new ID and its parts in JSON
+//	GET /ids         new IDs in JSON, CSV or NDJSON
+//	GET /ids/stream  new IDs pushed as server-sent events
+//	GET /healthz     200 OK while the process is up
+//	GET /readyz      200 OK while the generator is able to issue IDs, or 503 Service Unavailable
+//	POST /state      the signed final state of the generator, which stops issuing IDs, for a successor
+//
+// The endpoints /healthz and /readyz are meant for liveness and readiness probes of Kubernetes.
+// The endpoint /state is served only with Settings.StateKey, for zero-duplicate rolling restarts on the same machine ID:
+// the successor takes the state over from the predecessor by TakeOver before serving.
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/sony/sonyflake"
+)
+
+// Settings configures Server:
+//
+// MaxClockSkew is how far the clock may be behind the time of the last issued ID, such as after a clock step back,
+// while the Server is ready. NextID waits for the clock to catch up during the skew.
+// If MaxClockSkew is 0, it is 1 sec.
+//
+// CertFile and KeyFile are the PEM files of the certificate and the private key with which ListenAndServe serves TLS.
+// If they are empty, ListenAndServe serves plain HTTP.
+//
+// ClientCAFile is the PEM file of the certificate authorities of clients.
+// If ClientCAFile is not empty, ListenAndServe requires clients to present certificates signed by them (mTLS).
+// ClientCAFile requires CertFile and KeyFile.
+//
+// Authenticate identifies the client of a request to issue IDs, such as by APIKeys.
+// If Authenticate returns false, the Server responds 401 Unauthorized.
+// If Authenticate is nil, every request is accepted and its client is the remote IP address.
+//
+// RateLimit is the number of requests to issue IDs per second allowed for each client,
+// and RateBurst is the number of such requests allowed at once.
+// If RateLimit is 0, requests are not limited. If RateBurst is 0, it is 1.
+// The Server responds 429 Too Many Requests to the requests over the limit.
+//
+// MaxBatchSize is the maximum number of IDs issued by a request to /ids or /ids/stream.
+// If MaxBatchSize is 0, it is 1000.
+//
+// StateKey is the secret key signing the state handed off at /state and verifying it on Restore.
+// The predecessor and the successor must share it. If StateKey is empty, /state is not served.
+// /state is authenticated and rate-limited as the requests to issue IDs.
+type Settings struct {
+	MaxClockSkew time.Duration
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string
+	Authenticate func(r *http.Request) (client string, ok bool)
+	RateLimit    float64
+	RateBurst    int
+	MaxBatchSize int
+	StateKey     []byte
+}
+
+const (
+	defaultMaxClockSkew = time.Second
+	defaultMaxBatchSize = 1000
+)
+
+// ErrNoCertificate is returned if Settings.ClientCAFile is given without Settings.CertFile and Settings.KeyFile.
+var ErrNoCertificate = errors.New("client verification requires a server certificate")
+
+// Server is an http.Handler serving the endpoints of the ID service.
+type Server struct {
+	sf      *sonyflake.Sonyflake
+	st      Settings
+	mux     *http.ServeMux
+	limiter *limiter
+}
+
+// New returns a new Server issuing IDs by the given Sonyflake.
+func New(sf *sonyflake.Sonyflake, st Settings) *Server {
+	if st.MaxClockSkew == 0 {
+		st.MaxClockSkew = defaultMaxClockSkew
+	}
+	if st.MaxBatchSize == 0 {
+		st.MaxBatchSize = defaultMaxBatchSize
+	}
+
+	s := &Server{sf: sf, st: st, mux: http.NewServeMux()}
+	if st.RateLimit > 0 {
+		s.limiter = newLimiter(st.RateLimit, st.RateBurst)
+	}
+	s.mux.Handle("/id", s.guard(http.HandlerFunc(s.handleID)))
+	s.mux.Handle("/ids", s.guard(http.HandlerFunc(s.handleIDs)))
+	s.mux.Handle("/ids/stream", s.guard(http.HandlerFunc(s.handleStream)))
+	s.mux.HandleFunc("/healthz", s.handleHealthz)
+	s.mux.HandleFunc("/readyz", s.handleReadyz)
+	if len(st.StateKey) > 0 {
+		s.mux.Handle("/state", s.guard(http.HandlerFunc(s.handleState)))
+	}
+	return s
+}
+
+// ListenAndServe listens on the given TCP address and serves the endpoints of the Server,
+// with TLS if Settings.CertFile and Settings.KeyFile are given.
+func (s *Server) ListenAndServe(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ln)
+}
+
+// Serve serves the endpoints of the Server on the given listener,
+// with TLS if Settings.CertFile and Settings.KeyFile are given.
+// Serve closes the listener when it returns.
+func (s *Server) Serve(ln net.Listener) error {
+	config, err := s.st.TLSConfig()
+	if err != nil {
+		ln.Close()
+		return err
+	}
+	if config != nil {
+		ln = tls.NewListener(ln, config)
+	}
+	return http.Serve(ln, s)
+}
+
+// TLSConfig returns the TLS configuration loaded from CertFile, KeyFile and ClientCAFile,
+// or nil if TLS is not configured.
+func (st Settings) TLSConfig() (*tls.Config, error) {
+	if st.CertFile == "" && st.KeyFile == "" {
+		if st.ClientCAFile != "" {
+			return nil, ErrNoCertificate
+		}
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(st.CertFile, st.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if st.ClientCAFile != "" {
+		pem, err := ioutil.ReadFile(st.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s: no certificate", st.ClientCAFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleID(w http.ResponseWriter, r *http.Request) {
+	id, err := s.sf.NextID()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	body, err := json.Marshal(s.sf.Decompose(id))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header()["Content-Type"] = []string{"application/json; charset=utf-8"}
+	w.Write(body)
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	io.WriteString(w, "ok\n")
+}
+
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if err := s.Ready(); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	io.WriteString(w, "ok\n")
+}
+
+// Ready returns nil if the Server is able to issue IDs.
+// Otherwise Ready returns the reason in the following cases:
+// - The Sonyflake is closed or has detected a change of the machine ID.
+// - The Sonyflake time is over the time limit.
+// - The clock is behind the time of the last issued ID by more than Settings.MaxClockSkew.
+func (s *Server) Ready() error {
+	if err := s.sf.Err(); err != nil {
+		return err
+	}
+
+	layout := s.sf.Layout()
+	now := time.Since(layout.StartTime) / layout.TimeUnit
+	if layout.BitsTime < 64 && uint64(now) >= 1<<layout.BitsTime {
+		return sonyflake.ErrOverTimeLimit
+	}
+
+	last := time.Duration(s.sf.Snapshot().ElapsedTime)
+	if skew := (last - now) * layout.TimeUnit; skew > s.st.MaxClockSkew {
+		return fmt.Errorf("clock is behind the last issued id by %s", skew)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sony/sonyflake"
+)
+
+func newSonyflake(t *testing.T, st sonyflake.Settings) *sonyflake.Sonyflake {
+	st.MachineID = func() (uint16, error) { return 1, nil }
+	sf, err := sonyflake.New(st)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return sf
+}
+
+func get(h http.Handler, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+func TestID(t *testing.T) {
+	s := New(newSonyflake(t, sonyflake.Settings{}), Settings{})
+
+	rec := get(s, "/id")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", rec.Code)
+	}
+
+	var parts map[string]uint64
+	if err := json.Unmarshal(rec.Body.Bytes(), &parts); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if parts["machine-id"] != 1 || parts["id"] == 0 {
+		t.Errorf("unexpected parts: %v", parts)
+	}
+}
+
+func TestHealthz(t *testing.T) {
+	sf := newSonyflake(t, sonyflake.Settings{})
+	s := New(sf, Settings{})
+	sf.Close()
+
+	if rec := get(s, "/healthz"); rec.Code != http.StatusOK {
+		t.Errorf("unexpected status: %d", rec.Code)
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	sf := newSonyflake(t, sonyflake.Settings{})
+	s := New(sf, Settings{})
+
+	if rec := get(s, "/readyz"); rec.Code != http.StatusOK {
+		t.Errorf("unexpected status: %d", rec.Code)
+	}
+
+	sf.Close()
+	rec := get(s, "/readyz")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("unexpected status: %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), sonyflake.ErrClosed.Error()) {
+		t.Errorf("unexpected body: %s", rec.Body.String())
+	}
+}
+
+func TestReadyClockSkew(t *testing.T) {
+	sf := newSonyflake(t, sonyflake.Settings{StartTime: time.Now().Add(-time.Hour)})
+	s := New(sf, Settings{MaxClockSkew: time.Minute})
+
+	if _, err := sf.NextID(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// the last ID was issued 2 minutes ahead, as if the clock stepped back
+	state := sf.Snapshot()
+	state.ElapsedTime += int64(2 * time.Minute / (10 * time.Millisecond))
+	if err := sf.Restore(state); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := s.Ready(); err == nil || !strings.HasPrefix(err.Error(), "clock is behind") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	s = New(sf, Settings{MaxClockSkew: 3 * time.Minute})
+	if err := s.Ready(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestReadyOverTimeLimit(t *testing.T) {
+	sf := newSonyflake(t, sonyflake.Settings{
+		StartTime: time.Now().Add(-500 * 24 * time.Hour),
+		TimeUnit:  time.Millisecond,
+		// 32 bits of time in units of 1 msec overflow in about 50 days
+		BitsSequence:  15,
+		BitsMachineID: 16,
+	})
+	s := New(sf, Settings{})
+
+	if err := s.Ready(); err != sonyflake.ErrOverTimeLimit {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/sony/sonyflake"
+)
+
+// ErrNoStateKey is returned by Restore and TakeOver if Settings.StateKey is empty.
+var ErrNoStateKey = errors.New("state hand-off requires a state key")
+
+var stateEncoding = base64.RawURLEncoding
+
+// maxSnapshotLen bounds the response read by TakeOver.
+const maxSnapshotLen = 1024
+
+// handleState hands off the generator to a successor on POST:
+// it closes the Sonyflake, so that no more IDs are issued, and responds its final State
+// as an opaque snapshot signed by Settings.StateKey, which the successor passes to Restore.
+// Requesting it again responds the same snapshot.
+func (s *Server) handleState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.sf.Close()
+	snapshot, err := s.signState(s.sf.Snapshot())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header()["Content-Type"] = []string{"text/plain; charset=utf-8"}
+	w.Header().Set("Cache-Control", "no-store")
+	io.WriteString(w, snapshot+"\n")
+}
+
+// Restore advances the Sonyflake of the Server to the State in the given snapshot
+// responded by /state of its predecessor, so that it never reissues an ID of the predecessor.
+// Restore must be called before the Server issues IDs.
+// Restore returns sonyflake.ErrInvalidState if the snapshot is malformed, was not signed by Settings.StateKey,
+// was taken by a Sonyflake of another layout, or has another machine ID.
+func (s *Server) Restore(snapshot string) error {
+	if len(s.st.StateKey) == 0 {
+		return ErrNoStateKey
+	}
+
+	b, err := stateEncoding.DecodeString(snapshot)
+	if err != nil || len(b) < sha256.Size {
+		return sonyflake.ErrInvalidState
+	}
+	payload, signature := b[:len(b)-sha256.Size], b[len(b)-sha256.Size:]
+	expected, err := s.sign(payload)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(signature, expected) {
+		return sonyflake.ErrInvalidState
+	}
+
+	var state sonyflake.State
+	if err := state.UnmarshalBinary(payload); err != nil {
+		return err
+	}
+	return s.sf.Restore(state)
+}
+
+// TakeOver sends the given request, a POST to /state of the predecessor with its credentials,
+// by the given client and restores the snapshot in the response.
+// It is meant to be called on startup of a successor reusing the machine ID of the predecessor,
+// such as in a rolling restart, before the successor starts serving.
+// If client is nil, http.DefaultClient is used.
+func (s *Server) TakeOver(client *http.Client, req *http.Request) error {
+	if len(s.st.StateKey) == 0 {
+		return ErrNoStateKey
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxSnapshotLen))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("state hand-off failed: %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return s.Restore(string(bytes.TrimSpace(body)))
+}
+
+func (s *Server) signState(state sonyflake.State) (string, error) {
+	payload, err := state.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	signature, err := s.sign(payload)
+	if err != nil {
+		return "", err
+	}
+	return stateEncoding.EncodeToString(append(payload, signature...)), nil
+}
+
+// sign returns the HMAC-SHA256 of the given payload and the layout descriptor of the Sonyflake,
+// so that a snapshot is accepted only by a Sonyflake of the same layout.
+func (s *Server) sign(payload []byte) ([]byte, error) {
+	layout, err := s.sf.LayoutJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha256.New, s.st.StateKey)
+	mac.Write(layout)
+	mac.Write(payload)
+	return mac.Sum(nil), nil
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sony/sonyflake"
+)
+
+func TestStateHandOff(t *testing.T) {
+	key := []byte("secret")
+	start := time.Now().Add(-time.Hour)
+	predecessor := newSonyflake(t, sonyflake.Settings{StartTime: start})
+	ts := httptest.NewServer(New(predecessor, Settings{StateKey: key}))
+	defer ts.Close()
+
+	// the predecessor issued IDs ahead of the clock, as after a burst
+	state := predecessor.Snapshot()
+	state.ElapsedTime = int64(time.Since(start)/(10*time.Millisecond)) + 5
+	if err := predecessor.Restore(state); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	last, err := predecessor.NextID()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	successor := newSonyflake(t, sonyflake.Settings{StartTime: start})
+	s := New(successor, Settings{StateKey: key})
+	req, err := http.NewRequest(http.MethodPost, ts.URL+"/state", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := s.TakeOver(ts.Client(), req); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := predecessor.NextID(); err != sonyflake.ErrClosed {
+		t.Errorf("unexpected error: %v", err)
+	}
+	id, err := successor.NextID()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if id <= last {
+		t.Errorf("successor issued %d after %d", id, last)
+	}
+}
+
+func TestStateRestoreError(t *testing.T) {
+	sf := newSonyflake(t, sonyflake.Settings{})
+	s := New(sf, Settings{StateKey: []byte("secret")})
+	rec := post(s, "/state")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", rec.Code)
+	}
+	snapshot := strings.TrimSpace(rec.Body.String())
+
+	testCases := []struct {
+		s        *Server
+		snapshot string
+		err      error
+	}{
+		{New(newSonyflake(t, sonyflake.Settings{}), Settings{StateKey: []byte("secret")}), snapshot, nil},
+		{New(newSonyflake(t, sonyflake.Settings{}), Settings{StateKey: []byte("other")}), snapshot, sonyflake.ErrInvalidState},
+		{New(newSonyflake(t, sonyflake.Settings{TimeUnit: time.Millisecond}), Settings{StateKey: []byte("secret")}), snapshot, sonyflake.ErrInvalidState},
+		{New(newSonyflake(t, sonyflake.Settings{}), Settings{StateKey: []byte("secret")}), snapshot[1:], sonyflake.ErrInvalidState},
+		{New(newSonyflake(t, sonyflake.Settings{}), Settings{StateKey: []byte("secret")}), "!", sonyflake.ErrInvalidState},
+		{New(newSonyflake(t, sonyflake.Settings{}), Settings{}), snapshot, ErrNoStateKey},
+	}
+	for i, tc := range testCases {
+		if err := tc.s.Restore(tc.snapshot); err != tc.err {
+			t.Errorf("unexpected error of case %d: %v, expected %v", i, err, tc.err)
+		}
+	}
+}
+
+func TestStateEndpoint(t *testing.T) {
+	if rec := post(New(newSonyflake(t, sonyflake.Settings{}), Settings{}), "/state"); rec.Code != http.StatusNotFound {
+		t.Errorf("unexpected status without state key: %d", rec.Code)
+	}
+
+	sf := newSonyflake(t, sonyflake.Settings{})
+	s := New(sf, Settings{StateKey: []byte("secret"), Authenticate: APIKeys(map[string]string{"key": "deployer"})})
+	if rec := post(s, "/state"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("unexpected status without credentials: %d", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/state", nil)
+	req.Header.Set("X-API-Key", "key")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("unexpected status of GET: %d", rec.Code)
+	}
+	if err := sf.Err(); err != nil {
+		t.Errorf("GET must not hand off: %s", err)
+	}
+}
+
+func post(h http.Handler, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, nil))
+	return rec
+}
@@ -8,15 +8,18 @@
 package sonyflake
 
 import (
+	"crypto/rand"
+	"encoding/binary"
 	"errors"
 	"net"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/sony/sonyflake/types"
 )
 
-// These constants are the bit lengths of Sonyflake ID parts.
+// These constants are the bit lengths of Sonyflake ID parts in the default layout.
 const (
 	BitLenTime      = 39                               // bit length of time
 	BitLenSequence  = 8                                // bit length of sequence number
@@ -30,74 +33,430 @@ const (
 // If StartTime is ahead of the current time, Sonyflake is not created.
 //
 // MachineID returns the unique ID of the Sonyflake instance.
-// If MachineID returns an error, Sonyflake is not created.
+// If MachineID returns an error or a machine ID which does not fit in BitsMachineID bits, Sonyflake is not created.
 // If MachineID is nil, default MachineID is used.
-// Default MachineID returns the lower 16 bits of the private IP address.
+// Default MachineID returns the lower BitsMachineID bits of the private IP address.
+// If no private IPv4 address exists, the lower BitsMachineID bits of the interface identifier
+// of a unique local (fc00::/7) or global IPv6 address are used instead.
+// On js and wasip1, where network interfaces are unavailable, and with the build tag nonet,
+// MachineID or FallbackMachineID is required.
+//
+// FallbackMachineID is called only when MachineID, or default MachineID if MachineID is nil, fails,
+// so that a service preferring a precise machine ID can still start in unusual environments,
+// such as with a random machine ID by PersistentRandomMachineID.
+// If FallbackMachineID also returns an error, Sonyflake is not created.
+// If FallbackMachineID is nil, the failure of MachineID is not recovered.
 //
 // CheckMachineID validates the uniqueness of the machine ID.
 // If CheckMachineID returns false, Sonyflake is not created.
 // If CheckMachineID is nil, no validation is done.
+//
+// RecheckInterval and RecheckEvery make CheckMachineID be called again after Sonyflake is created,
+// since the uniqueness of the machine ID can be lost later, such as by a lost lease or a reset registry.
+// If RecheckInterval is positive, CheckMachineID is called every RecheckInterval in a goroutine stopped by Close.
+// If RecheckEvery is positive, CheckMachineID is called before every RecheckEvery calls of NextID,
+// with the Sonyflake locked, so it must return quickly and must not call methods of the Sonyflake.
+// RecheckPolicy is how NextID behaves after a recheck fails, which is CheckPolicyError by default.
+// If CheckMachineID is nil, the machine ID is not rechecked.
+// If RecheckInterval is negative or RecheckPolicy is unknown, Sonyflake is not created.
+//
+// Interfaces restricts the network interfaces which default MachineID looks up, by name.
+// Addresses of the interfaces listed earlier take precedence.
+// Interfaces which do not exist on the host are ignored.
+// If Interfaces is empty, the addresses of all interfaces are looked up.
+//
+// PreferredCIDRs is an ordered list of networks, such as "10.32.0.0/12", which default MachineID prefers.
+// The first address in the earliest listed network is used even if it is not a private address.
+// If no address is in any of the networks, default MachineID selects the private IP address as usual.
+// If PreferredCIDRs contains an invalid CIDR, Sonyflake is not created.
+//
+// AllowPublicIP makes default MachineID fall back to a public IPv4 address
+// if neither a private IPv4 address nor a usable IPv6 address exists, such as in some cloud sandboxes and VPSs.
+// Public addresses of hosts in different networks may share their lower bits,
+// so the machine IDs are unique only if the lower bits of the addresses are unique among the hosts,
+// which is not the case for hosts of different providers or subnets. Use CheckMachineID to verify uniqueness.
+//
+// BitsSequence is the bit length of a sequence number.
+// If BitsSequence is 0, the default bit length is used, which is 8.
+// If BitsSequence is negative or leaves less than 32 bits for time, Sonyflake is not created.
+//
+// BitsMachineID is the bit length of a machine ID.
+// If BitsMachineID is 0, the default bit length is used, which is 16.
+// If BitsMachineID is negative or more than 16, Sonyflake is not created.
+//
+// BitsNamespace is the bit length of a namespace ID, such as a tenant or a shard, carried by IDs.
+// If BitsNamespace is 0, IDs carry no namespace ID.
+// If BitsNamespace is negative or more than 16, Sonyflake is not created.
+//
+// NamespaceID is the namespace ID carried by IDs.
+// If NamespaceID does not fit in BitsNamespace bits, Sonyflake is not created.
+//
+// UseMSB makes IDs use the most significant bit for time, which is otherwise reserved
+// so that IDs can be converted to int64 safely.
+// UseMSB is for IDs stored as unsigned integers or byte keys.
+//
+// The bit length of time is 63, or 64 if UseMSB is true, minus BitsSequence, BitsNamespace and BitsMachineID.
+//
+// TimeUnit is the time unit of Sonyflake.
+// If TimeUnit is 0, the default time unit is used, which is 10 msec.
+// If TimeUnit is negative or less than 1 msec, Sonyflake is not created.
+//
+// FieldOrder is the order of the parts of IDs below the time.
+// If FieldOrder is FieldOrderSequenceFirst, which is the default,
+// the sequence number is placed above the namespace ID and the machine ID.
+// If FieldOrder is FieldOrderMachineIDFirst,
+// the namespace ID and the machine ID are placed above the sequence number
+// for compatibility with snowflake formats of that order.
+// If FieldOrder is unknown, Sonyflake is not created.
+//
+// RandomSequenceStart makes the sequence number of each time unit start at a random offset
+// in the lower half of its range instead of 0, so that IDs are harder to guess within a time unit.
+// The IDs are still unique and increasing, but a time unit can issue as few as half as many IDs.
+//
+// RandomSequence makes the sequence numbers unpredictable: each one is drawn by crypto/rand
+// among those not yet used in the time unit, so that IDs reveal neither the issuance rate nor the order
+// within a time unit, while they are still unique and sorted by time.
+// Use FieldOrderMachineIDFirst to place the random bits in the lowest bits, and BitsSequence to set their number.
+// A time unit issues up to half as many IDs, and IDs in the same time unit are not increasing.
+//
+// UtilizationQuota is a soft quota of Stats.Utilization, the sustained fraction of the sequence numbers
+// consumed per time unit, between 0 and 1.
+// When Stats.Utilization exceeds UtilizationQuota, OnUtilizationQuota is called in a new goroutine
+// with the utilization, signaling that BitsSequence or TimeUnit needs adjusting.
+// It is called again only after the utilization falls to or below UtilizationQuota and exceeds it again.
+// If UtilizationQuota is 0 or OnUtilizationQuota is nil, the quota is not enforced.
+//
+// Paced makes NextID spread the IDs of each time unit evenly over the time unit,
+// by sleeping until the share of the time unit for the number of IDs already issued in it has passed,
+// instead of issuing all the sequence numbers at once and then sleeping until the next time unit.
+// It smooths bursts of writes keyed by IDs downstream, at the cost of the latency of NextID under load.
+// The sleeps are as precise as time.Sleep, so paced time units issue fewer IDs if the share is short.
+//
+// BackwardTolerance is how far the clock may move back behind the time of the last ID
+// while NextID absorbs it silently by continuing from the time of the last ID.
+// Beyond BackwardTolerance, NextID returns a ClockBackwardError if OnClockBackward is nil.
+// Otherwise NextID keeps absorbing the movement and calls OnClockBackward in a new goroutine
+// with how far the clock is behind, again only after the clock comes back within BackwardTolerance and falls beyond it again.
+// Movements within a time unit are always absorbed, since they occur when NextID wakes up from a sleep early.
+// If BackwardTolerance is 0, any backward movement is absorbed.
+// If BackwardTolerance is negative, Sonyflake is not created.
+//
+// Trace is an opt-in hook called with a TraceEvent on each sequence rollover, sleep and clock anomaly in NextID,
+// for short-term diagnosis such as of inserts slowing down at every time unit.
+// Trace is called with the Sonyflake locked, so it must return quickly and must not call methods of the Sonyflake.
+// If Trace is nil, nothing is traced.
 type Settings struct {
 	StartTime      time.Time
 	MachineID      func() (uint16, error)
 	CheckMachineID func(uint16) bool
+	Interfaces     []string
+	PreferredCIDRs []string
+	AllowPublicIP  bool
+	BitsSequence   int
+	BitsMachineID  int
+	BitsNamespace  int
+	NamespaceID    uint16
+	TimeUnit       time.Duration
+	FieldOrder     FieldOrder
+	UseMSB         bool
+
+	FallbackMachineID func() (uint16, error)
+
+	RecheckInterval time.Duration
+	RecheckEvery    uint64
+	RecheckPolicy   CheckPolicy
+
+	RandomSequenceStart bool
+	RandomSequence      bool
+
+	UtilizationQuota   float64
+	OnUtilizationQuota func(utilization float64)
+
+	Paced bool
+
+	BackwardTolerance time.Duration
+	OnClockBackward   func(backward time.Duration)
+
+	Trace func(TraceEvent)
 }
 
+// FieldOrder is the order of the parts of Sonyflake IDs below the time.
+type FieldOrder int
+
+// These are the orders of the parts of Sonyflake IDs below the time, from the upper bits.
+const (
+	FieldOrderSequenceFirst  FieldOrder = iota // time, sequence, namespace, machine id
+	FieldOrderMachineIDFirst                   // time, namespace, machine id, sequence
+)
+
 // Sonyflake is a distributed unique ID generator.
 type Sonyflake struct {
 	mutex       *sync.Mutex
 	startTime   int64
 	elapsedTime int64
-	sequence    uint16
+	sequence    uint32
 	machineID   uint16
+	machineIP   net.IP
+	identity    Identity
+	lastID      uint64
+	stats       Stats
+
+	bitsTime      int
+	bitsSequence  int
+	bitsMachineID int
+	bitsNamespace int
+	namespaceID   uint16
+	timeUnit      int64
+	fieldOrder    FieldOrder
+	useMSB        bool
+
+	randomSequenceStart bool
+	randomSequence      bool
+	usedSequences       map[uint32]struct{}
+
+	tickIDs            uint64
+	utilizationQuota   float64
+	onUtilizationQuota func(utilization float64)
+	overQuota          bool
+
+	paced bool
+
+	backwardTolerance time.Duration
+	onClockBackward   func(backward time.Duration)
+	beyondTolerance   bool
+
+	traceFunc func(TraceEvent)
+
+	shiftTime      int
+	shiftSequence  int
+	shiftNamespace int
+	shiftMachineID int
+
+	resolveMachineID  func() (uint16, net.IP, error)
+	fallbackMachineID func() (uint16, error)
+	identitySource    IdentitySource
+	machineIDChanged  bool
+
+	checkMachineID func(uint16) bool
+	checkPolicy    CheckPolicy
+	checkFailed    bool
+	recheckEvery   uint64
+	recheckCalls   uint64
+
+	// base is the Sonyflake which checks the shared machine ID on behalf of this one, such as by MultiTenant
+	base *Sonyflake
+
+	closed  bool
+	closers []func() error
 }
 
 var (
 	ErrStartTimeAhead   = errors.New("start time is ahead of now")
 	ErrNoPrivateAddress = errors.New("no private ip address")
+	ErrNoMachineID      = errors.New("machine id required")
 	ErrOverTimeLimit    = errors.New("over the time limit")
 	ErrInvalidMachineID = errors.New("invalid machine id")
+	ErrMachineIDChanged = errors.New("machine id changed")
+	ErrClosed           = errors.New("sonyflake closed")
+
+	ErrInvalidBitsTime      = errors.New("invalid bit length for time")
+	ErrInvalidBitsSequence  = errors.New("invalid bit length for sequence number")
+	ErrInvalidBitsMachineID = errors.New("invalid bit length for machine id")
+	ErrInvalidBitsNamespace = errors.New("invalid bit length for namespace id")
+	ErrInvalidNamespaceID   = errors.New("invalid namespace id")
+	ErrInvalidTimeUnit      = errors.New("invalid time unit")
+	ErrInvalidFieldOrder    = errors.New("invalid field order")
+	ErrInvalidSequence      = errors.New("invalid sequence number")
+	ErrInvalidBatchSize     = errors.New("invalid batch size")
+	ErrInvalidQuota         = errors.New("invalid utilization quota")
+	ErrInvalidRecheck       = errors.New("invalid machine id recheck")
+	ErrMachineIDCheckFailed = errors.New("machine id check failed")
+	ErrInvalidTolerance     = errors.New("invalid backward tolerance")
+	ErrClockBackward        = errors.New("clock moved backward")
 )
 
-var defaultInterfaceAddrs = net.InterfaceAddrs
+// Validate checks the Settings without resolving the machine ID.
+// Validate returns an error in the following cases:
+// - Settings.StartTime is ahead of the current time.
+// - Settings.PreferredCIDRs contains an invalid CIDR.
+// - Settings.BitsSequence, Settings.BitsMachineID, Settings.BitsNamespace or Settings.TimeUnit is out of range.
+// - Settings.NamespaceID does not fit in Settings.BitsNamespace bits.
+// - Settings.FieldOrder is unknown.
+func (st Settings) Validate() error {
+	if st.StartTime.After(time.Now()) {
+		return ErrStartTimeAhead
+	}
+
+	if st.BitsSequence < 0 || st.BitsSequence > 63 {
+		return ErrInvalidBitsSequence
+	}
+	if st.BitsMachineID < 0 || st.BitsMachineID > BitLenMachineID {
+		return ErrInvalidBitsMachineID
+	}
+	if st.BitsNamespace < 0 || st.BitsNamespace > 16 {
+		return ErrInvalidBitsNamespace
+	}
+	if uint64(st.NamespaceID) >= 1<<st.BitsNamespace {
+		return ErrInvalidNamespaceID
+	}
+	if st.bitsTime() < 32 {
+		return ErrInvalidBitsTime
+	}
+	if st.TimeUnit < 0 || (st.TimeUnit > 0 && st.TimeUnit < time.Millisecond) {
+		return ErrInvalidTimeUnit
+	}
+	if st.FieldOrder != FieldOrderSequenceFirst && st.FieldOrder != FieldOrderMachineIDFirst {
+		return ErrInvalidFieldOrder
+	}
+	if st.UtilizationQuota < 0 || st.UtilizationQuota > 1 {
+		return ErrInvalidQuota
+	}
+	if st.RecheckInterval < 0 || (st.RecheckPolicy != CheckPolicyError && st.RecheckPolicy != CheckPolicyHalt) {
+		return ErrInvalidRecheck
+	}
+	if st.BackwardTolerance < 0 {
+		return ErrInvalidTolerance
+	}
+
+	if _, err := parseCIDRs(st.PreferredCIDRs); err != nil {
+		return err
+	}
+
+	return nil
+}
 
 // New returns a new Sonyflake configured with the given Settings.
 // New returns an error in the following cases:
-// - Settings.StartTime is ahead of the current time.
-// - Settings.MachineID returns an error.
+// - Settings.Validate returns an error.
+// - Settings.MachineID returns an error, and Settings.FallbackMachineID is nil or returns an error.
 // - Settings.CheckMachineID returns false.
 func New(st Settings) (*Sonyflake, error) {
-	if st.StartTime.After(time.Now()) {
-		return nil, ErrStartTimeAhead
+	if err := st.Validate(); err != nil {
+		return nil, err
 	}
 
 	sf := new(Sonyflake)
 	sf.mutex = new(sync.Mutex)
-	sf.sequence = uint16(1<<BitLenSequence - 1)
+	sf.bitsTime = st.bitsTime()
+	sf.bitsSequence = st.bitsSequence()
+	sf.bitsMachineID = st.bitsMachineID()
+	sf.bitsNamespace = st.BitsNamespace
+	sf.namespaceID = st.NamespaceID
+	sf.fieldOrder = st.FieldOrder
+	sf.useMSB = st.UseMSB
+	sf.randomSequenceStart = st.RandomSequenceStart
+	sf.randomSequence = st.RandomSequence
+	sf.utilizationQuota = st.UtilizationQuota
+	sf.onUtilizationQuota = st.OnUtilizationQuota
+	sf.paced = st.Paced
+	sf.backwardTolerance = st.BackwardTolerance
+	sf.onClockBackward = st.OnClockBackward
+	sf.traceFunc = st.Trace
+	sf.sequence = uint32(1<<sf.bitsSequence - 1)
+
+	switch st.FieldOrder {
+	case FieldOrderMachineIDFirst:
+		sf.shiftSequence = 0
+		sf.shiftMachineID = sf.bitsSequence
+		sf.shiftNamespace = sf.shiftMachineID + sf.bitsMachineID
+	default:
+		sf.shiftMachineID = 0
+		sf.shiftNamespace = sf.bitsMachineID
+		sf.shiftSequence = sf.shiftNamespace + sf.bitsNamespace
+	}
+	sf.shiftTime = sf.bitsSequence + sf.bitsNamespace + sf.bitsMachineID
+
+	sf.timeUnit = sonyflakeTimeUnit
+	if st.TimeUnit != 0 {
+		sf.timeUnit = int64(st.TimeUnit)
+	}
 
 	if st.StartTime.IsZero() {
-		sf.startTime = toSonyflakeTime(time.Date(2014, 9, 1, 0, 0, 0, 0, time.UTC))
+		sf.startTime = sf.toInternalTime(time.Date(2014, 9, 1, 0, 0, 0, 0, time.UTC))
 	} else {
-		sf.startTime = toSonyflakeTime(st.StartTime)
+		sf.startTime = sf.toInternalTime(st.StartTime)
 	}
 
 	var err error
-	if st.MachineID == nil {
-		sf.machineID, err = lower16BitPrivateIP(defaultInterfaceAddrs)
-	} else {
-		sf.machineID, err = st.MachineID()
+	sf.resolveMachineID, err = machineIDResolver(st)
+	if err == ErrNoMachineID && st.FallbackMachineID != nil {
+		sf.resolveMachineID, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sf.fallbackMachineID = st.FallbackMachineID
+
+	sf.identitySource = SourceIPAddress
+	if st.MachineID != nil {
+		sf.identitySource = SourceSettings
 	}
+	var source IdentitySource
+	sf.machineID, sf.machineIP, source, err = sf.resolve()
 	if err != nil {
 		return nil, err
 	}
+	sf.identity = newIdentity(source, sf.machineID, sf.machineIP)
+
+	if !sf.isValidMachineID(sf.machineID) {
+		return nil, sf.invalidMachineIDError(sf.machineID)
+	}
 
 	if st.CheckMachineID != nil && !st.CheckMachineID(sf.machineID) {
-		return nil, ErrInvalidMachineID
+		return nil, sf.invalidMachineIDError(sf.machineID)
+	}
+
+	sf.checkMachineID = st.CheckMachineID
+	sf.checkPolicy = st.RecheckPolicy
+	sf.recheckEvery = st.RecheckEvery
+	if st.CheckMachineID != nil && st.RecheckInterval > 0 {
+		sf.startRecheck(st.RecheckInterval)
 	}
 
 	return sf, nil
 }
 
+func machineIDResolver(st Settings) (func() (uint16, net.IP, error), error) {
+	if st.MachineID != nil {
+		return func() (uint16, net.IP, error) {
+			machineID, err := st.MachineID()
+			return machineID, nil, err
+		}, nil
+	}
+
+	return defaultMachineIDResolver(st)
+}
+
+func (st Settings) bitsSequence() int {
+	if st.BitsSequence == 0 {
+		return BitLenSequence
+	}
+	return st.BitsSequence
+}
+
+func (st Settings) bitsMachineID() int {
+	if st.BitsMachineID == 0 {
+		return BitLenMachineID
+	}
+	return st.BitsMachineID
+}
+
+func (st Settings) bitsTime() int {
+	bits := 63
+	if st.UseMSB {
+		bits = 64
+	}
+	return bits - st.bitsSequence() - st.BitsNamespace - st.bitsMachineID()
+}
+
+func (sf *Sonyflake) isValidMachineID(machineID uint16) bool {
+	return uint64(machineID) < 1<<sf.bitsMachineID
+}
+
+func (sf *Sonyflake) invalidMachineIDError(machineID uint16) error {
+	return &InvalidMachineIDError{Got: machineID, Max: uint16(1<<sf.bitsMachineID - 1)}
+}
+
 // NewSonyflake returns a new Sonyflake configured with the given Settings.
 // NewSonyflake returns nil in the following cases:
 // - Settings.StartTime is ahead of the current time.
@@ -111,25 +470,339 @@ func NewSonyflake(st Settings) *Sonyflake {
 // NextID generates a next unique ID.
 // After the Sonyflake time overflows, NextID returns an error.
 func (sf *Sonyflake) NextID() (uint64, error) {
-	const maskSequence = uint16(1<<BitLenSequence - 1)
+	maskSequence := uint32(1<<sf.bitsSequence - 1)
 
 	sf.mutex.Lock()
 	defer sf.mutex.Unlock()
 
-	current := currentElapsedTime(sf.startTime)
-	if sf.elapsedTime < current {
+	if !sf.closed {
+		sf.countRecheck()
+	}
+	if err := sf.err(); err != nil {
+		return 0, err
+	}
+
+	current := sf.currentElapsedTime()
+	if sf.elapsedTime > current+1 {
+		// a time unit of tolerance for the rounding of the sleep after a rollover
+		backward := time.Duration((sf.elapsedTime - current) * sf.timeUnit)
+		sf.trace(TraceClockBackward, backward)
+		if err := sf.checkBackward(backward); err != nil {
+			return 0, err
+		}
+	} else {
+		sf.beyondTolerance = false
+	}
+	if sf.randomSequence {
+		if err := sf.nextRandomSequence(current); err != nil {
+			return 0, err
+		}
+	} else if sf.elapsedTime < current {
+		sf.endTick()
 		sf.elapsedTime = current
-		sf.sequence = 0
+		sf.sequence = sf.firstSequence()
 	} else { // sf.elapsedTime >= current
 		sf.sequence = (sf.sequence + 1) & maskSequence
 		if sf.sequence == 0 {
+			sf.endTick()
 			sf.elapsedTime++
-			overtime := sf.elapsedTime - current
-			time.Sleep(sleepTime((overtime)))
+			sf.sequence = sf.firstSequence()
+			sf.stats.Exhausted++
+			sf.trace(TraceRollover, 0)
+			sf.sleep(sf.elapsedTime - current)
 		}
 	}
+	if sf.paced && sf.tickIDs > 0 && sf.elapsedTime == current {
+		sf.pace()
+	}
 
-	return sf.toID()
+	id, err := sf.toID()
+	if err != nil {
+		return 0, err
+	}
+	sf.lastID = id
+	sf.stats.Generated++
+	sf.tickIDs++
+	return id, nil
+}
+
+// utilizationWeight is the weight of the latest time unit in Stats.Utilization.
+const utilizationWeight = 1.0 / 16
+
+// endTick updates Stats.Utilization by the time unit ending now, if it issued any ID,
+// and calls Settings.OnUtilizationQuota if the utilization newly exceeds Settings.UtilizationQuota.
+func (sf *Sonyflake) endTick() {
+	if sf.tickIDs == 0 {
+		return
+	}
+
+	utilization := float64(sf.tickIDs) / float64(sf.tickCapacity())
+	sf.tickIDs = 0
+
+	if sf.stats.Ticks == 0 {
+		sf.stats.Utilization = utilization
+	} else {
+		sf.stats.Utilization += (utilization - sf.stats.Utilization) * utilizationWeight
+	}
+	sf.stats.Ticks++
+
+	if sf.utilizationQuota == 0 || sf.onUtilizationQuota == nil {
+		return
+	}
+	over := sf.stats.Utilization > sf.utilizationQuota
+	if over && !sf.overQuota {
+		go sf.onUtilizationQuota(sf.stats.Utilization)
+	}
+	sf.overQuota = over
+}
+
+// checkBackward applies Settings.BackwardTolerance to the given backward movement of the clock.
+func (sf *Sonyflake) checkBackward(backward time.Duration) error {
+	if sf.backwardTolerance == 0 || backward <= sf.backwardTolerance {
+		sf.beyondTolerance = false
+		return nil
+	}
+	if sf.onClockBackward == nil {
+		return &ClockBackwardError{Backward: backward, Tolerance: sf.backwardTolerance}
+	}
+	if !sf.beyondTolerance {
+		go sf.onClockBackward(backward)
+	}
+	sf.beyondTolerance = true
+	return nil
+}
+
+// tickCapacity returns the number of IDs which a time unit can issue.
+func (sf *Sonyflake) tickCapacity() uint64 {
+	capacity := uint64(1) << sf.bitsSequence
+	if sf.randomSequence {
+		capacity >>= 1
+	}
+	return capacity
+}
+
+// pace sleeps until the share of the current time unit for the IDs issued in it has passed,
+// for Settings.Paced.
+func (sf *Sonyflake) pace() {
+	offset := float64(sf.timeUnit) * float64(sf.tickIDs) / float64(sf.tickCapacity())
+	at := (sf.startTime+sf.elapsedTime)*sf.timeUnit + int64(offset)
+	if d := time.Duration(at - time.Now().UnixNano()); d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// firstSequence returns the sequence number of the first ID in a time unit,
+// which is 0 unless Settings.RandomSequenceStart is true.
+func (sf *Sonyflake) firstSequence() uint32 {
+	if !sf.randomSequenceStart || sf.bitsSequence < 2 {
+		return 0
+	}
+
+	var b [4]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b[:]) & uint32(1<<(sf.bitsSequence-1)-1)
+}
+
+// nextRandomSequence draws the next sequence number for Settings.RandomSequence
+// among those not yet used in the time unit.
+// A time unit issues up to half of the sequence numbers so that drawing an unused one stays fast.
+// A nil set of used sequence numbers, such as after Restore, means the time unit is used up.
+func (sf *Sonyflake) nextRandomSequence(current int64) error {
+	if sf.elapsedTime < current {
+		sf.endTick()
+		sf.elapsedTime = current
+		sf.usedSequences = make(map[uint32]struct{})
+	} else if sf.usedSequences == nil || len(sf.usedSequences) >= 1<<(sf.bitsSequence-1) {
+		sf.endTick()
+		sf.elapsedTime++
+		sf.usedSequences = make(map[uint32]struct{})
+		sf.stats.Exhausted++
+		sf.trace(TraceRollover, 0)
+		sf.sleep(sf.elapsedTime - current)
+	}
+
+	maskSequence := uint32(1<<sf.bitsSequence - 1)
+	var b [4]byte
+	for {
+		if _, err := rand.Read(b[:]); err != nil {
+			return err
+		}
+		sequence := binary.BigEndian.Uint32(b[:]) & maskSequence
+		if _, used := sf.usedSequences[sequence]; !used {
+			sf.usedSequences[sequence] = struct{}{}
+			sf.sequence = sequence
+			return nil
+		}
+	}
+}
+
+// LastID returns the ID most recently generated by NextID.
+// LastID returns 0 if no ID has been generated yet.
+func (sf *Sonyflake) LastID() uint64 {
+	sf.mutex.Lock()
+	defer sf.mutex.Unlock()
+
+	return sf.lastID
+}
+
+// WatchMachineID re-resolves the machine ID in the same way as New every interval,
+// in order to detect that the underlying identity, such as a DHCP-assigned private IP address, has changed.
+// A change is detected when the resolved machine ID differs from the one resolved before,
+// which is the current machine ID when watching starts, so that each change is detected once.
+// When a change is detected, onChange is called with the machine IDs resolved before and after it.
+// If onChange is nil, NextID returns ErrMachineIDChanged after a change is detected
+// until a new machine ID is set by SetMachineID.
+// Failures to re-resolve the machine ID are ignored.
+// WatchMachineID returns a function that stops watching.
+// Close also stops watching.
+func (sf *Sonyflake) WatchMachineID(interval time.Duration, onChange func(old, new uint16)) (stop func()) {
+	done := make(chan struct{})
+	var once sync.Once
+	stop = func() {
+		once.Do(func() { close(done) })
+	}
+	sf.onClose(func() error {
+		stop()
+		return nil
+	})
+
+	sf.mutex.Lock()
+	last := sf.machineID
+	sf.mutex.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			sf.mutex.Lock()
+			current := sf.machineID
+			sf.mutex.Unlock()
+
+			machineID, _, _, err := sf.resolve()
+			if err != nil || machineID == last {
+				continue
+			}
+			old := last
+			last = machineID
+
+			if onChange != nil {
+				onChange(old, machineID)
+				continue
+			}
+			sf.mutex.Lock()
+			if sf.machineID == current { // the change is already handled if SetMachineID has been called meanwhile
+				sf.machineIDChanged = true
+			}
+			sf.mutex.Unlock()
+		}
+	}()
+
+	return stop
+}
+
+// SetMachineID replaces the machine ID of the Sonyflake with the given one,
+// so that an instance which has lost its machine ID can re-acquire a new one without being recreated.
+// SetMachineID returns ErrInvalidMachineID if the machine ID does not fit in the bit length of a machine ID.
+// If check is not nil, it validates the uniqueness of the machine ID in the same way as Settings.CheckMachineID,
+// and SetMachineID returns ErrInvalidMachineID without replacing the machine ID if check returns false.
+// IDs generated after SetMachineID returns contain the new machine ID.
+//
+// SetMachineID clears a change detected by WatchMachineID without stopping it.
+// The watcher keeps comparing the resolved machine IDs with each other rather than with the one set by SetMachineID,
+// so NextID returns ErrMachineIDChanged again only after the resolved machine ID changes again.
+func (sf *Sonyflake) SetMachineID(machineID uint16, check func(uint16) bool) error {
+	if !sf.isValidMachineID(machineID) {
+		return sf.invalidMachineIDError(machineID)
+	}
+
+	if check != nil && !check(machineID) {
+		return sf.invalidMachineIDError(machineID)
+	}
+
+	sf.mutex.Lock()
+	defer sf.mutex.Unlock()
+
+	sf.machineID = machineID
+	sf.machineIP = nil
+	sf.identity = newIdentity(SourceSetMachineID, machineID, nil)
+	sf.machineIDChanged = false
+	sf.checkFailed = false
+	return nil
+}
+
+// Close stops the background work of the Sonyflake, such as watching the machine ID.
+// After Close is called, NextID returns ErrClosed.
+// Close returns the first error of stopping the background work, if any.
+// Calling Close more than once has no effect and returns nil.
+func (sf *Sonyflake) Close() error {
+	sf.mutex.Lock()
+	if sf.closed {
+		sf.mutex.Unlock()
+		return nil
+	}
+	sf.closed = true
+	closers := sf.closers
+	sf.closers = nil
+	sf.mutex.Unlock()
+
+	var err error
+	for i := len(closers) - 1; i >= 0; i-- {
+		if cerr := closers[i](); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// Err returns ErrClosed if the Sonyflake is closed,
+// ErrMachineIDChanged if a change of the machine ID has been detected and not handled,
+// or ErrMachineIDCheckFailed if issuance is stopped by a failed recheck of the machine ID.
+// Otherwise Err returns nil.
+// It is useful for health checks, since NextID fails while Err returns an error.
+func (sf *Sonyflake) Err() error {
+	sf.mutex.Lock()
+	defer sf.mutex.Unlock()
+
+	return sf.err()
+}
+
+func (sf *Sonyflake) err() error {
+	if sf.closed {
+		return ErrClosed
+	}
+	if sf.machineIDChanged {
+		return ErrMachineIDChanged
+	}
+	if sf.checkFailed {
+		return ErrMachineIDCheckFailed
+	}
+	if sf.base != nil {
+		return sf.base.Err()
+	}
+	return nil
+}
+
+// onClose registers a function called by Close in the reverse order of registration.
+// If the Sonyflake is already closed, the function is called immediately.
+func (sf *Sonyflake) onClose(closer func() error) {
+	sf.mutex.Lock()
+	if !sf.closed {
+		sf.closers = append(sf.closers, closer)
+		sf.mutex.Unlock()
+		return
+	}
+	sf.mutex.Unlock()
+
+	closer()
 }
 
 const sonyflakeTimeUnit = 1e7 // nsec, i.e. 10 msec
@@ -138,23 +811,118 @@ func toSonyflakeTime(t time.Time) int64 {
 	return t.UTC().UnixNano() / sonyflakeTimeUnit
 }
 
-func currentElapsedTime(startTime int64) int64 {
-	return toSonyflakeTime(time.Now()) - startTime
+func (sf *Sonyflake) toInternalTime(t time.Time) int64 {
+	return t.UTC().UnixNano() / sf.timeUnit
+}
+
+func (sf *Sonyflake) currentElapsedTime() int64 {
+	return sf.toInternalTime(time.Now()) - sf.startTime
+}
+
+// sleep sleeps until the given overtime passes, recording the duration in Stats.
+func (sf *Sonyflake) sleep(overtime int64) {
+	d := sf.sleepTime(overtime)
+	sf.stats.observeSleep(d)
+	sf.trace(TraceSleep, d)
+	time.Sleep(d)
 }
 
-func sleepTime(overtime int64) time.Duration {
-	return time.Duration(overtime*sonyflakeTimeUnit) -
-		time.Duration(time.Now().UTC().UnixNano()%sonyflakeTimeUnit)
+func (sf *Sonyflake) sleepTime(overtime int64) time.Duration {
+	return time.Duration(overtime*sf.timeUnit) -
+		time.Duration(time.Now().UTC().UnixNano()%sf.timeUnit)
 }
 
 func (sf *Sonyflake) toID() (uint64, error) {
-	if sf.elapsedTime >= 1<<BitLenTime {
-		return 0, ErrOverTimeLimit
+	if sf.elapsedTime >= 1<<sf.bitsTime {
+		return 0, &OverTimeLimitError{OverflowAt: sf.Layout().TimeLimit()}
+	}
+
+	return uint64(sf.elapsedTime)<<sf.shiftTime |
+		uint64(sf.sequence)<<sf.shiftSequence |
+		uint64(sf.namespaceID)<<sf.shiftNamespace |
+		uint64(sf.machineID)<<sf.shiftMachineID, nil
+}
+
+func sortedInterfaceAddrs(interfaces types.Interfaces, interfaceAddrsByName types.InterfaceAddrsByName) types.InterfaceAddrs {
+	return func() ([]net.Addr, error) {
+		ifs, err := interfaces()
+		if err != nil {
+			return nil, err
+		}
+		return namedInterfaceAddrs(interfaceAddrsByName, sortInterfaceNames(ifs))()
 	}
+}
 
-	return uint64(sf.elapsedTime)<<(BitLenSequence+BitLenMachineID) |
-		uint64(sf.sequence)<<BitLenMachineID |
-		uint64(sf.machineID), nil
+func sortedInterfaceNames(interfaces types.Interfaces) []string {
+	ifs, err := interfaces()
+	if err != nil {
+		return nil
+	}
+	return sortInterfaceNames(ifs)
+}
+
+func sortInterfaceNames(ifs []net.Interface) []string {
+	// Sort interfaces so that the address selection does not depend on the order the OS reports them in.
+	sort.Slice(ifs, func(i, j int) bool {
+		if ifs[i].Index != ifs[j].Index {
+			return ifs[i].Index < ifs[j].Index
+		}
+		return ifs[i].Name < ifs[j].Name
+	})
+
+	names := make([]string, 0, len(ifs))
+	for _, ifi := range ifs {
+		names = append(names, ifi.Name)
+	}
+	return names
+}
+
+func namedInterfaceAddrs(interfaceAddrsByName types.InterfaceAddrsByName, names []string) types.InterfaceAddrs {
+	return func() ([]net.Addr, error) {
+		var as []net.Addr
+		for _, name := range names {
+			addrs, err := interfaceAddrsByName(name)
+			if err != nil {
+				return nil, err
+			}
+			as = append(as, addrs...)
+		}
+		return as, nil
+	}
+}
+
+func parseCIDRs(ss []string) ([]*net.IPNet, error) {
+	cidrs := make([]*net.IPNet, 0, len(ss))
+	for _, s := range ss {
+		_, cidr, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, err
+		}
+		cidrs = append(cidrs, cidr)
+	}
+	return cidrs, nil
+}
+
+func preferredIP(interfaceAddrs types.InterfaceAddrs, cidrs []*net.IPNet) (net.IP, error) {
+	as, err := interfaceAddrs()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cidr := range cidrs {
+		for _, a := range as {
+			ipnet, ok := a.(*net.IPNet)
+			if !ok || ipnet.IP.IsLoopback() || !cidr.Contains(ipnet.IP) {
+				continue
+			}
+
+			if ip := ipnet.IP.To4(); ip != nil {
+				return ip, nil
+			}
+			return ipnet.IP.To16(), nil
+		}
+	}
+	return nil, ErrNoPrivateAddress
 }
 
 func privateIPv4(interfaceAddrs types.InterfaceAddrs) (net.IP, error) {
@@ -177,22 +945,156 @@ func privateIPv4(interfaceAddrs types.InterfaceAddrs) (net.IP, error) {
 	return nil, ErrNoPrivateAddress
 }
 
+// publicIPv4 returns the first non-loopback global unicast IPv4 address, for Settings.AllowPublicIP.
+func publicIPv4(interfaceAddrs types.InterfaceAddrs) (net.IP, error) {
+	as, err := interfaceAddrs()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range as {
+		ipnet, ok := a.(*net.IPNet)
+		if !ok || ipnet.IP.IsLoopback() {
+			continue
+		}
+
+		ip := ipnet.IP.To4()
+		if ip != nil && ip.IsGlobalUnicast() {
+			return ip, nil
+		}
+	}
+	return nil, ErrNoPrivateAddress
+}
+
 func isPrivateIPv4(ip net.IP) bool {
 	// Allow private IP addresses (RFC1918) and link-local addresses (RFC3927)
 	return ip != nil &&
 		(ip[0] == 10 || ip[0] == 172 && (ip[1] >= 16 && ip[1] < 32) || ip[0] == 192 && ip[1] == 168 || ip[0] == 169 && ip[1] == 254)
 }
 
-func lower16BitPrivateIP(interfaceAddrs types.InterfaceAddrs) (uint16, error) {
-	ip, err := privateIPv4(interfaceAddrs)
+func privateIPv6(interfaceAddrs types.InterfaceAddrs) (net.IP, error) {
+	as, err := interfaceAddrs()
+	if err != nil {
+		return nil, err
+	}
+
+	var global net.IP
+	for _, a := range as {
+		ipnet, ok := a.(*net.IPNet)
+		if !ok || ipnet.IP.IsLoopback() || ipnet.IP.To4() != nil {
+			continue
+		}
+
+		ip := ipnet.IP.To16()
+		if isUniqueLocalIPv6(ip) {
+			return ip, nil
+		}
+		if global == nil && ip.IsGlobalUnicast() {
+			global = ip
+		}
+	}
+	if global != nil {
+		return global, nil
+	}
+	return nil, ErrNoPrivateAddress
+}
+
+func rejectedAddrs(interfaceAddrs types.InterfaceAddrs) []RejectedAddress {
+	as, err := interfaceAddrs()
+	if err != nil {
+		return nil
+	}
+
+	rejected := make([]RejectedAddress, 0, len(as))
+	for _, a := range as {
+		ipnet, ok := a.(*net.IPNet)
+		if !ok {
+			rejected = append(rejected, RejectedAddress{Addr: a.String(), Reason: "not an ip network"})
+			continue
+		}
+
+		var reason string
+		switch {
+		case ipnet.IP.IsLoopback():
+			reason = "loopback"
+		case ipnet.IP.To4() != nil:
+			reason = "public ipv4"
+		case ipnet.IP.IsLinkLocalUnicast():
+			reason = "link-local ipv6"
+		default:
+			reason = "unusable ipv6"
+		}
+		rejected = append(rejected, RejectedAddress{Addr: ipnet.IP.String(), Reason: reason})
+	}
+	return rejected
+}
+
+func isUniqueLocalIPv6(ip net.IP) bool {
+	// Allow unique local addresses (RFC4193)
+	return ip != nil && ip[0]&0xfe == 0xfc
+}
+
+func privateIP(interfaceAddrs types.InterfaceAddrs, cidrs ...*net.IPNet) (net.IP, error) {
+	ip, err := preferredIP(interfaceAddrs, cidrs)
+	if errors.Is(err, ErrNoPrivateAddress) {
+		ip, err = privateIPv4(interfaceAddrs)
+	}
+	if errors.Is(err, ErrNoPrivateAddress) {
+		ip, err = privateIPv6(interfaceAddrs)
+	}
+	return ip, err
+}
+
+func lower16BitIP(ip net.IP) uint16 {
+	if len(ip) < 2 {
+		return 0
+	}
+
+	// The lower 16 bits of an IPv6 address belong to its interface identifier.
+	return uint16(ip[len(ip)-2])<<8 + uint16(ip[len(ip)-1])
+}
+
+// LowerBitsPrivateIP returns a function usable as Settings.MachineID
+// which returns the lower bits bits of the private IP address, selected as default MachineID does,
+// so that it can be combined with other providers for a layout of BitsMachineID bits.
+// The function returns ErrInvalidBitsMachineID if bits is not positive or more than 16.
+func LowerBitsPrivateIP(bits int) func() (uint16, error) {
+	return func() (uint16, error) {
+		if bits <= 0 || bits > BitLenMachineID {
+			return 0, ErrInvalidBitsMachineID
+		}
+
+		resolve, err := defaultMachineIDResolver(Settings{BitsMachineID: bits})
+		if err != nil {
+			return 0, err
+		}
+		machineID, _, err := resolve()
+		return machineID, err
+	}
+}
+
+func lower16BitPrivateIP(interfaceAddrs types.InterfaceAddrs, cidrs ...*net.IPNet) (uint16, error) {
+	ip, err := privateIP(interfaceAddrs, cidrs...)
 	if err != nil {
 		return 0, err
 	}
 
-	return uint16(ip[2])<<8 + uint16(ip[3]), nil
+	return lower16BitIP(ip), nil
+}
+
+// MachineIP returns the IP address from which default MachineID derived the machine ID.
+// MachineIP returns nil if Settings.MachineID is given or the machine ID has been replaced by SetMachineID.
+func (sf *Sonyflake) MachineIP() net.IP {
+	sf.mutex.Lock()
+	defer sf.mutex.Unlock()
+
+	if sf.machineIP == nil {
+		return nil
+	}
+	return append(net.IP(nil), sf.machineIP...)
 }
 
-// ElapsedTime returns the elapsed time when the given Sonyflake ID was generated.
+// ElapsedTime returns the elapsed time when the given Sonyflake ID in the default layout was generated.
 func ElapsedTime(id uint64) time.Duration {
 	return time.Duration(elapsedTime(id) * sonyflakeTimeUnit)
 }
@@ -201,19 +1103,19 @@ func elapsedTime(id uint64) uint64 {
 	return id >> (BitLenSequence + BitLenMachineID)
 }
 
-// SequenceNumber returns the sequence number of a Sonyflake ID.
+// SequenceNumber returns the sequence number of a Sonyflake ID in the default layout.
 func SequenceNumber(id uint64) uint64 {
 	const maskSequence = uint64((1<<BitLenSequence - 1) << BitLenMachineID)
 	return id & maskSequence >> BitLenMachineID
 }
 
-// MachineID returns the machine ID of a Sonyflake ID.
+// MachineID returns the machine ID of a Sonyflake ID in the default layout.
 func MachineID(id uint64) uint64 {
 	const maskMachineID = uint64(1<<BitLenMachineID - 1)
 	return id & maskMachineID
 }
 
-// Decompose returns a set of Sonyflake ID parts.
+// Decompose returns a set of Sonyflake ID parts in the default layout.
 func Decompose(id uint64) map[string]uint64 {
 	msb := id >> 63
 	time := elapsedTime(id)
@@ -227,3 +1129,89 @@ func Decompose(id uint64) map[string]uint64 {
 		"machine-id": machineID,
 	}
 }
+
+// ElapsedTime returns the elapsed time when the given ID generated by the Sonyflake was generated.
+func (sf *Sonyflake) ElapsedTime(id uint64) time.Duration {
+	return time.Duration(int64(id>>sf.shiftTime&(1<<sf.bitsTime-1)) * sf.timeUnit)
+}
+
+// Decompose returns a set of parts of the given ID generated by the Sonyflake.
+func (sf *Sonyflake) Decompose(id uint64) map[string]uint64 {
+	maskTime := uint64(1<<sf.bitsTime - 1)
+	maskSequence := uint64(1<<sf.bitsSequence - 1)
+	maskNamespace := uint64(1<<sf.bitsNamespace - 1)
+	maskMachineID := uint64(1<<sf.bitsMachineID - 1)
+
+	msb := id >> 63
+	time := id >> sf.shiftTime & maskTime
+	sequence := id >> sf.shiftSequence & maskSequence
+	namespace := id >> sf.shiftNamespace & maskNamespace
+	machineID := id >> sf.shiftMachineID & maskMachineID
+	return map[string]uint64{
+		"id":         id,
+		"msb":        msb,
+		"time":       time,
+		"sequence":   sequence,
+		"namespace":  namespace,
+		"machine-id": machineID,
+	}
+}
+
+// Compose returns the ID which the Sonyflake would generate at the given time
+// with the given sequence number and machine ID, carrying the namespace ID of the Sonyflake.
+// Compose returns an error in the following cases:
+// - The given time is before the start time.
+// - The given time is over the time limit.
+// - The sequence number or the machine ID does not fit in its bit length.
+func (sf *Sonyflake) Compose(t time.Time, sequence uint32, machineID uint16) (uint64, error) {
+	elapsedTime := sf.toInternalTime(t) - sf.startTime
+	if elapsedTime < 0 {
+		return 0, ErrStartTimeAhead
+	}
+	if elapsedTime >= 1<<sf.bitsTime {
+		return 0, ErrOverTimeLimit
+	}
+	if uint64(sequence) >= 1<<sf.bitsSequence {
+		return 0, ErrInvalidSequence
+	}
+	if !sf.isValidMachineID(machineID) {
+		return 0, sf.invalidMachineIDError(machineID)
+	}
+
+	return uint64(elapsedTime)<<sf.shiftTime |
+		uint64(sequence)<<sf.shiftSequence |
+		uint64(sf.namespaceID)<<sf.shiftNamespace |
+		uint64(machineID)<<sf.shiftMachineID, nil
+}
+
+// ComposeBatch returns n unique IDs which the Sonyflake would generate at the given time with the given machine ID,
+// for backfilling many records sharing a creation time.
+// The IDs are increasing: ComposeBatch walks the sequence numbers from 0 and then those of the following time units
+// if n exceeds the sequence numbers of a time unit, so the later IDs can be up to n/2^BitsSequence time units later.
+// Since the IDs are composed without the state of NextID, ComposeBatch must be called with a time
+// or a machine ID not used by running Sonyflakes, and with disjoint times or machine IDs for separate batches.
+// ComposeBatch returns ErrInvalidBatchSize if n is negative, and otherwise an error in the same cases as Compose.
+func (sf *Sonyflake) ComposeBatch(t time.Time, machineID uint16, n int) ([]uint64, error) {
+	if n < 0 {
+		return nil, ErrInvalidBatchSize
+	}
+	if _, err := sf.Compose(t, 0, machineID); err != nil || n == 0 {
+		return nil, err
+	}
+
+	elapsedTime := sf.toInternalTime(t) - sf.startTime
+	lastTime := elapsedTime + int64(n-1)>>sf.bitsSequence
+	if lastTime >= 1<<sf.bitsTime {
+		return nil, ErrOverTimeLimit
+	}
+
+	ids := make([]uint64, 0, n)
+	for i := 0; i < n; i++ {
+		sequence := uint64(i) & (1<<sf.bitsSequence - 1)
+		ids = append(ids, uint64(elapsedTime+int64(i)>>sf.bitsSequence)<<sf.shiftTime|
+			sequence<<sf.shiftSequence|
+			uint64(sf.namespaceID)<<sf.shiftNamespace|
+			uint64(machineID)<<sf.shiftMachineID)
+	}
+	return ids, nil
+}
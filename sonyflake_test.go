@@ -2,9 +2,8 @@ package sonyflake
 
 import (
 	"errors"
-	"fmt"
 	"net"
-	"runtime"
+	"sync"
 	"testing"
 	"time"
 
@@ -12,303 +11,1024 @@ import (
 	"github.com/sony/sonyflake/types"
 )
 
-var sf *Sonyflake
+func TestPrivateIPv4(t *testing.T) {
+	testCases := []struct {
+		description    string
+		expected       net.IP
+		interfaceAddrs types.InterfaceAddrs
+		error          string
+	}{
+		{
+			description:    "InterfaceAddrs returns an error",
+			expected:       nil,
+			interfaceAddrs: mock.NewFailingInterfaceAddrs(),
+			error:          "test error",
+		},
+		{
+			description:    "InterfaceAddrs returns an empty or nil list",
+			expected:       nil,
+			interfaceAddrs: mock.NewNilInterfaceAddrs(),
+			error:          "no private ip address",
+		},
+		{
+			description:    "InterfaceAddrs returns one or more IPs",
+			expected:       net.IP{192, 168, 0, 1},
+			interfaceAddrs: mock.NewSuccessfulInterfaceAddrs(),
+			error:          "",
+		},
+	}
 
-var startTime int64
-var machineID uint64
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			actual, err := privateIPv4(tc.interfaceAddrs)
 
-func init() {
-	var st Settings
-	st.StartTime = time.Now()
+			if (err != nil) && (tc.error == "") {
+				t.Errorf("expected no error, but got: %s", err)
+				return
+			} else if (err != nil) && (tc.error != "") {
+				return
+			}
 
-	sf = NewSonyflake(st)
-	if sf == nil {
-		panic("sonyflake not created")
+			if net.IP.Equal(actual, tc.expected) {
+				return
+			} else {
+				t.Errorf("error: expected: %s, but got: %s", tc.expected, actual)
+			}
+		})
 	}
+}
 
-	startTime = toSonyflakeTime(st.StartTime)
+func TestPrivateIPv6(t *testing.T) {
+	testCases := []struct {
+		description    string
+		expected       net.IP
+		interfaceAddrs types.InterfaceAddrs
+		error          string
+	}{
+		{
+			description:    "InterfaceAddrs returns an error",
+			expected:       nil,
+			interfaceAddrs: mock.NewFailingInterfaceAddrs(),
+			error:          "test error",
+		},
+		{
+			description:    "InterfaceAddrs returns only IPv4 addresses",
+			expected:       nil,
+			interfaceAddrs: mock.NewSuccessfulInterfaceAddrs(),
+			error:          "no private ip address",
+		},
+		{
+			description:    "InterfaceAddrs returns one or more IPv6 addresses",
+			expected:       net.ParseIP("fd00::304"),
+			interfaceAddrs: mock.NewIPv6InterfaceAddrs(),
+			error:          "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			actual, err := privateIPv6(tc.interfaceAddrs)
+
+			if (err != nil) && (tc.error == "") {
+				t.Errorf("expected no error, but got: %s", err)
+				return
+			} else if (err != nil) && (tc.error != "") {
+				return
+			}
 
-	ip, _ := lower16BitPrivateIP(defaultInterfaceAddrs)
-	machineID = uint64(ip)
+			if !net.IP.Equal(actual, tc.expected) {
+				t.Errorf("error: expected: %s, but got: %s", tc.expected, actual)
+			}
+		})
+	}
 }
 
-func nextID(t *testing.T) uint64 {
-	id, err := sf.NextID()
+func TestPublicIPv4(t *testing.T) {
+	ip, err := publicIPv4(namedInterfaceAddrs(mock.NewInterfaceAddrsByName(), []string{"eth1"}))
 	if err != nil {
-		t.Fatal("id not generated")
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !ip.Equal(net.IP{8, 8, 4, 4}) {
+		t.Errorf("unexpected ip: %s", ip)
 	}
-	return id
-}
 
-func TestNew(t *testing.T) {
-	genError := fmt.Errorf("an error occurred while generating ID")
+	if _, err := publicIPv4(mock.NewIPv6InterfaceAddrs()); err != ErrNoPrivateAddress {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := publicIPv4(mock.NewFailingInterfaceAddrs()); err == nil {
+		t.Error("expected an error")
+	}
+}
 
-	tests := []struct {
-		name     string
-		settings Settings
-		err      error
+func TestLower16BitPrivateIP(t *testing.T) {
+	testCases := []struct {
+		description    string
+		expected       uint16
+		interfaceAddrs types.InterfaceAddrs
+		error          string
 	}{
 		{
-			name: "failure: time ahead",
-			settings: Settings{
-				StartTime: time.Now().Add(time.Minute),
-			},
-			err: ErrStartTimeAhead,
+			description:    "InterfaceAddrs returns an empty or nil list",
+			expected:       0,
+			interfaceAddrs: mock.NewNilInterfaceAddrs(),
+			error:          "no private ip address",
 		},
 		{
-			name: "failure: machine ID",
-			settings: Settings{
-				MachineID: func() (uint16, error) {
-					return 0, genError
-				},
-			},
-			err: genError,
+			description:    "InterfaceAddrs returns one or more IPs",
+			expected:       1,
+			interfaceAddrs: mock.NewSuccessfulInterfaceAddrs(),
+			error:          "",
 		},
 		{
-			name: "failure: invalid machine ID",
-			settings: Settings{
-				CheckMachineID: func(uint16) bool {
-					return false
-				},
-			},
-			err: ErrInvalidMachineID,
+			description:    "InterfaceAddrs returns only IPv6 addresses",
+			expected:       3<<8 + 4,
+			interfaceAddrs: mock.NewIPv6InterfaceAddrs(),
+			error:          "",
 		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			actual, err := lower16BitPrivateIP(tc.interfaceAddrs)
+
+			if (err != nil) && (tc.error == "") {
+				t.Errorf("expected no error, but got: %s", err)
+				return
+			} else if (err != nil) && (tc.error != "") {
+				return
+			}
+
+			if actual == tc.expected {
+				return
+			} else {
+				t.Errorf("error: expected: %v, but got: %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSonyflakeTimeUnit(t *testing.T) {
+	if time.Duration(sonyflakeTimeUnit) != 10*time.Millisecond {
+		t.Errorf("unexpected time unit")
+	}
+}
+
+func TestNamedInterfaceAddrs(t *testing.T) {
+	testCases := []struct {
+		description string
+		names       []string
+		expected    uint16
+		error       string
+	}{
 		{
-			name:     "success",
-			settings: Settings{},
+			description: "no named interface has a private IP",
+			names:       []string{"eth1", "tun0"},
+			expected:    0,
+			error:       "no private ip address",
+		},
+		{
+			description: "a named interface has a private IP",
+			names:       []string{"tun0", "eth1", "eth0"},
+			expected:    1<<8 + 2,
+			error:       "",
+		},
+		{
+			description: "a named interface returns an error",
+			names:       []string{"err0", "eth0"},
+			expected:    0,
+			error:       "test error",
 		},
 	}
 
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			sonyflake, err := New(test.settings)
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			interfaceAddrs := namedInterfaceAddrs(mock.NewInterfaceAddrsByName(), tc.names)
+			actual, err := lower16BitPrivateIP(interfaceAddrs)
 
-			if !errors.Is(err, test.err) {
-				t.Fatalf("unexpected value, want %#v, got %#v", test.err, err)
+			if (err != nil) && (tc.error == "") {
+				t.Errorf("expected no error, but got: %s", err)
+				return
+			} else if (err != nil) && (tc.error != "") {
+				if err.Error() != tc.error {
+					t.Errorf("expected error: %s, but got: %s", tc.error, err)
+				}
+				return
 			}
 
-			if sonyflake == nil && err == nil {
-				t.Fatal("unexpected value, sonyflake should not be nil")
+			if actual != tc.expected {
+				t.Errorf("error: expected: %v, but got: %v", tc.expected, actual)
 			}
 		})
 	}
 }
 
-func TestSonyflakeOnce(t *testing.T) {
-	sleepTime := time.Duration(50 * sonyflakeTimeUnit)
-	time.Sleep(sleepTime)
-
-	id := nextID(t)
-
-	actualTime := ElapsedTime(id)
-	if actualTime < sleepTime || actualTime > sleepTime+sonyflakeTimeUnit {
-		t.Errorf("unexpected time: %d", actualTime)
+func TestSortedInterfaceAddrs(t *testing.T) {
+	as, err := sortedInterfaceAddrs(mock.NewInterfaces(), mock.NewInterfaceAddrsByName())()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
 	}
 
-	actualSequence := SequenceNumber(id)
-	if actualSequence != 0 {
-		t.Errorf("unexpected sequence: %d", actualSequence)
+	expected := []net.IP{{10, 0, 1, 2}, {8, 8, 4, 4}}
+	if len(as) != len(expected) {
+		t.Fatalf("unexpected number of addresses: %d", len(as))
+	}
+	for i, a := range as {
+		if ip := a.(*net.IPNet).IP; !ip.Equal(expected[i]) {
+			t.Errorf("error: expected: %s, but got: %s", expected[i], ip)
+		}
 	}
+}
 
-	actualMachineID := MachineID(id)
-	if actualMachineID != machineID {
-		t.Errorf("unexpected machine id: %d", actualMachineID)
+func TestLower16BitPreferredIP(t *testing.T) {
+	testCases := []struct {
+		description string
+		cidrs       []string
+		expected    uint16
+	}{
+		{
+			description: "no preferred CIDR",
+			cidrs:       nil,
+			expected:    1,
+		},
+		{
+			description: "the earliest CIDR wins",
+			cidrs:       []string{"10.32.0.0/12", "192.168.0.0/16"},
+			expected:    1<<8 + 2,
+		},
+		{
+			description: "a later CIDR matches",
+			cidrs:       []string{"172.16.0.0/12", "192.168.0.0/16"},
+			expected:    1,
+		},
+		{
+			description: "no CIDR matches",
+			cidrs:       []string{"172.16.0.0/12"},
+			expected:    1,
+		},
+		{
+			description: "loopback is not preferred",
+			cidrs:       []string{"127.0.0.0/8", "10.0.0.0/8"},
+			expected:    1<<8 + 2,
+		},
 	}
 
-	fmt.Println("sonyflake id:", id)
-	fmt.Println("decompose:", Decompose(id))
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cidrs, err := parseCIDRs(tc.cidrs)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			actual, err := lower16BitPrivateIP(mock.NewMultiHomedInterfaceAddrs(), cidrs...)
+			if err != nil {
+				t.Errorf("expected no error, but got: %s", err)
+				return
+			}
+
+			if actual != tc.expected {
+				t.Errorf("error: expected: %v, but got: %v", tc.expected, actual)
+			}
+		})
+	}
 }
 
-func currentTime() int64 {
-	return toSonyflakeTime(time.Now())
+func TestNewWithInvalidCIDR(t *testing.T) {
+	_, err := New(Settings{PreferredCIDRs: []string{"10.0.0.0"}})
+	if err == nil {
+		t.Errorf("expected an error for an invalid CIDR")
+	}
 }
 
-func TestSonyflakeFor10Sec(t *testing.T) {
-	var numID uint32
-	var lastID uint64
-	var maxSequence uint64
+func TestWatchMachineID(t *testing.T) {
+	var mutex sync.Mutex
+	current := uint16(1)
+	resolve := func() (uint16, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return current, nil
+	}
+	setCurrent := func(id uint16) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		current = id
+	}
+
+	t.Run("callback", func(t *testing.T) {
+		setCurrent(1)
+		sf, err := New(Settings{MachineID: resolve})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
 
-	initial := currentTime()
-	current := initial
-	for current-initial < 1000 {
-		id := nextID(t)
-		parts := Decompose(id)
-		numID++
+		changed := make(chan [2]uint16, 1)
+		stop := sf.WatchMachineID(time.Millisecond, func(old, new uint16) {
+			select {
+			case changed <- [2]uint16{old, new}:
+			default:
+			}
+		})
+		defer stop()
+
+		setCurrent(2)
+		select {
+		case ids := <-changed:
+			if ids != [2]uint16{1, 2} {
+				t.Errorf("unexpected change: %v", ids)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("change not detected")
+		}
 
-		if id == lastID {
-			t.Fatal("duplicated id")
+		if _, err := sf.NextID(); err != nil {
+			t.Errorf("unexpected error: %s", err)
 		}
-		if id < lastID {
-			t.Fatal("must increase with time")
+	})
+
+	t.Run("error", func(t *testing.T) {
+		setCurrent(1)
+		sf, err := New(Settings{MachineID: resolve})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
 		}
-		lastID = id
 
-		current = currentTime()
+		stop := sf.WatchMachineID(time.Millisecond, nil)
+		defer stop()
 
-		actualMSB := parts["msb"]
-		if actualMSB != 0 {
-			t.Errorf("unexpected msb: %d", actualMSB)
+		if _, err := sf.NextID(); err != nil {
+			t.Fatalf("unexpected error: %s", err)
 		}
 
-		actualTime := int64(parts["time"])
-		overtime := startTime + actualTime - current
-		if overtime > 0 {
-			t.Errorf("unexpected overtime: %d", overtime)
+		setCurrent(2)
+		deadline := time.Now().Add(time.Second)
+		for {
+			_, err := sf.NextID()
+			if errors.Is(err, ErrMachineIDChanged) {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("change not detected")
+			}
+			time.Sleep(time.Millisecond)
 		}
+	})
 
-		actualSequence := parts["sequence"]
-		if maxSequence < actualSequence {
-			maxSequence = actualSequence
+	t.Run("callback once per change", func(t *testing.T) {
+		setCurrent(1)
+		sf, err := New(Settings{MachineID: resolve})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
 		}
 
-		actualMachineID := parts["machine-id"]
-		if actualMachineID != machineID {
-			t.Errorf("unexpected machine id: %d", actualMachineID)
+		changed := make(chan [2]uint16, 10)
+		stop := sf.WatchMachineID(time.Millisecond, func(old, new uint16) {
+			changed <- [2]uint16{old, new}
+		})
+		defer stop()
+
+		time.Sleep(10 * time.Millisecond)
+		setCurrent(2)
+		time.Sleep(20 * time.Millisecond)
+		setCurrent(3)
+		time.Sleep(20 * time.Millisecond)
+
+		var changes [][2]uint16
+		for len(changed) > 0 {
+			changes = append(changes, <-changed)
 		}
-	}
+		if len(changes) != 2 || changes[0] != [2]uint16{1, 2} || changes[1] != [2]uint16{2, 3} {
+			t.Errorf("unexpected changes: %v", changes)
+		}
+	})
 
-	if maxSequence != 1<<BitLenSequence-1 {
-		t.Errorf("unexpected max sequence: %d", maxSequence)
-	}
-	fmt.Println("max sequence:", maxSequence)
-	fmt.Println("number of id:", numID)
-}
+	t.Run("error cleared by SetMachineID", func(t *testing.T) {
+		setCurrent(1)
+		sf, err := New(Settings{MachineID: resolve})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
 
-func TestSonyflakeInParallel(t *testing.T) {
-	numCPU := runtime.NumCPU()
-	runtime.GOMAXPROCS(numCPU)
-	fmt.Println("number of cpu:", numCPU)
+		stop := sf.WatchMachineID(time.Millisecond, nil)
+		defer stop()
 
-	consumer := make(chan uint64)
+		time.Sleep(10 * time.Millisecond)
+		setCurrent(2)
+		deadline := time.Now().Add(time.Second)
+		for !errors.Is(sf.Err(), ErrMachineIDChanged) {
+			if time.Now().After(deadline) {
+				t.Fatal("change not detected")
+			}
+			time.Sleep(time.Millisecond)
+		}
 
-	const numID = 10000
-	generate := func() {
-		for i := 0; i < numID; i++ {
-			consumer <- nextID(t)
+		// the new machine ID differs from the resolved one
+		if err := sf.SetMachineID(5, nil); err != nil {
+			t.Fatalf("unexpected error: %s", err)
 		}
+		time.Sleep(20 * time.Millisecond)
+		if _, err := sf.NextID(); err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		// the watcher keeps running after SetMachineID
+		setCurrent(3)
+		deadline = time.Now().Add(time.Second)
+		for !errors.Is(sf.Err(), ErrMachineIDChanged) {
+			if time.Now().After(deadline) {
+				t.Fatal("change after SetMachineID not detected")
+			}
+			time.Sleep(time.Millisecond)
+		}
+	})
+}
+
+func TestSetMachineID(t *testing.T) {
+	sf, err := New(Settings{MachineID: func() (uint16, error) { return 1, nil }})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
 	}
 
-	const numGenerator = 10
-	for i := 0; i < numGenerator; i++ {
-		go generate()
+	taken := func(id uint16) bool { return id != 2 }
+	if err := sf.SetMachineID(2, taken); !errors.Is(err, ErrInvalidMachineID) {
+		t.Errorf("unexpected error: %v", err)
 	}
 
-	set := make(map[uint64]struct{})
-	for i := 0; i < numID*numGenerator; i++ {
-		id := <-consumer
-		if _, ok := set[id]; ok {
-			t.Fatal("duplicated id")
-		}
-		set[id] = struct{}{}
+	id, err := sf.NextID()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if MachineID(id) != 1 {
+		t.Errorf("unexpected machine id: %d", MachineID(id))
+	}
+
+	if err := sf.SetMachineID(3, taken); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	id, err = sf.NextID()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if MachineID(id) != 3 {
+		t.Errorf("unexpected machine id: %d", MachineID(id))
 	}
-	fmt.Println("number of id:", len(set))
 }
 
-func pseudoSleep(period time.Duration) {
-	sf.startTime -= int64(period) / sonyflakeTimeUnit
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings Settings
+		valid    bool
+	}{
+		{
+			name:     "success: empty",
+			settings: Settings{},
+			valid:    true,
+		},
+		{
+			name: "success: machine id not resolved",
+			settings: Settings{
+				MachineID: func() (uint16, error) { return 0, errors.New("not resolved") },
+			},
+			valid: true,
+		},
+		{
+			name:     "failure: time ahead",
+			settings: Settings{StartTime: time.Now().Add(time.Minute)},
+		},
+		{
+			name:     "failure: invalid cidr",
+			settings: Settings{PreferredCIDRs: []string{"10.32.0.0"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.settings.Validate()
+			if test.valid && err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			if !test.valid && err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
 }
 
-func TestNextIDError(t *testing.T) {
-	year := time.Duration(365*24) * time.Hour
-	pseudoSleep(time.Duration(174) * year)
-	nextID(t)
+func TestCustomLayout(t *testing.T) {
+	st := Settings{
+		StartTime:     time.Now(),
+		MachineID:     func() (uint16, error) { return 1023, nil },
+		BitsSequence:  12,
+		BitsMachineID: 10,
+		TimeUnit:      time.Millisecond,
+	}
 
-	pseudoSleep(time.Duration(1) * year)
-	_, err := sf.NextID()
-	if err == nil {
-		t.Errorf("time is not over")
+	sf, err := New(st)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var last map[string]uint64
+	for i := 0; i < 1<<12+1; i++ {
+		id, err := sf.NextID()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		last = sf.Decompose(id)
+		if last["machine-id"] != 1023 {
+			t.Fatalf("unexpected machine id: %d", last["machine-id"])
+		}
+	}
+	if last["time"] == 0 {
+		t.Errorf("sequence did not roll over to the next time unit")
+	}
+
+	if err := sf.SetMachineID(1024, nil); !errors.Is(err, ErrInvalidMachineID) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	st.MachineID = func() (uint16, error) { return 1024, nil }
+	if _, err := New(st); !errors.Is(err, ErrInvalidMachineID) {
+		t.Errorf("unexpected error: %v", err)
 	}
 }
 
-func TestPrivateIPv4(t *testing.T) {
-	testCases := []struct {
-		description    string
-		expected       net.IP
-		interfaceAddrs types.InterfaceAddrs
-		error          string
+func TestValidateLayout(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings Settings
+		err      error
 	}{
 		{
-			description:    "InterfaceAddrs returns an error",
-			expected:       nil,
-			interfaceAddrs: mock.NewFailingInterfaceAddrs(),
-			error:          "test error",
+			name:     "failure: bits sequence",
+			settings: Settings{BitsSequence: -1},
+			err:      ErrInvalidBitsSequence,
 		},
 		{
-			description:    "InterfaceAddrs returns an empty or nil list",
-			expected:       nil,
-			interfaceAddrs: mock.NewNilInterfaceAddrs(),
-			error:          "no private ip address",
+			name:     "failure: bits machine id",
+			settings: Settings{BitsMachineID: 17},
+			err:      ErrInvalidBitsMachineID,
 		},
 		{
-			description:    "InterfaceAddrs returns one or more IPs",
-			expected:       net.IP{192, 168, 0, 1},
-			interfaceAddrs: mock.NewSuccessfulInterfaceAddrs(),
-			error:          "",
+			name:     "failure: bits time",
+			settings: Settings{BitsSequence: 16, BitsMachineID: 16},
+			err:      ErrInvalidBitsTime,
+		},
+		{
+			name:     "failure: time unit",
+			settings: Settings{TimeUnit: time.Microsecond},
+			err:      ErrInvalidTimeUnit,
 		},
 	}
 
-	for _, tc := range testCases {
-		t.Run(tc.description, func(t *testing.T) {
-			actual, err := privateIPv4(tc.interfaceAddrs)
-
-			if (err != nil) && (tc.error == "") {
-				t.Errorf("expected no error, but got: %s", err)
-				return
-			} else if (err != nil) && (tc.error != "") {
-				return
-			}
-
-			if net.IP.Equal(actual, tc.expected) {
-				return
-			} else {
-				t.Errorf("error: expected: %s, but got: %s", tc.expected, actual)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.settings.Validate(); !errors.Is(err, test.err) {
+				t.Errorf("unexpected error: %v", err)
 			}
 		})
 	}
 }
 
-func TestLower16BitPrivateIP(t *testing.T) {
-	testCases := []struct {
-		description    string
-		expected       uint16
-		interfaceAddrs types.InterfaceAddrs
-		error          string
+func TestClose(t *testing.T) {
+	sf, err := New(Settings{MachineID: func() (uint16, error) { return 1, nil }})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	sf.WatchMachineID(time.Millisecond, nil)
+
+	errCloser := errors.New("closer error")
+	var order []int
+	sf.onClose(func() error { order = append(order, 1); return errCloser })
+	sf.onClose(func() error { order = append(order, 2); return nil })
+
+	if _, err := sf.NextID(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := sf.Err(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	if err := sf.Close(); !errors.Is(err, errCloser) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(order) != 2 || order[0] != 2 || order[1] != 1 {
+		t.Errorf("unexpected order: %v", order)
+	}
+
+	if _, err := sf.NextID(); !errors.Is(err, ErrClosed) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := sf.Err(); !errors.Is(err, ErrClosed) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := sf.Close(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestLastID(t *testing.T) {
+	sf, err := New(Settings{MachineID: func() (uint16, error) { return 1, nil }})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if sf.LastID() != 0 {
+		t.Errorf("unexpected last id: %d", sf.LastID())
+	}
+
+	for i := 0; i < 10; i++ {
+		id, err := sf.NextID()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if sf.LastID() != id {
+			t.Errorf("unexpected last id: %d, want %d", sf.LastID(), id)
+		}
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings Settings
+		err      error
 	}{
 		{
-			description:    "InterfaceAddrs returns an empty or nil list",
-			expected:       0,
-			interfaceAddrs: mock.NewNilInterfaceAddrs(),
-			error:          "no private ip address",
+			name:     "failure: bits namespace",
+			settings: Settings{BitsNamespace: 17},
+			err:      ErrInvalidBitsNamespace,
 		},
 		{
-			description:    "InterfaceAddrs returns one or more IPs",
-			expected:       1,
-			interfaceAddrs: mock.NewSuccessfulInterfaceAddrs(),
-			error:          "",
+			name:     "failure: namespace id",
+			settings: Settings{BitsNamespace: 4, NamespaceID: 16},
+			err:      ErrInvalidNamespaceID,
+		},
+		{
+			name:     "failure: bits time",
+			settings: Settings{BitsNamespace: 16},
+			err:      ErrInvalidBitsTime,
+		},
+		{
+			name:     "success: namespace id",
+			settings: Settings{BitsNamespace: 4, NamespaceID: 15},
 		},
 	}
-	for _, tc := range testCases {
-		t.Run(tc.description, func(t *testing.T) {
-			actual, err := lower16BitPrivateIP(tc.interfaceAddrs)
 
-			if (err != nil) && (tc.error == "") {
-				t.Errorf("expected no error, but got: %s", err)
-				return
-			} else if (err != nil) && (tc.error != "") {
-				return
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.settings.Validate(); !errors.Is(err, test.err) {
+				t.Errorf("unexpected error: %v", err)
 			}
+		})
+	}
+}
 
-			if actual == tc.expected {
-				return
-			} else {
-				t.Errorf("error: expected: %v, but got: %v", tc.expected, actual)
+func TestFieldOrder(t *testing.T) {
+	st := Settings{
+		StartTime:     time.Now(),
+		MachineID:     func() (uint16, error) { return 0x1234, nil },
+		BitsNamespace: 4,
+		NamespaceID:   5,
+		FieldOrder:    FieldOrderMachineIDFirst,
+	}
+
+	sf, err := New(st)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		id, err := sf.NextID()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		parts := sf.Decompose(id)
+		if id&(1<<BitLenSequence-1) != parts["sequence"] {
+			t.Errorf("sequence not in the low bits: %x", id)
+		}
+		if id>>BitLenSequence&(1<<BitLenMachineID-1) != 0x1234 || parts["machine-id"] != 0x1234 {
+			t.Errorf("unexpected machine id: %x", id)
+		}
+		if parts["namespace"] != 5 {
+			t.Errorf("unexpected namespace: %d", parts["namespace"])
+		}
+	}
+
+	st.FieldOrder = FieldOrder(2)
+	if err := st.Validate(); !errors.Is(err, ErrInvalidFieldOrder) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCompose(t *testing.T) {
+	startTime := time.Date(2014, 9, 1, 0, 0, 0, 0, time.UTC)
+	sf, err := New(Settings{
+		StartTime: startTime,
+		MachineID: func() (uint16, error) { return 1, nil },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	now := time.Now()
+	id, err := sf.Compose(now, 2, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	parts := sf.Decompose(id)
+	if parts["time"] != uint64(now.Sub(startTime)/(10*time.Millisecond)) || parts["sequence"] != 2 || parts["machine-id"] != 3 {
+		t.Errorf("unexpected parts: %v", parts)
+	}
+
+	if _, err := sf.Compose(startTime.Add(-time.Second), 0, 0); !errors.Is(err, ErrStartTimeAhead) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := sf.Compose(now, 1<<BitLenSequence, 0); !errors.Is(err, ErrInvalidSequence) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestComposeBatch(t *testing.T) {
+	startTime := time.Date(2014, 9, 1, 0, 0, 0, 0, time.UTC)
+	sf, err := New(Settings{
+		StartTime: startTime,
+		MachineID: func() (uint16, error) { return 1, nil },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	at := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	n := 1<<BitLenSequence + 10
+	ids, err := sf.ComposeBatch(at, 3, n)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(ids) != n {
+		t.Fatalf("unexpected number of ids: %d", len(ids))
+	}
+
+	first, err := sf.Compose(at, 0, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if ids[0] != first {
+		t.Errorf("unexpected first id: %d, expected %d", ids[0], first)
+	}
+	for i := 1; i < n; i++ {
+		if ids[i] <= ids[i-1] {
+			t.Fatalf("ids not increasing at %d: %d, %d", i, ids[i-1], ids[i])
+		}
+		if MachineID(ids[i]) != 3 {
+			t.Errorf("unexpected machine id: %d", MachineID(ids[i]))
+		}
+	}
+	if parts := sf.Decompose(ids[n-1]); parts["time"] != Decompose(first)["time"]+1 || parts["sequence"] != 9 {
+		t.Errorf("unexpected parts of the last id: %v", parts)
+	}
+
+	if ids, err := sf.ComposeBatch(at, 3, 0); err != nil || len(ids) != 0 {
+		t.Errorf("unexpected result: %v, %v", ids, err)
+	}
+	if _, err := sf.ComposeBatch(at, 3, -1); !errors.Is(err, ErrInvalidBatchSize) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := sf.ComposeBatch(startTime.Add(-time.Second), 3, 1); !errors.Is(err, ErrStartTimeAhead) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestUseMSB(t *testing.T) {
+	startTime := time.Date(2014, 9, 1, 0, 0, 0, 0, time.UTC)
+	st := Settings{
+		StartTime: startTime,
+		MachineID: func() (uint16, error) { return 1, nil },
+	}
+	late := startTime.Add(time.Duration(1<<BitLenTime) * 10 * time.Millisecond)
+
+	sf, err := New(st)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := sf.Compose(late, 0, 1); !errors.Is(err, ErrOverTimeLimit) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	st.UseMSB = true
+	sf, err = New(st)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	id, err := sf.Compose(late, 0, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if id>>63 != 1 {
+		t.Errorf("most significant bit not used: %x", id)
+	}
+	if sf.Decompose(id)["time"] != 1<<BitLenTime {
+		t.Errorf("unexpected time: %d", sf.Decompose(id)["time"])
+	}
+}
+
+func TestRandomSequenceStart(t *testing.T) {
+	sf, err := New(Settings{
+		StartTime:           time.Now().Add(-time.Minute),
+		TimeUnit:            time.Millisecond,
+		MachineID:           func() (uint16, error) { return 1, nil },
+		RandomSequenceStart: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var last uint64
+	starts := make(map[uint64]bool)
+	for i := 0; i < 2000; i++ {
+		id, err := sf.NextID()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if id <= last {
+			t.Fatalf("ids must increase: %d <= %d", id, last)
+		}
+
+		parts := sf.Decompose(id)
+		if last == 0 || parts["time"] != sf.Decompose(last)["time"] {
+			if parts["sequence"] >= 1<<(BitLenSequence-1) {
+				t.Errorf("sequence must start in the lower half: %d", parts["sequence"])
 			}
+			starts[parts["sequence"]] = true
+		}
+		last = id
+	}
+
+	if len(starts) < 2 {
+		t.Errorf("sequence must start at random offsets: %v", starts)
+	}
+}
+
+func TestRandomSequence(t *testing.T) {
+	sf, err := New(Settings{
+		StartTime:      time.Now().Add(-time.Minute),
+		TimeUnit:       time.Millisecond,
+		MachineID:      func() (uint16, error) { return 1, nil },
+		RandomSequence: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var last uint64
+	seen := make(map[uint64]bool)
+	decreased := false
+	for i := 0; i < 2000; i++ {
+		id, err := sf.NextID()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if seen[id] {
+			t.Fatalf("duplicated id: %d", id)
+		}
+		seen[id] = true
+
+		if last != 0 {
+			parts, lastParts := sf.Decompose(id), sf.Decompose(last)
+			if parts["time"] < lastParts["time"] {
+				t.Fatalf("ids must be sorted by time: %d < %d", parts["time"], lastParts["time"])
+			}
+			if parts["time"] == lastParts["time"] && parts["sequence"] < lastParts["sequence"] {
+				decreased = true
+			}
+		}
+		last = id
+	}
+
+	if !decreased {
+		t.Errorf("sequence must be random within a time unit")
+	}
+}
+
+func TestRandomSequenceRestore(t *testing.T) {
+	sf, err := New(Settings{
+		StartTime:      time.Now().Add(-time.Minute),
+		TimeUnit:       time.Millisecond,
+		MachineID:      func() (uint16, error) { return 1, nil },
+		RandomSequence: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	state := State{ElapsedTime: sf.currentElapsedTime() + 10, MachineID: 1}
+	if err := sf.Restore(state); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	id, err := sf.NextID()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if elapsed := int64(sf.Decompose(id)["time"]); elapsed <= state.ElapsedTime {
+		t.Errorf("unexpected time: %d", elapsed)
+	}
+}
+
+func TestBackwardTolerance(t *testing.T) {
+	newSonyflake := func(tolerance time.Duration, onClockBackward func(time.Duration)) *Sonyflake {
+		sf, err := New(Settings{
+			StartTime:         time.Now().Add(-time.Hour),
+			MachineID:         func() (uint16, error) { return 1, nil },
+			TimeUnit:          time.Millisecond,
+			BackwardTolerance: tolerance,
+			OnClockBackward:   onClockBackward,
 		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		return sf
+	}
+	// moveBack makes the clock behind the time of the last ID by the given number of time units
+	moveBack := func(sf *Sonyflake, units int64) {
+		if err := sf.Restore(State{ElapsedTime: sf.currentElapsedTime() + units, MachineID: 1}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	sf := newSonyflake(0, nil)
+	moveBack(sf, 1000)
+	if _, err := sf.NextID(); err != nil {
+		t.Errorf("unexpected error without tolerance: %s", err)
+	}
+
+	sf = newSonyflake(time.Second, nil)
+	moveBack(sf, 500)
+	if _, err := sf.NextID(); err != nil {
+		t.Errorf("unexpected error within tolerance: %s", err)
+	}
+	moveBack(sf, 2000)
+	_, err := sf.NextID()
+	var backward *ClockBackwardError
+	if !errors.As(err, &backward) || !errors.Is(err, ErrClockBackward) ||
+		backward.Backward < 1900*time.Millisecond || backward.Tolerance != time.Second {
+		t.Errorf("unexpected error beyond tolerance: %v", err)
+	}
+
+	called := make(chan time.Duration, 2)
+	sf = newSonyflake(time.Second, func(d time.Duration) { called <- d })
+	moveBack(sf, 2000)
+	for i := 0; i < 2; i++ {
+		if _, err := sf.NextID(); err != nil {
+			t.Errorf("unexpected error with callback: %s", err)
+		}
+	}
+	select {
+	case d := <-called:
+		if d < 1900*time.Millisecond {
+			t.Errorf("unexpected backward movement: %s", d)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("OnClockBackward is not called")
+	}
+	select {
+	case <-called:
+		t.Error("OnClockBackward is called again while the clock is behind")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	if _, err := New(Settings{BackwardTolerance: -1}); err != ErrInvalidTolerance {
+		t.Errorf("unexpected error: %v", err)
 	}
 }
 
-func TestSonyflakeTimeUnit(t *testing.T) {
-	if time.Duration(sonyflakeTimeUnit) != 10*time.Millisecond {
-		t.Errorf("unexpected time unit")
+func TestPaced(t *testing.T) {
+	sf, err := New(Settings{
+		StartTime:    time.Now().Add(-time.Hour),
+		MachineID:    func() (uint16, error) { return 1, nil },
+		BitsSequence: 2,
+		TimeUnit:     100 * time.Millisecond,
+		Paced:        true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// each of the 4 IDs of a time unit has a share of 25 msec
+	layout := sf.Layout()
+	for i := 0; i < 8; i++ {
+		id, err := sf.NextID()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		issued := time.Now()
+
+		sequence := layout.Decompose(id)["sequence"]
+		if earliest := layout.Time(id).Add(time.Duration(sequence) * 25 * time.Millisecond); issued.Before(earliest) {
+			t.Errorf("id of sequence %d issued at %s, before %s", sequence, issued, earliest)
+		}
 	}
 }
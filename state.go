@@ -0,0 +1,86 @@
+package sonyflake
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// ErrInvalidState is returned when a State cannot be decoded or restored.
+var ErrInvalidState = errors.New("invalid state")
+
+// State is a snapshot of the progress of a Sonyflake,
+// which a successor on the same machine ID restores so as not to reissue an earlier tick.
+// A State is meaningful only between Sonyflakes with the same start time and layout.
+type State struct {
+	ElapsedTime int64
+	Sequence    uint32
+	MachineID   uint16
+}
+
+const stateVersion = 1
+
+const stateLen = 1 + 8 + 4 + 2
+
+// MarshalBinary encodes the State in a compact binary form.
+func (s State) MarshalBinary() ([]byte, error) {
+	b := make([]byte, stateLen)
+	b[0] = stateVersion
+	binary.BigEndian.PutUint64(b[1:9], uint64(s.ElapsedTime))
+	binary.BigEndian.PutUint32(b[9:13], s.Sequence)
+	binary.BigEndian.PutUint16(b[13:15], s.MachineID)
+	return b, nil
+}
+
+// UnmarshalBinary decodes the State encoded by MarshalBinary.
+func (s *State) UnmarshalBinary(b []byte) error {
+	if len(b) != stateLen || b[0] != stateVersion {
+		return ErrInvalidState
+	}
+
+	s.ElapsedTime = int64(binary.BigEndian.Uint64(b[1:9]))
+	s.Sequence = binary.BigEndian.Uint32(b[9:13])
+	s.MachineID = binary.BigEndian.Uint16(b[13:15])
+	return nil
+}
+
+// Snapshot returns the current State of the Sonyflake.
+func (sf *Sonyflake) Snapshot() State {
+	sf.mutex.Lock()
+	defer sf.mutex.Unlock()
+
+	return State{
+		ElapsedTime: sf.elapsedTime,
+		Sequence:    sf.sequence,
+		MachineID:   sf.machineID,
+	}
+}
+
+// Restore advances the Sonyflake to the given State taken by Snapshot of its predecessor,
+// so that the Sonyflake never issues an ID at or before the last one issued by the predecessor.
+// Restore never moves the Sonyflake backwards.
+// With Settings.RandomSequence, Restore skips the time unit of the State since its used sequence numbers are unknown.
+// Restore returns ErrInvalidState if the State has a different machine ID or a sequence number out of range.
+func (sf *Sonyflake) Restore(state State) error {
+	sf.mutex.Lock()
+	defer sf.mutex.Unlock()
+
+	if state.MachineID != sf.machineID || uint64(state.Sequence) >= 1<<sf.bitsSequence {
+		return ErrInvalidState
+	}
+
+	if sf.randomSequence {
+		// the sequence numbers used by the predecessor are unknown, so skip its last time unit
+		if state.ElapsedTime >= sf.elapsedTime {
+			sf.elapsedTime = state.ElapsedTime
+			sf.usedSequences = nil
+		}
+		return nil
+	}
+
+	if state.ElapsedTime > sf.elapsedTime ||
+		state.ElapsedTime == sf.elapsedTime && state.Sequence > sf.sequence {
+		sf.elapsedTime = state.ElapsedTime
+		sf.sequence = state.Sequence
+	}
+	return nil
+}
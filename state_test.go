@@ -0,0 +1,61 @@
+package sonyflake
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestState(t *testing.T) {
+	st := Settings{MachineID: func() (uint16, error) { return 1, nil }}
+
+	predecessor, err := New(st)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var last uint64
+	for i := 0; i < 1000; i++ {
+		last, err = predecessor.NextID()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	b, err := predecessor.Snapshot().MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var state State
+	if err := state.UnmarshalBinary(b); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if state != predecessor.Snapshot() {
+		t.Errorf("unexpected state: %+v", state)
+	}
+
+	successor, err := New(st)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := successor.Restore(state); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	id, err := successor.NextID()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if id <= last {
+		t.Errorf("reissued an earlier id: %d <= %d", id, last)
+	}
+
+	state.MachineID = 2
+	if err := successor.Restore(state); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := state.UnmarshalBinary(b[1:]); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
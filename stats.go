@@ -0,0 +1,61 @@
+package sonyflake
+
+import "time"
+
+// SleepBuckets are the upper bounds of the buckets of Stats.Sleeps.
+var SleepBuckets = [...]time.Duration{
+	time.Millisecond,
+	2 * time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	20 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	200 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+// Stats are the statistics of a Sonyflake since it was created.
+//
+// Generated is the number of IDs generated by NextID.
+//
+// Exhausted is the number of times NextID ran out of sequence numbers in a time unit
+// and waited for the next time unit.
+//
+// Ticks is the number of time units in which IDs were generated, excluding the current one.
+//
+// Utilization is the fraction of the sequence numbers consumed per time unit,
+// averaged over the recent Ticks with more weight on later ones.
+// Utilization close to 1 means that BitsSequence or TimeUnit needs adjusting.
+//
+// Sleeps is the histogram of how long NextID slept after running out of sequence numbers,
+// which appears as tail latency of callers.
+// Sleeps[i] counts the sleeps up to SleepBuckets[i] and longer than the previous bound,
+// and the last element counts the sleeps longer than all the bounds.
+// SleepTime is the total time of the sleeps.
+type Stats struct {
+	Generated   uint64
+	Exhausted   uint64
+	Ticks       uint64
+	Utilization float64
+	Sleeps      [len(SleepBuckets) + 1]uint64
+	SleepTime   time.Duration
+}
+
+// Stats returns the Stats of the Sonyflake.
+func (sf *Sonyflake) Stats() Stats {
+	sf.mutex.Lock()
+	defer sf.mutex.Unlock()
+
+	return sf.stats
+}
+
+func (s *Stats) observeSleep(d time.Duration) {
+	i := 0
+	for i < len(SleepBuckets) && d > SleepBuckets[i] {
+		i++
+	}
+	s.Sleeps[i]++
+	s.SleepTime += d
+}
@@ -0,0 +1,107 @@
+package sonyflake
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	sf, err := New(Settings{
+		StartTime:    time.Now(),
+		MachineID:    func() (uint16, error) { return 1, nil },
+		BitsSequence: 1,
+		TimeUnit:     100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if stats := sf.Stats(); stats != (Stats{}) {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err := sf.NextID(); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	stats := sf.Stats()
+	if stats.Generated != 5 {
+		t.Errorf("unexpected number of generated ids: %d", stats.Generated)
+	}
+	// 5 IDs with 2 sequence numbers per time unit take 3 time units at least
+	if stats.Exhausted < 1 {
+		t.Errorf("unexpected number of exhaustions: %d", stats.Exhausted)
+	}
+
+	var sleeps uint64
+	for _, n := range stats.Sleeps {
+		sleeps += n
+	}
+	if sleeps != stats.Exhausted || stats.SleepTime <= 0 || stats.SleepTime > time.Duration(stats.Exhausted)*100*time.Millisecond {
+		t.Errorf("unexpected sleeps: %v in %s", stats.Sleeps, stats.SleepTime)
+	}
+}
+
+func TestObserveSleep(t *testing.T) {
+	var stats Stats
+	stats.observeSleep(time.Millisecond)
+	stats.observeSleep(3 * time.Millisecond)
+	stats.observeSleep(2 * time.Second)
+
+	expected := Stats{SleepTime: 2*time.Second + 4*time.Millisecond}
+	expected.Sleeps[0] = 1
+	expected.Sleeps[2] = 1
+	expected.Sleeps[len(SleepBuckets)] = 1
+	if stats != expected {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestUtilization(t *testing.T) {
+	quota := make(chan float64, 1)
+	sf, err := New(Settings{
+		StartTime:          time.Now(),
+		MachineID:          func() (uint16, error) { return 1, nil },
+		BitsSequence:       2,
+		TimeUnit:           time.Millisecond,
+		UtilizationQuota:   0.5,
+		OnUtilizationQuota: func(utilization float64) { quota <- utilization },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// every time unit is used up
+	for i := 0; i < 40; i++ {
+		if _, err := sf.NextID(); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	stats := sf.Stats()
+	if stats.Ticks < 9 || stats.Utilization <= 0.5 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	select {
+	case utilization := <-quota:
+		if utilization <= 0.5 {
+			t.Errorf("unexpected utilization: %v", utilization)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("quota not exceeded")
+	}
+	select {
+	case <-quota:
+		t.Errorf("quota exceeded twice")
+	default:
+	}
+}
+
+func TestInvalidQuota(t *testing.T) {
+	if _, err := New(Settings{UtilizationQuota: 1.5}); err != ErrInvalidQuota {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
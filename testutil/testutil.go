@@ -0,0 +1,54 @@
+// Package testutil provides assertions on Sonyflake IDs for tests of integrations,
+// such as those with a custom MachineID or a mocked clock,
+// so that downstream tests need not decompose IDs by themselves.
+//
+// The assertions report failures by Errorf of the given testing.TB and continue the test.
+package testutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sony/sonyflake"
+)
+
+// AssertMonotonic asserts that the given IDs are strictly increasing,
+// as the IDs issued one after another by a Sonyflake are.
+// It reports the first pair of IDs out of order.
+func AssertMonotonic(t testing.TB, ids []uint64) {
+	t.Helper()
+
+	for i := 1; i < len(ids); i++ {
+		if ids[i] <= ids[i-1] {
+			t.Errorf("ids are not increasing: ids[%d] = %d, ids[%d] = %d", i-1, ids[i-1], i, ids[i])
+			return
+		}
+	}
+}
+
+// AssertUniqueMachine asserts that all of the given IDs in the given Layout carry the given machine ID,
+// as the IDs issued by a single Sonyflake do.
+// It reports the first ID of another machine ID.
+func AssertUniqueMachine(t testing.TB, l sonyflake.Layout, ids []uint64, machineID uint16) {
+	t.Helper()
+
+	for i, id := range ids {
+		if got := l.Decompose(id)["machine-id"]; got != uint64(machineID) {
+			t.Errorf("ids[%d] = %d has machine id %d, expected %d", i, id, got, machineID)
+			return
+		}
+	}
+}
+
+// AssertWithinWindow asserts that the given ID in the given Layout was issued from the time from to the time to.
+// Since IDs carry no precision below the time unit, from is truncated to the time unit.
+func AssertWithinWindow(t testing.TB, l sonyflake.Layout, id uint64, from, to time.Time) {
+	t.Helper()
+
+	at := l.Time(id)
+	start := l.StartTime.Add(from.Sub(l.StartTime) / l.TimeUnit * l.TimeUnit)
+	if at.Before(start) || at.After(to) {
+		t.Errorf("id %d was issued at %s, out of %s to %s", id, at.Format(time.RFC3339Nano),
+			from.Format(time.RFC3339Nano), to.Format(time.RFC3339Nano))
+	}
+}
@@ -0,0 +1,99 @@
+package testutil
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sony/sonyflake"
+)
+
+// recorder records the failures reported to it instead of failing the test.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func newSonyflake(t *testing.T, machineID uint16) *sonyflake.Sonyflake {
+	sf, err := sonyflake.New(sonyflake.Settings{
+		StartTime: time.Now().Add(-time.Hour),
+		MachineID: func() (uint16, error) { return machineID, nil },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return sf
+}
+
+func nextIDs(t *testing.T, sf *sonyflake.Sonyflake, n int) []uint64 {
+	ids := make([]uint64, n)
+	for i := range ids {
+		id, err := sf.NextID()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		ids[i] = id
+	}
+	return ids
+}
+
+func TestAssertMonotonic(t *testing.T) {
+	ids := nextIDs(t, newSonyflake(t, 1), 1000)
+
+	r := &recorder{TB: t}
+	AssertMonotonic(r, ids)
+	AssertMonotonic(r, nil)
+	if len(r.errors) != 0 {
+		t.Errorf("unexpected failures: %v", r.errors)
+	}
+
+	ids[500], ids[501] = ids[501], ids[500]
+	AssertMonotonic(r, ids)
+	expected := fmt.Sprintf("ids are not increasing: ids[500] = %d, ids[501] = %d", ids[500], ids[501])
+	if len(r.errors) != 1 || r.errors[0] != expected {
+		t.Errorf("unexpected failures: %v", r.errors)
+	}
+}
+
+func TestAssertUniqueMachine(t *testing.T) {
+	sf := newSonyflake(t, 1)
+	ids := nextIDs(t, sf, 10)
+
+	r := &recorder{TB: t}
+	AssertUniqueMachine(r, sf.Layout(), ids, 1)
+	if len(r.errors) != 0 {
+		t.Errorf("unexpected failures: %v", r.errors)
+	}
+
+	ids = append(ids, nextIDs(t, newSonyflake(t, 2), 1)...)
+	AssertUniqueMachine(r, sf.Layout(), ids, 1)
+	expected := fmt.Sprintf("ids[10] = %d has machine id 2, expected 1", ids[10])
+	if len(r.errors) != 1 || r.errors[0] != expected {
+		t.Errorf("unexpected failures: %v", r.errors)
+	}
+}
+
+func TestAssertWithinWindow(t *testing.T) {
+	sf := newSonyflake(t, 1)
+	from := time.Now()
+	id := nextIDs(t, sf, 1)[0]
+	to := time.Now()
+
+	r := &recorder{TB: t}
+	AssertWithinWindow(r, sf.Layout(), id, from, to)
+	if len(r.errors) != 0 {
+		t.Errorf("unexpected failures: %v", r.errors)
+	}
+
+	AssertWithinWindow(r, sf.Layout(), id, to.Add(time.Second), to.Add(time.Minute))
+	AssertWithinWindow(r, sf.Layout(), id, from.Add(-time.Minute), from.Add(-time.Second))
+	if len(r.errors) != 2 {
+		t.Errorf("unexpected failures: %v", r.errors)
+	}
+}
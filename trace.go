@@ -0,0 +1,52 @@
+package sonyflake
+
+import "time"
+
+// TraceKind is the kind of a TraceEvent.
+type TraceKind int
+
+const (
+	// TraceRollover is traced when NextID runs out of sequence numbers and moves on to the next time unit.
+	TraceRollover TraceKind = iota
+	// TraceSleep is traced when NextID sleeps until the time unit of the next ID comes.
+	TraceSleep
+	// TraceClockBackward is traced when the current time is behind the time unit of the last ID,
+	// such as after the clock was set back.
+	TraceClockBackward
+)
+
+var traceKindNames = map[TraceKind]string{
+	TraceRollover:      "rollover",
+	TraceSleep:         "sleep",
+	TraceClockBackward: "clock-backward",
+}
+
+// String returns the name of the TraceKind, such as "rollover".
+func (k TraceKind) String() string {
+	if name, ok := traceKindNames[k]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// TraceEvent is an event traced by Settings.Trace.
+//
+// Time is when the event occurred.
+//
+// ElapsedTime is the time unit of the next ID since the start time.
+//
+// Duration is how long NextID sleeps for TraceSleep,
+// or how far the current time is behind ElapsedTime for TraceClockBackward.
+type TraceEvent struct {
+	Kind        TraceKind
+	Time        time.Time
+	ElapsedTime int64
+	Duration    time.Duration
+}
+
+func (sf *Sonyflake) trace(kind TraceKind, d time.Duration) {
+	if sf.traceFunc == nil {
+		return
+	}
+	sf.traceFunc(TraceEvent{Kind: kind, Time: time.Now(), ElapsedTime: sf.elapsedTime, Duration: d})
+}
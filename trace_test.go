@@ -0,0 +1,55 @@
+package sonyflake
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrace(t *testing.T) {
+	var events []TraceEvent
+	sf, err := New(Settings{
+		StartTime:    time.Now(),
+		MachineID:    func() (uint16, error) { return 1, nil },
+		BitsSequence: 1,
+		TimeUnit:     time.Millisecond,
+		Trace:        func(e TraceEvent) { events = append(events, e) },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err := sf.NextID(); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if len(events) < 2 {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+	for i := 0; i+1 < len(events); i += 2 {
+		if events[i].Kind != TraceRollover || events[i+1].Kind != TraceSleep {
+			t.Errorf("unexpected events: %v, %v", events[i].Kind, events[i+1].Kind)
+		}
+		if events[i+1].Duration > time.Millisecond {
+			t.Errorf("unexpected sleep: %s", events[i+1].Duration)
+		}
+	}
+
+	events = nil
+	if err := sf.Restore(State{ElapsedTime: sf.currentElapsedTime() + 10, MachineID: 1}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := sf.NextID(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(events) == 0 || events[0].Kind != TraceClockBackward || events[0].Duration < 8*time.Millisecond {
+		t.Errorf("unexpected events: %+v", events)
+	}
+}
+
+func TestTraceKindString(t *testing.T) {
+	if s := TraceClockBackward.String(); s != "clock-backward" {
+		t.Errorf("unexpected string: %s", s)
+	}
+}
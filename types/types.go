@@ -6,3 +6,9 @@ import "net"
 
 // InterfaceAddrs defines the interface used for retrieving network addresses
 type InterfaceAddrs func() ([]net.Addr, error)
+
+// InterfaceAddrsByName defines the interface used for retrieving network addresses of a named interface
+type InterfaceAddrsByName func(name string) ([]net.Addr, error)
+
+// Interfaces defines the interface used for retrieving network interfaces
+type Interfaces func() ([]net.Interface, error)
@@ -0,0 +1,161 @@
+// Package vectors generates test vectors of Sonyflake IDs,
+// so that ports of Sonyflake in other languages can validate bit-exact compatibility with this package.
+//
+// A test vector consists of the settings of a layout, the components of an ID, and the expected ID.
+// The set of test vectors is versioned, and Version is incremented whenever vectors are changed or removed.
+package vectors
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/sony/sonyflake"
+)
+
+// Version is the version of the set of test vectors.
+const Version = 1
+
+// Set is a versioned set of test vectors.
+type Set struct {
+	Version int      `json:"version"`
+	Vectors []Vector `json:"vectors"`
+}
+
+// Vector is a test vector.
+type Vector struct {
+	Name       string     `json:"name"`
+	Settings   Settings   `json:"settings"`
+	Components Components `json:"components"`
+	ID         string     `json:"id"`
+}
+
+// Settings is the layout of a test vector.
+// TimeUnit is in nanoseconds, and FieldOrder is "sequence-first" or "machine-id-first".
+type Settings struct {
+	StartTime     string `json:"start_time"`
+	TimeUnit      int64  `json:"time_unit"`
+	BitsTime      int    `json:"bits_time"`
+	BitsSequence  int    `json:"bits_sequence"`
+	BitsNamespace int    `json:"bits_namespace"`
+	BitsMachineID int    `json:"bits_machine_id"`
+	FieldOrder    string `json:"field_order"`
+	UseMSB        bool   `json:"use_msb"`
+}
+
+// Components are the parts of the ID of a test vector.
+// ElapsedTime is in units of Settings.TimeUnit since Settings.StartTime, and Time is the corresponding time.
+type Components struct {
+	ElapsedTime uint64 `json:"elapsed_time"`
+	Time        string `json:"time"`
+	Sequence    uint64 `json:"sequence"`
+	NamespaceID uint64 `json:"namespace_id"`
+	MachineID   uint64 `json:"machine_id"`
+}
+
+type layout struct {
+	name     string
+	settings sonyflake.Settings
+}
+
+var startTime = time.Date(2014, 9, 1, 0, 0, 0, 0, time.UTC)
+
+var layouts = []layout{
+	{"default", sonyflake.PresetDefault()},
+	{"high-throughput", sonyflake.PresetHighThroughput()},
+	{"long-lifetime", sonyflake.PresetLongLifetime()},
+	{"namespace", sonyflake.Settings{BitsSequence: 8, BitsNamespace: 4, BitsMachineID: 12}},
+	{"machine-id-first", sonyflake.Settings{
+		BitsSequence:  12,
+		BitsNamespace: 2,
+		BitsMachineID: 8,
+		TimeUnit:      time.Millisecond,
+		FieldOrder:    sonyflake.FieldOrderMachineIDFirst,
+	}},
+	{"use-msb", sonyflake.Settings{UseMSB: true}},
+	{"custom-start-time", sonyflake.Settings{StartTime: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}},
+}
+
+// Generate returns the set of test vectors.
+func Generate() Set {
+	set := Set{Version: Version}
+	for _, l := range layouts {
+		st := l.settings
+		if st.StartTime.IsZero() {
+			st.StartTime = startTime
+		}
+		lo, err := st.Layout()
+		if err != nil {
+			panic(err)
+		}
+
+		maxTime := uint64(1<<lo.BitsTime - 1)
+		maxSequence := uint64(1<<lo.BitsSequence - 1)
+		maxNamespace := uint64(1<<lo.BitsNamespace - 1)
+		maxMachineID := uint64(1<<lo.BitsMachineID - 1)
+
+		cases := []struct {
+			name string
+			c    Components
+		}{
+			{"zero", Components{}},
+			{"one", Components{ElapsedTime: 1, Sequence: 1, NamespaceID: 1 & maxNamespace, MachineID: 1}},
+			{"max", Components{ElapsedTime: maxTime, Sequence: maxSequence, NamespaceID: maxNamespace, MachineID: maxMachineID}},
+			{"mixed", Components{
+				ElapsedTime: 0x2a5a5a5a5a & maxTime,
+				Sequence:    0x5a5 & maxSequence,
+				NamespaceID: 0xa & maxNamespace,
+				MachineID:   0xa5c3 & maxMachineID,
+			}},
+		}
+		for _, c := range cases {
+			set.Vectors = append(set.Vectors, newVector(l.name+"/"+c.name, lo, c.c))
+		}
+	}
+	return set
+}
+
+func newVector(name string, l sonyflake.Layout, c Components) Vector {
+	fieldOrder := "sequence-first"
+	shiftMachineID := 0
+	shiftNamespace := l.BitsMachineID
+	shiftSequence := shiftNamespace + l.BitsNamespace
+	if l.FieldOrder == sonyflake.FieldOrderMachineIDFirst {
+		fieldOrder = "machine-id-first"
+		shiftSequence = 0
+		shiftMachineID = l.BitsSequence
+		shiftNamespace = shiftMachineID + l.BitsMachineID
+	}
+	shiftTime := l.BitsSequence + l.BitsNamespace + l.BitsMachineID
+
+	id := c.ElapsedTime<<shiftTime |
+		c.Sequence<<shiftSequence |
+		c.NamespaceID<<shiftNamespace |
+		c.MachineID<<shiftMachineID
+	c.Time = l.Time(id).Format(time.RFC3339Nano)
+
+	return Vector{
+		Name: name,
+		Settings: Settings{
+			StartTime:     l.StartTime.Format(time.RFC3339Nano),
+			TimeUnit:      int64(l.TimeUnit),
+			BitsTime:      l.BitsTime,
+			BitsSequence:  l.BitsSequence,
+			BitsNamespace: l.BitsNamespace,
+			BitsMachineID: l.BitsMachineID,
+			FieldOrder:    fieldOrder,
+			UseMSB:        l.UseMSB,
+		},
+		Components: c,
+		ID:         strconv.FormatUint(id, 10),
+	}
+}
+
+// Write writes the set of test vectors to w in indented JSON.
+// IDs are written as decimal strings, since JSON numbers of many languages cannot represent 64-bit integers.
+func Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(Generate())
+}
@@ -0,0 +1,118 @@
+package vectors
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sony/sonyflake"
+)
+
+func TestGenerate(t *testing.T) {
+	set := Generate()
+	if set.Version != Version {
+		t.Errorf("unexpected version: %d", set.Version)
+	}
+	if len(set.Vectors) != len(layouts)*4 {
+		t.Errorf("unexpected number of vectors: %d", len(set.Vectors))
+	}
+
+	names := make(map[string]bool)
+	for _, v := range set.Vectors {
+		if names[v.Name] {
+			t.Errorf("duplicated name: %s", v.Name)
+		}
+		names[v.Name] = true
+
+		id, err := strconv.ParseUint(v.ID, 10, 64)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", v.Name, err)
+		}
+
+		startTime, err := time.Parse(time.RFC3339Nano, v.Settings.StartTime)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", v.Name, err)
+		}
+		st := sonyflake.Settings{
+			StartTime:     startTime,
+			MachineID:     func() (uint16, error) { return uint16(v.Components.MachineID), nil },
+			BitsSequence:  v.Settings.BitsSequence,
+			BitsNamespace: v.Settings.BitsNamespace,
+			NamespaceID:   uint16(v.Components.NamespaceID),
+			BitsMachineID: v.Settings.BitsMachineID,
+			TimeUnit:      time.Duration(v.Settings.TimeUnit),
+			UseMSB:        v.Settings.UseMSB,
+		}
+		if v.Settings.FieldOrder == "machine-id-first" {
+			st.FieldOrder = sonyflake.FieldOrderMachineIDFirst
+		}
+
+		layout, err := st.Layout()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", v.Name, err)
+		}
+		if layout.BitsTime != v.Settings.BitsTime {
+			t.Errorf("%s: unexpected bits of time: %d", v.Name, v.Settings.BitsTime)
+		}
+
+		parts := layout.Decompose(id)
+		if parts["time"] != v.Components.ElapsedTime ||
+			parts["sequence"] != v.Components.Sequence ||
+			parts["namespace"] != v.Components.NamespaceID ||
+			parts["machine-id"] != v.Components.MachineID {
+			t.Errorf("%s: unexpected parts: %v", v.Name, parts)
+		}
+
+		at, err := time.Parse(time.RFC3339Nano, v.Components.Time)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", v.Name, err)
+		}
+		if at.Year() >= 2262 {
+			continue // out of the range of Compose
+		}
+
+		sf, err := sonyflake.New(st)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", v.Name, err)
+		}
+		composed, err := sf.Compose(at, uint32(v.Components.Sequence), uint16(v.Components.MachineID))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", v.Name, err)
+		}
+		if composed != id {
+			t.Errorf("%s: unexpected id: %s, composed %d", v.Name, v.ID, composed)
+		}
+	}
+}
+
+func TestGenerateGolden(t *testing.T) {
+	golden := map[string]string{
+		"default/zero": "0",
+		"default/one":  "16842753",
+		"default/max":  "9223372036854775807",
+		"use-msb/max":  "18446744073709551615",
+	}
+
+	for _, v := range Generate().Vectors {
+		if expected, ok := golden[v.Name]; ok && v.ID != expected {
+			t.Errorf("%s: unexpected id: %s, expected %s", v.Name, v.ID, expected)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var b bytes.Buffer
+	if err := Write(&b); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var set Set
+	if err := json.Unmarshal(b.Bytes(), &set); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if set.Version != Version || len(set.Vectors) != len(Generate().Vectors) {
+		t.Errorf("unexpected set: %+v", set)
+	}
+}